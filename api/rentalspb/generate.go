@@ -0,0 +1,4 @@
+// Package rentalspb holds the protobuf messages and gRPC service generated from rentals.proto.
+package rentalspb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative rentals.proto
@@ -0,0 +1,213 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: rentals.proto
+
+package rentalspb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	RentalService_GetRental_FullMethodName   = "/rentals.v1.RentalService/GetRental"
+	RentalService_ListRentals_FullMethodName = "/rentals.v1.RentalService/ListRentals"
+	RentalService_SearchNear_FullMethodName  = "/rentals.v1.RentalService/SearchNear"
+)
+
+// RentalServiceClient is the client API for RentalService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RentalServiceClient interface {
+	GetRental(ctx context.Context, in *GetRentalRequest, opts ...grpc.CallOption) (*Rental, error)
+	// ListRentals streams the rentals matching the request, one message per rental.
+	ListRentals(ctx context.Context, in *ListRentalsRequest, opts ...grpc.CallOption) (RentalService_ListRentalsClient, error)
+	SearchNear(ctx context.Context, in *SearchNearRequest, opts ...grpc.CallOption) (*SearchNearResponse, error)
+}
+
+type rentalServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRentalServiceClient(cc grpc.ClientConnInterface) RentalServiceClient {
+	return &rentalServiceClient{cc}
+}
+
+func (c *rentalServiceClient) GetRental(ctx context.Context, in *GetRentalRequest, opts ...grpc.CallOption) (*Rental, error) {
+	out := new(Rental)
+	err := c.cc.Invoke(ctx, RentalService_GetRental_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rentalServiceClient) ListRentals(ctx context.Context, in *ListRentalsRequest, opts ...grpc.CallOption) (RentalService_ListRentalsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &RentalService_ServiceDesc.Streams[0], RentalService_ListRentals_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &rentalServiceListRentalsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type RentalService_ListRentalsClient interface {
+	Recv() (*Rental, error)
+	grpc.ClientStream
+}
+
+type rentalServiceListRentalsClient struct {
+	grpc.ClientStream
+}
+
+func (x *rentalServiceListRentalsClient) Recv() (*Rental, error) {
+	m := new(Rental)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *rentalServiceClient) SearchNear(ctx context.Context, in *SearchNearRequest, opts ...grpc.CallOption) (*SearchNearResponse, error) {
+	out := new(SearchNearResponse)
+	err := c.cc.Invoke(ctx, RentalService_SearchNear_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RentalServiceServer is the server API for RentalService service.
+// All implementations must embed UnimplementedRentalServiceServer
+// for forward compatibility
+type RentalServiceServer interface {
+	GetRental(context.Context, *GetRentalRequest) (*Rental, error)
+	// ListRentals streams the rentals matching the request, one message per rental.
+	ListRentals(*ListRentalsRequest, RentalService_ListRentalsServer) error
+	SearchNear(context.Context, *SearchNearRequest) (*SearchNearResponse, error)
+	mustEmbedUnimplementedRentalServiceServer()
+}
+
+// UnimplementedRentalServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedRentalServiceServer struct {
+}
+
+func (UnimplementedRentalServiceServer) GetRental(context.Context, *GetRentalRequest) (*Rental, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRental not implemented")
+}
+func (UnimplementedRentalServiceServer) ListRentals(*ListRentalsRequest, RentalService_ListRentalsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListRentals not implemented")
+}
+func (UnimplementedRentalServiceServer) SearchNear(context.Context, *SearchNearRequest) (*SearchNearResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchNear not implemented")
+}
+func (UnimplementedRentalServiceServer) mustEmbedUnimplementedRentalServiceServer() {}
+
+// UnsafeRentalServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RentalServiceServer will
+// result in compilation errors.
+type UnsafeRentalServiceServer interface {
+	mustEmbedUnimplementedRentalServiceServer()
+}
+
+func RegisterRentalServiceServer(s grpc.ServiceRegistrar, srv RentalServiceServer) {
+	s.RegisterService(&RentalService_ServiceDesc, srv)
+}
+
+func _RentalService_GetRental_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRentalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RentalServiceServer).GetRental(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RentalService_GetRental_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RentalServiceServer).GetRental(ctx, req.(*GetRentalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RentalService_ListRentals_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListRentalsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RentalServiceServer).ListRentals(m, &rentalServiceListRentalsServer{stream})
+}
+
+type RentalService_ListRentalsServer interface {
+	Send(*Rental) error
+	grpc.ServerStream
+}
+
+type rentalServiceListRentalsServer struct {
+	grpc.ServerStream
+}
+
+func (x *rentalServiceListRentalsServer) Send(m *Rental) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _RentalService_SearchNear_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchNearRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RentalServiceServer).SearchNear(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RentalService_SearchNear_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RentalServiceServer).SearchNear(ctx, req.(*SearchNearRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RentalService_ServiceDesc is the grpc.ServiceDesc for RentalService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RentalService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "rentals.v1.RentalService",
+	HandlerType: (*RentalServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetRental",
+			Handler:    _RentalService_GetRental_Handler,
+		},
+		{
+			MethodName: "SearchNear",
+			Handler:    _RentalService_SearchNear_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListRentals",
+			Handler:       _RentalService_ListRentals_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "rentals.proto",
+}
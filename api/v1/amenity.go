@@ -0,0 +1,8 @@
+package v1
+
+// Amenity is an entry of the catalogue of amenities, Code is what rentals list and filter by.
+type Amenity struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+}
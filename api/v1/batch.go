@@ -0,0 +1,18 @@
+package v1
+
+// BatchGetRequest is the request body of POST /v1/rentals:batchGet.
+type BatchGetRequest struct {
+	IDs []int `json:"ids"`
+}
+
+type BatchGetResponse struct {
+	// Results has one entry per requested id, in the order of the request.
+	Results []BatchGetResult `json:"results"`
+}
+
+// BatchGetResult holds the rental with ID, or the code of the error when it could not be returned.
+type BatchGetResult struct {
+	ID     int     `json:"id"`
+	Rental *Rental `json:"rental,omitempty"`
+	Error  string  `json:"error,omitempty"`
+}
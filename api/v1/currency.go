@@ -0,0 +1,13 @@
+package v1
+
+import "time"
+
+// DefaultCurrency is the currency of rentals created without one and the base of the exchange rates.
+const DefaultCurrency = "USD"
+
+// ExchangeRate is the amount of Currency worth one unit of DefaultCurrency.
+type ExchangeRate struct {
+	Currency string    `json:"currency"`
+	Rate     float64   `json:"rate"`
+	Updated  time.Time `json:"updated,omitempty"`
+}
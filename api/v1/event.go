@@ -0,0 +1,25 @@
+package v1
+
+import "time"
+
+// Types of the rental change events published from the outbox.
+const (
+	EventRentalCreated = "rental.created"
+	EventRentalUpdated = "rental.updated"
+	EventRentalDeleted = "rental.deleted"
+	// EventRentalRemoved is only sent on filtered streams, for an update moving a rental out of
+	// the filters. Its Rental is the rental after the change.
+	EventRentalRemoved = "rental.removed"
+)
+
+// RentalEvent is published for every committed change of a rental. Rental is the rental after the
+// change, or before it for rental.deleted. Events are published at least once. ID increases with
+// every event and can be used to drop duplicates or to restore the order of changes.
+type RentalEvent struct {
+	ID       int64     `json:"id"`
+	Type     string    `json:"type"`
+	RentalID int       `json:"rental_id"`
+	UserID   int       `json:"user_id"`
+	Occurred time.Time `json:"occurred"`
+	Rental   Rental    `json:"rental"`
+}
@@ -0,0 +1,38 @@
+package v1
+
+// RentalImage is an image of the gallery of a rental. Position orders the gallery from 0, the
+// image at position 0 is the primary image of the rental. Width and Height are in pixels.
+// Uploaded images have Variants, smaller renderings ordered by width.
+type RentalImage struct {
+	ID       int            `json:"id"`
+	URL      string         `json:"url"`
+	Position int            `json:"position"`
+	Caption  string         `json:"caption"`
+	Width    *int           `json:"width,omitempty"`
+	Height   *int           `json:"height,omitempty"`
+	Variants []ImageVariant `json:"variants,omitempty"`
+}
+
+// ImageVariant is a rendering of an uploaded image scaled down to Width, named like "w480".
+type ImageVariant struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// RentalImageInput is the request body for adding an image. The image is added at the end of the
+// gallery unless Position is given, the images from Position on move one position back.
+type RentalImageInput struct {
+	URL      string `json:"url"`
+	Position *int   `json:"position,omitempty"`
+	Caption  string `json:"caption"`
+	Width    *int   `json:"width,omitempty"`
+	Height   *int   `json:"height,omitempty"`
+}
+
+// ImageOrder is the request body for reordering the gallery of a rental. ImageIDs lists every
+// image of the rental in the new order.
+type ImageOrder struct {
+	ImageIDs []int `json:"image_ids"`
+}
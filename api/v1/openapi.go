@@ -0,0 +1,8 @@
+package v1
+
+import _ "embed"
+
+// OpenAPI is the OpenAPI 3 document describing the v1 endpoints.
+//
+//go:embed openapi.json
+var OpenAPI []byte
@@ -0,0 +1,52 @@
+package v1
+
+// Kinds of the line items of a Quote.
+const (
+	QuoteItemNights           = "nights"
+	QuoteItemSeasonNights     = "season_nights"
+	QuoteItemWeekendSurcharge = "weekend_surcharge"
+	QuoteItemWeeklyDiscount   = "weekly_discount"
+	QuoteItemMonthlyDiscount  = "monthly_discount"
+)
+
+// PricingRules adjust the price per day of a rental. Dates are formatted as 2006-01-02 and prices
+// are in cents.
+type PricingRules struct {
+	Seasons []Season `json:"seasons"`
+	// WeekendSurchargePercent is added to the rate of Friday and Saturday nights.
+	WeekendSurchargePercent int `json:"weekend_surcharge_percent"`
+	// WeeklyDiscountPercent is taken off stays of 7 nights or more.
+	WeeklyDiscountPercent int `json:"weekly_discount_percent"`
+	// MonthlyDiscountPercent is taken off stays of 28 nights or more, instead of the weekly discount.
+	MonthlyDiscountPercent int `json:"monthly_discount_percent"`
+	MinNights              int `json:"min_nights"`
+}
+
+// Season replaces the price per day for the nights from Start to End, both included.
+type Season struct {
+	Name        string `json:"name"`
+	Start       string `json:"start"`
+	End         string `json:"end"`
+	PricePerDay int    `json:"price_per_day"`
+	// MinNights applies to stays with a night in the season, when greater than the minimum of the rental.
+	MinNights int `json:"min_nights,omitempty"`
+}
+
+// Quote is the price of the nights from From to To, the day of departure. Total is the sum of the
+// line items, discounts have negative amounts.
+type Quote struct {
+	RentalID  int             `json:"rental_id"`
+	From      string          `json:"from"`
+	To        string          `json:"to"`
+	Nights    int             `json:"nights"`
+	LineItems []QuoteLineItem `json:"line_items"`
+	Total     int             `json:"total"`
+}
+
+type QuoteLineItem struct {
+	Kind        string `json:"kind"`
+	Description string `json:"description"`
+	Quantity    int    `json:"quantity"`
+	UnitPrice   int    `json:"unit_price,omitempty"`
+	Amount      int    `json:"amount"`
+}
@@ -0,0 +1,30 @@
+package v1
+
+// ProblemContentType is the media type of error responses (RFC 7807).
+const ProblemContentType = "application/problem+json"
+
+// Stable, machine-readable error codes returned in Problem.Code.
+const (
+	ErrCodeInvalidParameter = "invalid_parameter"
+	ErrCodeInvalidBody      = "invalid_body"
+	ErrCodeUnauthorized     = "unauthorized"
+	ErrCodeForbidden        = "forbidden"
+	ErrCodeNotFound         = "not_found"
+	ErrCodeNotAcceptable    = "not_acceptable"
+	ErrCodeConflict         = "conflict"
+	ErrCodeRateLimited      = "rate_limited"
+	ErrCodeInvalidQuery     = "invalid_query"
+	ErrCodeTimeout          = "timeout"
+	ErrCodeInternal         = "internal_error"
+)
+
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	Param     string `json:"param,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
@@ -1,20 +1,43 @@
 package v1
 
+import "time"
+
+// SortsMap maps the sort parameter to the column rentals are sorted by. Every key of PriceUnitsMap
+// is also a sort key of the same charge, so price_unit=week pairs with sort=week, and price sorts
+// like day.
 var SortsMap = map[string]string{
-	"id":          "id",
-	"name":        "name",
-	"description": "description",
-	"type":        "type",
-	"make":        "vehicle_make",
-	"model":       "vehicle_model",
-	"year":        "vehicle_year",
-	"length":      "vehicle_length",
-	"sleeps":      "sleeps",
-	"price":       "price_per_day",
-	"city":        "home_city",
-	"state":       "home_state",
-	"zip":         "home_zip",
-	"country":     "home_country",
+	"id":               "id",
+	"name":             "name",
+	"description":      "description",
+	"type":             "type",
+	"make":             "vehicle_make",
+	"model":            "vehicle_model",
+	"year":             "vehicle_year",
+	"length":           "vehicle_length",
+	"sleeps":           "sleeps",
+	"price":            "price_per_day",
+	"security_deposit": "security_deposit",
+	"cleaning_fee":     "cleaning_fee",
+	"day":              "price_per_day",
+	"week":             "price_per_week",
+	"month":            "price_per_month",
+	"per_mile":         "price_per_mile",
+	"city":             "home_city",
+	"state":            "home_state",
+	"zip":              "home_zip",
+	"country":          "home_country",
+	"rating":           "rating_avg",
+}
+
+// PriceUnitsMap maps the price_unit parameter, a field of Price, to the column price filters compare.
+// Its keys are sort keys of SortsMap too.
+var PriceUnitsMap = map[string]string{
+	"day":              "price_per_day",
+	"week":             "price_per_week",
+	"month":            "price_per_month",
+	"security_deposit": "security_deposit",
+	"cleaning_fee":     "cleaning_fee",
+	"per_mile":         "price_per_mile",
 }
 
 type Rental struct {
@@ -30,11 +53,29 @@ type Rental struct {
 	PrimaryImageURL string   `json:"primary_image_url"`
 	Price           Price    `json:"price"`
 	Location        Location `json:"location"`
-	User            User     `json:"user"`
+	// Images is the gallery ordered by position, PrimaryImageURL is the URL of the first image.
+	Images []RentalImage `json:"images"`
+	// Amenities are the codes of the amenities the rental offers, ordered by code.
+	Amenities []string `json:"amenities"`
+	// RatingAvg is the average star rating of the reviews, nil until the first review.
+	RatingAvg   *float64 `json:"rating_avg"`
+	RatingCount int      `json:"rating_count"`
+	User        *User    `json:"user,omitempty"`
+	// UserID is the owner, also known when User is not loaded.
+	UserID int `json:"-"`
+	// Updated is the time of the last change, it drives the Last-Modified header.
+	Updated time.Time `json:"-"`
 }
 
+// Price amounts are in cents of Currency, an ISO 4217 code. Charges the rental does not offer are nil.
 type Price struct {
-	Day int `json:"day"`
+	Day             int    `json:"day"`
+	Week            *int   `json:"week,omitempty"`
+	Month           *int   `json:"month,omitempty"`
+	SecurityDeposit *int   `json:"security_deposit,omitempty"`
+	CleaningFee     *int   `json:"cleaning_fee,omitempty"`
+	PerMile         *int   `json:"per_mile,omitempty"`
+	Currency        string `json:"currency,omitempty"`
 }
 
 type Location struct {
@@ -45,3 +86,20 @@ type Location struct {
 	Lat     float64 `json:"lat"`
 	Lng     float64 `json:"lng"`
 }
+
+// RentalInput is the request body for creating and updating a rental. The owner is always the caller.
+type RentalInput struct {
+	Name            string   `json:"name"`
+	Description     string   `json:"description"`
+	Type            string   `json:"type"`
+	Make            string   `json:"make"`
+	Model           string   `json:"model"`
+	Year            int      `json:"year"`
+	Length          float32  `json:"length"`
+	Sleeps          int      `json:"sleeps"`
+	PrimaryImageURL string   `json:"primary_image_url"`
+	Price           Price    `json:"price"`
+	Location        Location `json:"location"`
+	// Amenities replaces the amenities of the rental, a missing or null list keeps them on updates.
+	Amenities []string `json:"amenities"`
+}
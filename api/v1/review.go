@@ -0,0 +1,22 @@
+package v1
+
+import "time"
+
+// Review is the star rating, from 1 to 5, and text the guest of a completed booking left for the rental.
+type Review struct {
+	ID        int       `json:"id"`
+	RentalID  int       `json:"rental_id"`
+	BookingID int       `json:"booking_id"`
+	UserID    int       `json:"user_id"`
+	Rating    int       `json:"rating"`
+	Text      string    `json:"text"`
+	Created   time.Time `json:"created"`
+}
+
+// ReviewInput is the request body for reviewing a rental. BookingID is a completed booking of the
+// caller on the rental, each booking is reviewed once.
+type ReviewInput struct {
+	BookingID int    `json:"booking_id"`
+	Rating    int    `json:"rating"`
+	Text      string `json:"text"`
+}
@@ -0,0 +1,44 @@
+package v1
+
+import "time"
+
+// EventSavedSearchChanged is the type of the webhook events sent for saved searches.
+const EventSavedSearchChanged = "saved_search.changed"
+
+// SavedSearchInput is the request body for saving a search. Query takes the filters, sort and
+// pagination parameters of GET v1/rentals, e.g. "price_max=10000&near=33.64,-117.93".
+type SavedSearchInput struct {
+	Query       string `json:"query"`
+	CallbackURL string `json:"callback_url"`
+}
+
+type SavedSearch struct {
+	ID          int    `json:"id"`
+	UserID      int    `json:"user_id"`
+	Query       string `json:"query"`
+	CallbackURL string `json:"callback_url"`
+	// Secret signs the webhooks of the search, it is only returned when the search is created.
+	Secret  string     `json:"secret,omitempty"`
+	Checked *time.Time `json:"checked,omitempty"`
+	Created time.Time  `json:"created"`
+}
+
+type WebhookDelivery struct {
+	ID         int       `json:"id"`
+	EventID    string    `json:"event_id"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Created    time.Time `json:"created"`
+}
+
+// SavedSearchEvent is the body of the webhooks sent when rentals newly match a saved search or
+// drop out of it.
+type SavedSearchEvent struct {
+	ID            string    `json:"id"`
+	Type          string    `json:"type"`
+	SavedSearchID int       `json:"saved_search_id"`
+	Created       time.Time `json:"created"`
+	Added         []Rental  `json:"added"`
+	Removed       []int     `json:"removed"`
+}
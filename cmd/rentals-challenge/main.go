@@ -1,13 +1,31 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
 	"github.com/alecthomas/kong"
+	"github.com/nats-io/nats.go"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
+	"github.com/mkermilska/rentals-challenge/internal/gql"
+	"github.com/mkermilska/rentals-challenge/internal/rpc"
 	"github.com/mkermilska/rentals-challenge/internal/web"
+	"github.com/mkermilska/rentals-challenge/pkg/auth"
+	"github.com/mkermilska/rentals-challenge/pkg/currency"
 	"github.com/mkermilska/rentals-challenge/pkg/database"
+	"github.com/mkermilska/rentals-challenge/pkg/events"
+	"github.com/mkermilska/rentals-challenge/pkg/ratelimit"
 	"github.com/mkermilska/rentals-challenge/pkg/service"
+	"github.com/mkermilska/rentals-challenge/pkg/storage"
+	"github.com/mkermilska/rentals-challenge/pkg/webhook"
 )
 
 const (
@@ -17,11 +35,66 @@ const (
 var cli struct {
 	Debug      int    `kong:"short='d',env='DEBUG',default=0,help='Run in debug mode'"`
 	HTTPPort   int    `kong:"short='t',env='HTTP_PORT',default='59191',help='HTTP server port'"`
+	GRPCPort   int    `kong:"short='g',env='GRPC_PORT',default='59192',help='gRPC server port'"`
 	DBHost     string `kong:"short='h',env='DB_HOST',default='127.0.0.1',help='DB server host'"`
 	DBPort     int    `kong:"short='r',env='DB_PORT',default='5434',help='DB server port'"`
 	DBName     string `kong:"short='n',env='DB_NAME',default='testingwithrentals',help='DB name'"`
 	DBUsername string `kong:"short='u',env='DB_USERNAME',default='root',help='DB username'"`
 	DBPassword string `kong:"short='p',env='DB_PASSWORD',default='root',help='DB password'"`
+
+	APIKeys     []string `kong:"env='API_KEYS',help='Static API keys as comma separated key:userID pairs'"`
+	JWKSFile    string   `kong:"env='JWKS_FILE',type='existingfile',help='JWKS file with HS256/RS256 keys for verifying JWTs'"`
+	JWTIssuer   string   `kong:"env='JWT_ISSUER',help='Expected JWT issuer'"`
+	JWTAudience string   `kong:"env='JWT_AUDIENCE',help='Expected JWT audience'"`
+
+	AdminUserIDs      []int  `kong:"name='admin-user-ids',env='ADMIN_USER_IDS',help='Users allowed to call the admin endpoints, comma separated'"`
+	ExchangeRatesFile string `kong:"env='EXCHANGE_RATES_FILE',type='existingfile',help='JSON file with exchange rates stored at startup'"`
+
+	RateLimit       string            `kong:"env='RATE_LIMIT',help='Requests allowed per client, e.g. 600/m. Empty disables rate limiting'"`
+	RouteRateLimits map[string]string `kong:"env='ROUTE_RATE_LIMITS',help='Per route overrides of the rate limit, e.g. rentals.near=60/m'"`
+	TrustedProxies  []string          `kong:"env='TRUSTED_PROXIES',help='Addresses or CIDRs of the reverse proxies whose X-Forwarded-For is trusted, comma separated'"`
+
+	CORSAllowedOrigins []string `kong:"env='CORS_ALLOWED_ORIGINS',help='Origins allowed to call the API from a browser. Empty disables CORS'"`
+	CORSAllowedMethods []string `kong:"env='CORS_ALLOWED_METHODS',default='GET,POST,PUT,DELETE',help='Methods allowed in cross-origin requests'"`
+	CORSAllowedHeaders []string `kong:"env='CORS_ALLOWED_HEADERS',default='Accept,Authorization,Content-Type,X-API-Key,X-Request-Id',help='Request headers allowed in cross-origin requests'"`
+	CORSMaxAge         int      `kong:"env='CORS_MAX_AGE',default='300',help='Seconds browsers may cache preflight responses'"`
+
+	CacheControl    string `kong:"env='CACHE_CONTROL',default='no-cache',help='Cache-Control header of GET responses'"`
+	CompressMinSize int    `kong:"env='COMPRESS_MIN_SIZE',default='1024',help='Smallest response in bytes compressed with gzip or brotli, 0 disables compression'"`
+	BatchGetMaxIDs  int    `kong:"env='BATCH_GET_MAX_IDS',default='100',help='Maximum number of ids in one batch get request'"`
+
+	StreamHeartbeat time.Duration `kong:"env='STREAM_HEARTBEAT',default='15s',help='Time between two heartbeats of the rentals stream'"`
+
+	CacheEnabled bool          `kong:"env='CACHE_ENABLED',help='Enable the in-process cache of rental lookups'"`
+	CacheSize    int           `kong:"env='CACHE_SIZE',default='1000',help='Maximum number of cached rentals and rental lists, each'"`
+	CacheTTL     time.Duration `kong:"env='CACHE_TTL',default='30s',help='Time a cached lookup is served before it is reloaded'"`
+
+	SavedSearchInterval time.Duration `kong:"env='SAVED_SEARCH_INTERVAL',default='1m',help='Time between two runs of the saved searches, 0 disables the webhook worker'"`
+	WebhookMaxAttempts  int           `kong:"env='WEBHOOK_MAX_ATTEMPTS',default='5',help='Delivery attempts of a webhook per run of its saved search'"`
+	WebhookTimeout      time.Duration `kong:"env='WEBHOOK_TIMEOUT',default='10s',help='Timeout of a single webhook delivery'"`
+	WebhookConcurrency  int           `kong:"env='WEBHOOK_CONCURRENCY',default='4',help='Saved searches checked and delivered at once'"`
+
+	EventPublisher    string        `kong:"env='EVENT_PUBLISHER',enum='stdout,http,nats',default='stdout',help='Where rental change events are published: stdout, http or nats'"`
+	EventHTTPURL      string        `kong:"name='event-http-url',env='EVENT_HTTP_URL',help='URL the http event publisher posts to'"`
+	EventHTTPSecret   string        `kong:"env='EVENT_HTTP_SECRET',help='Secret signing the events of the http publisher, empty sends them unsigned'"`
+	NATSURL           string        `kong:"name='nats-url',env='NATS_URL',default='nats://127.0.0.1:4222',help='NATS server of the nats event publisher'"`
+	NATSSubjectPrefix string        `kong:"env='NATS_SUBJECT_PREFIX',default='rentals',help='Prefix of the NATS subjects events are published on'"`
+	OutboxInterval    time.Duration `kong:"env='OUTBOX_INTERVAL',default='1s',help='Time between two polls of the event outbox'"`
+	OutboxRetention   time.Duration `kong:"env='OUTBOX_RETENTION',default='24h',help='Time published events are kept in the outbox'"`
+	OutboxMaxAttempts int           `kong:"env='OUTBOX_MAX_ATTEMPTS',default='10',help='Failed publishes after which an outbox event is set aside as failed'"`
+
+	Storage         string `kong:"env='STORAGE',enum='none,local,s3',default='none',help='Where uploaded images are stored: none disables uploads, local or s3'"`
+	StorageDir      string `kong:"env='STORAGE_DIR',default='media',help='Directory of the local storage'"`
+	S3Endpoint      string `kong:"name='s3-endpoint',env='S3_ENDPOINT',default='127.0.0.1:9000',help='Host and port of the S3-compatible storage'"`
+	S3Region        string `kong:"name='s3-region',env='S3_REGION',help='Region of the S3 bucket'"`
+	S3Bucket        string `kong:"name='s3-bucket',env='S3_BUCKET',default='rentals',help='S3 bucket, created when missing'"`
+	S3AccessKey     string `kong:"name='s3-access-key',env='S3_ACCESS_KEY',help='Access key of the S3 storage'"`
+	S3SecretKey     string `kong:"name='s3-secret-key',env='S3_SECRET_KEY',help='Secret key of the S3 storage'"`
+	S3UseSSL        bool   `kong:"name='s3-use-ssl',env='S3_USE_SSL',help='Connect to the S3 storage with TLS'"`
+	MediaBaseURL    string `kong:"env='MEDIA_BASE_URL',default='http://localhost:59191/media',help='URL uploaded images are served from, the API serves them at /media'"`
+	ThumbnailWidths []int  `kong:"env='THUMBNAIL_WIDTHS',default='160,480,1024',help='Widths in pixels uploaded images are scaled down to, comma separated'"`
+	MaxUploadBytes  int64  `kong:"env='MAX_UPLOAD_BYTES',default='10485760',help='Largest image upload in bytes'"`
+	ImageDecodes    int    `kong:"env='IMAGE_DECODES',default='2',help='Uploaded images decoded and scaled at once'"`
 }
 
 func main() {
@@ -39,26 +112,222 @@ func main() {
 	}
 	logger.Info("Starting rental service")
 
-	db, err := database.StartDBStore(database.StartUpOptions{
+	dbOpts := database.StartUpOptions{
 		DBHost:     cli.DBHost,
 		DBPort:     cli.DBPort,
 		DBName:     cli.DBName,
 		DBUsername: cli.DBUsername,
 		DBPassword: cli.DBPassword,
-	})
+	}
+	db, err := database.StartDBStore(dbOpts)
 	if err != nil {
 		logger.Fatal("Failed to start database", zap.Error(err))
 	}
 
-	rentalsSvc := service.NewRentalService(db, logger)
+	blobs, err := newBlobStore()
+	if err != nil {
+		logger.Fatal("Failed to configure storage", zap.Error(err))
+	}
+	svcOpts := service.Options{
+		Blobs:           blobs,
+		MediaBaseURL:    cli.MediaBaseURL,
+		ThumbnailWidths: cli.ThumbnailWidths,
+		ImageDecodes:    cli.ImageDecodes,
+	}
+	if cli.CacheEnabled {
+		svcOpts.CacheSize = cli.CacheSize
+		svcOpts.CacheTTL = cli.CacheTTL
+	}
+	rentalsSvc := service.NewRentalService(db, logger, svcOpts)
+	var uploads web.RentalImageUploadService
+	if blobs != nil {
+		uploads = rentalsSvc
+	}
+	go rentalsSvc.WatchChanges(context.Background(), database.NewListener(dbOpts, logger))
+	usersSvc := service.NewUserService(db, logger)
+	searchesSvc := service.NewSavedSearchService(db, logger)
+	pricingSvc := service.NewPricingService(db, logger)
+	currencySvc := service.NewCurrencyService(db, logger, rentalsSvc)
+	if cli.ExchangeRatesFile != "" {
+		rates, err := currency.LoadFile(cli.ExchangeRatesFile)
+		if err != nil {
+			logger.Fatal("Failed to load exchange rates", zap.Error(err))
+		}
+		if _, err := currencySvc.SetExchangeRates(context.Background(), rates); err != nil {
+			logger.Fatal("Failed to store exchange rates", zap.Error(err))
+		}
+	}
+
+	authenticator, err := newAuthenticator()
+	if err != nil {
+		logger.Fatal("Failed to configure authentication", zap.Error(err))
+	}
+
+	rateLimit, routeRateLimits, err := parseRateLimits()
+	if err != nil {
+		logger.Fatal("Failed to configure rate limits", zap.Error(err))
+	}
+	trustedProxies, err := parseTrustedProxies()
+	if err != nil {
+		logger.Fatal("Failed to configure trusted proxies", zap.Error(err))
+	}
 
 	server := web.New(
-		cli.HTTPPort,
+		web.Options{
+			Port:            cli.HTTPPort,
+			Authenticator:   authenticator,
+			RateLimit:       rateLimit,
+			RouteRateLimits: routeRateLimits,
+			TrustedProxies:  trustedProxies,
+			CORS: web.CORSOptions{
+				AllowedOrigins: cli.CORSAllowedOrigins,
+				AllowedMethods: cli.CORSAllowedMethods,
+				AllowedHeaders: cli.CORSAllowedHeaders,
+				MaxAge:         cli.CORSMaxAge,
+			},
+			CacheControl:    cli.CacheControl,
+			CompressMinSize: cli.CompressMinSize,
+			BatchGetMaxIDs:  cli.BatchGetMaxIDs,
+			SavedSearches:   searchesSvc,
+			GraphQL:         gql.NewHandler(rentalsSvc, usersSvc, logger),
+			RentalEvents:    rentalsSvc,
+			Pricing:         pricingSvc,
+			StreamHeartbeat: cli.StreamHeartbeat,
+			Currencies:      currencySvc,
+			AdminUserIDs:    cli.AdminUserIDs,
+			Images:          rentalsSvc,
+			Amenities:       rentalsSvc,
+			Reviews:         rentalsSvc,
+			Uploads:         uploads,
+			MaxUploadBytes:  cli.MaxUploadBytes,
+			Media:           blobs,
+		},
 		rentalsSvc,
 		logger)
 	if err != nil {
 		logger.Fatal("Failed to start HTTP server", zap.Error(err))
 	}
 
+	grpcServer := rpc.New(rpc.Options{Port: cli.GRPCPort}, rentalsSvc, logger)
+	go grpcServer.Start()
+
+	publisher, err := newEventPublisher()
+	if err != nil {
+		logger.Fatal("Failed to configure event publisher", zap.Error(err))
+	}
+	relay := events.NewRelay(events.Options{
+		Interval:    cli.OutboxInterval,
+		Retention:   cli.OutboxRetention,
+		MaxAttempts: cli.OutboxMaxAttempts,
+	}, database.NewOutboxRepository(db, logger), publisher, logger)
+	go relay.Run(context.Background())
+
+	if cli.SavedSearchInterval > 0 {
+		worker := webhook.NewWorker(webhook.Options{
+			Interval:    cli.SavedSearchInterval,
+			MaxAttempts: cli.WebhookMaxAttempts,
+			Timeout:     cli.WebhookTimeout,
+			Concurrency: cli.WebhookConcurrency,
+		}, database.NewSearchesRepository(db, logger), rentalsSvc, logger)
+		go worker.Run(context.Background())
+	}
+
 	server.Start()
 }
+
+func newEventPublisher() (events.EventPublisher, error) {
+	switch cli.EventPublisher {
+	case "http":
+		if cli.EventHTTPURL == "" {
+			return nil, errors.New("EVENT_HTTP_URL is required by the http event publisher")
+		}
+		return events.NewHTTPPublisher(cli.EventHTTPURL, cli.EventHTTPSecret, nil), nil
+	case "nats":
+		conn, err := nats.Connect(cli.NATSURL, nats.Name(serviceID), nats.MaxReconnects(-1))
+		if err != nil {
+			return nil, err
+		}
+		return events.NewNATSPublisher(conn, cli.NATSSubjectPrefix), nil
+	default:
+		return events.NewWriterPublisher(os.Stdout), nil
+	}
+}
+
+func newBlobStore() (storage.BlobStore, error) {
+	switch cli.Storage {
+	case "local":
+		return storage.NewLocalStore(cli.StorageDir)
+	case "s3":
+		return storage.NewS3Store(context.Background(), storage.S3Options{
+			Endpoint:  cli.S3Endpoint,
+			Region:    cli.S3Region,
+			Bucket:    cli.S3Bucket,
+			AccessKey: cli.S3AccessKey,
+			SecretKey: cli.S3SecretKey,
+			UseSSL:    cli.S3UseSSL,
+		})
+	default:
+		return nil, nil
+	}
+}
+
+func newAuthenticator() (auth.Authenticator, error) {
+	authenticators := auth.Chain{}
+	if len(cli.APIKeys) > 0 {
+		keys, err := auth.ParseAPIKeys(cli.APIKeys)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, auth.NewAPIKeyAuthenticator(keys))
+	}
+	if cli.JWKSFile != "" {
+		keySet, err := auth.LoadJWKS(cli.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, auth.NewJWTAuthenticator(keySet, auth.JWTOptions{
+			Issuer:   cli.JWTIssuer,
+			Audience: cli.JWTAudience,
+		}))
+	}
+	return authenticators, nil
+}
+
+func parseRateLimits() (ratelimit.Limit, map[string]ratelimit.Limit, error) {
+	var rateLimit ratelimit.Limit
+	if cli.RateLimit != "" {
+		limit, err := ratelimit.ParseLimit(cli.RateLimit)
+		if err != nil {
+			return rateLimit, nil, err
+		}
+		rateLimit = limit
+	}
+	routeRateLimits := make(map[string]ratelimit.Limit, len(cli.RouteRateLimits))
+	for route, value := range cli.RouteRateLimits {
+		if !slices.Contains(web.Routes, route) {
+			return rateLimit, nil, fmt.Errorf("unknown rate limit route %q, expected one of %s", route, strings.Join(web.Routes, ", "))
+		}
+		limit, err := ratelimit.ParseLimit(value)
+		if err != nil {
+			return rateLimit, nil, err
+		}
+		routeRateLimits[route] = limit
+	}
+	return rateLimit, routeRateLimits, nil
+}
+
+func parseTrustedProxies() ([]netip.Prefix, error) {
+	trustedProxies := make([]netip.Prefix, 0, len(cli.TrustedProxies))
+	for _, value := range cli.TrustedProxies {
+		if addr, err := netip.ParseAddr(value); err == nil {
+			trustedProxies = append(trustedProxies, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", value, err)
+		}
+		trustedProxies = append(trustedProxies, prefix.Masked())
+	}
+	return trustedProxies, nil
+}
@@ -0,0 +1,121 @@
+// Package gql serves the rentals and their owners over GraphQL.
+package gql
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"net/http"
+
+	"github.com/graph-gophers/graphql-go"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+)
+
+//go:embed schema.graphql
+var schema string
+
+// maxBodyBytes caps the size of GraphQL requests.
+const maxBodyBytes = 1 << 16
+
+// maxDepth caps the nesting of fields, so the rentals of owners of rentals can not be followed
+// without end. The deepest selection of the schema without cycles, rentals { user { rentals
+// { images { variants { url } } } } }, is 6 levels deep.
+const maxDepth = 8
+
+// maxParallelism caps the resolvers of a request running at once.
+const maxParallelism = 10
+
+// RentalService is what the GraphQL API needs from service.RentalService.
+type RentalService interface {
+	GetRentalByID(ctx context.Context, rentalID int) (*apiv1.Rental, error)
+	GetRentals(ctx context.Context, params database.RentalParams) ([]apiv1.Rental, error)
+}
+
+// UserService is what the GraphQL API needs from service.UserService.
+type UserService interface {
+	GetUsersByIDs(ctx context.Context, userIDs []int) ([]apiv1.User, error)
+}
+
+type Handler struct {
+	schema    *graphql.Schema
+	rentalSvc RentalService
+	userSvc   UserService
+	logger    *zap.Logger
+}
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func NewHandler(rentalSvc RentalService, userSvc UserService, logger *zap.Logger) *Handler {
+	return &Handler{
+		schema: graphql.MustParseSchema(schema, &resolver{rentalSvc: rentalSvc, logger: logger},
+			graphql.MaxDepth(maxDepth), graphql.MaxParallelism(maxParallelism)),
+		rentalSvc: rentalSvc,
+		userSvc:   userSvc,
+		logger:    logger,
+	}
+}
+
+// ServeHTTP executes queries sent as JSON body with POST, or as query parameters with GET.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if variables := r.URL.Query().Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				h.writeError(w, http.StatusBadRequest, "Invalid variables parameter")
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes)).Decode(&req); err != nil {
+			h.writeError(w, http.StatusBadRequest, "Invalid GraphQL request body")
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		h.writeError(w, http.StatusMethodNotAllowed, "GraphQL requests must use GET or POST")
+		return
+	}
+	if req.Query == "" {
+		h.writeError(w, http.StatusBadRequest, "Missing GraphQL query")
+		return
+	}
+
+	ctx := withLoaders(r.Context(), newLoaders(h.rentalSvc, h.userSvc))
+	resp := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+	out, err := json.Marshal(resp)
+	if err != nil {
+		errorMsg := "Error parsing GraphQL response"
+		h.logger.Error(errorMsg, zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, errorMsg)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(out)
+	if err != nil {
+		h.logger.Error("Error writing GraphQL response", zap.Error(err))
+	}
+}
+
+// writeError answers requests that could not be executed, in the errors format of GraphQL responses.
+func (h *Handler) writeError(w http.ResponseWriter, status int, msg string) {
+	out, _ := json.Marshal(map[string]interface{}{
+		"errors": []map[string]string{{"message": msg}},
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err := w.Write(out)
+	if err != nil {
+		h.logger.Error("Error writing GraphQL response", zap.Error(err))
+	}
+}
@@ -0,0 +1,235 @@
+package gql
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+)
+
+func TestHandler_Queries(t *testing.T) {
+	tests := map[string]struct {
+		query              string
+		expectedData       string
+		expectedError      bool
+		expectedUserCalls  int
+		expectedUserIDs    []int
+		expectedRentalCall *database.RentalParams
+	}{
+		"Rental with joined owner": {
+			query:             `{ rental(id: "3") { id price { day } user { id firstName } } }`,
+			expectedData:      `{"rental":{"id":"3","price":{"day":3000},"user":{"id":"2","firstName":"Joined"}}}`,
+			expectedUserCalls: 0,
+		},
+		"Rental with images": {
+			query: `{ rental(id: "2") { images { id url position caption width variants { name url width } } } }`,
+			expectedData: `{"rental":{"images":[{"id":"2","url":"https://example.com/2.jpg","position":0,"caption":"Front",` +
+				`"width":null,"variants":[{"name":"w160","url":"https://example.com/2-w160.jpg","width":160}]}]}}`,
+		},
+		"Missing rental": {
+			query:        `{ rental(id: "30") { id } }`,
+			expectedData: `{"rental":null}`,
+		},
+		"Rentals without owners": {
+			query:              `{ rentals { id } }`,
+			expectedData:       `{"rentals":[{"id":"1"},{"id":"2"},{"id":"3"},{"id":"4"},{"id":"5"},{"id":"6"}]}`,
+			expectedUserCalls:  0,
+			expectedRentalCall: &database.RentalParams{},
+		},
+		"Rentals with owners in one batch": {
+			query: `{ rentals { id user { id firstName } } }`,
+			expectedData: `{"rentals":[` +
+				`{"id":"1","user":{"id":"1","firstName":"Loaded"}},{"id":"2","user":{"id":"1","firstName":"Loaded"}},` +
+				`{"id":"3","user":{"id":"2","firstName":"Loaded"}},{"id":"4","user":{"id":"2","firstName":"Loaded"}},` +
+				`{"id":"5","user":{"id":"3","firstName":"Loaded"}},{"id":"6","user":{"id":"3","firstName":"Loaded"}}]}`,
+			expectedUserCalls: 1,
+			expectedUserIDs:   []int{1, 2, 3},
+		},
+		"Rentals filtered, sorted and paged": {
+			query: `{ rentals(filter: {priceMin: 9000, priceMax: 20000, ids: ["1", "2"]}, sort: PRICE,
+				page: {limit: 2, offset: 1}) { id } }`,
+			expectedData: `{"rentals":[{"id":"1"},{"id":"2"},{"id":"3"},{"id":"4"},{"id":"5"},{"id":"6"}]}`,
+			expectedRentalCall: &database.RentalParams{
+				PriceMin: 9000, PriceMax: 20000, IDs: []int{1, 2}, Sort: "price_per_day", Limit: 2, Offset: 1,
+			},
+		},
+		"Rentals filtered and sorted by weekly price": {
+			query:        `{ rentals(filter: {priceMax: 60000, priceUnit: WEEK}, sort: WEEK) { id } }`,
+			expectedData: `{"rentals":[{"id":"1"},{"id":"2"},{"id":"3"},{"id":"4"},{"id":"5"},{"id":"6"}]}`,
+			expectedRentalCall: &database.RentalParams{
+				PriceMax: 60000, PriceUnit: "price_per_week", Sort: "price_per_week",
+			},
+		},
+		"Rentals filtered and sorted by the price unit": {
+			query:        `{ rentals(filter: {priceMax: 3000, priceUnit: PER_MILE}, sort: PER_MILE) { id } }`,
+			expectedData: `{"rentals":[{"id":"1"},{"id":"2"},{"id":"3"},{"id":"4"},{"id":"5"},{"id":"6"}]}`,
+			expectedRentalCall: &database.RentalParams{
+				PriceMax: 3000, PriceUnit: "price_per_mile", Sort: "price_per_mile",
+			},
+		},
+		"Rentals filtered by amenities": {
+			query: `{ rentals(filter: {amenities: ["ac", "pet_friendly"]}, page: {limit: 1}) { id amenities } }`,
+			expectedData: `{"rentals":[{"id":"1","amenities":[]},{"id":"2","amenities":[]},{"id":"3","amenities":[]},` +
+				`{"id":"4","amenities":[]},{"id":"5","amenities":[]},{"id":"6","amenities":[]}]}`,
+			expectedRentalCall: &database.RentalParams{
+				Amenities: []string{"ac", "pet_friendly"}, Limit: 1,
+			},
+		},
+		"Rentals filtered and sorted by rating": {
+			query: `{ rentals(filter: {ratingMin: 4}, sort: RATING, page: {limit: 1}) { id ratingAvg ratingCount } }`,
+			expectedData: `{"rentals":[{"id":"1","ratingAvg":null,"ratingCount":0},{"id":"2","ratingAvg":4.5,"ratingCount":2},` +
+				`{"id":"3","ratingAvg":null,"ratingCount":0},{"id":"4","ratingAvg":4.5,"ratingCount":2},` +
+				`{"id":"5","ratingAvg":null,"ratingCount":0},{"id":"6","ratingAvg":4.5,"ratingCount":2}]}`,
+			expectedRentalCall: &database.RentalParams{
+				RatingMin: 4, Sort: "rating_avg", Limit: 1,
+			},
+		},
+		"Rentals with invalid sort": {
+			query:         `{ rentals(sort: SIZE) { id } }`,
+			expectedError: true,
+		},
+		"User with rentals": {
+			query:             `{ user(id: "2") { firstName rentals { id user { id } } } }`,
+			expectedData:      `{"user":{"firstName":"Loaded","rentals":[{"id":"3","user":{"id":"2"}},{"id":"4","user":{"id":"2"}}]}}`,
+			expectedUserCalls: 1,
+			expectedUserIDs:   []int{2},
+			expectedRentalCall: &database.RentalParams{
+				UserIDs: []int{2}, Sort: "id",
+			},
+		},
+		"Missing user": {
+			query:             `{ user(id: "30") { firstName } }`,
+			expectedData:      `{"user":null}`,
+			expectedUserCalls: 1,
+			expectedUserIDs:   []int{30},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			svc := &fakeServices{}
+			resp := execute(t, NewHandler(svc, svc, zap.NewNop()), test.query)
+			if test.expectedError {
+				assert.NotEmpty(t, resp["errors"])
+				return
+			}
+			assert.Nil(t, resp["errors"])
+			data, err := json.Marshal(resp["data"])
+			require.Nil(t, err, "Error encoding data")
+			assert.JSONEq(t, test.expectedData, string(data))
+
+			assert.Len(t, svc.userCalls, test.expectedUserCalls)
+			if test.expectedUserIDs != nil {
+				userIDs := svc.userCalls[0]
+				sort.Ints(userIDs)
+				assert.Equal(t, test.expectedUserIDs, userIDs)
+			}
+			if test.expectedRentalCall != nil {
+				require.Len(t, svc.rentalParams, 1)
+				assert.Equal(t, *test.expectedRentalCall, svc.rentalParams[0])
+			}
+		})
+	}
+}
+
+func TestHandler_NestedRentalsBatched(t *testing.T) {
+	svc := &fakeServices{}
+	resp := execute(t, NewHandler(svc, svc, zap.NewNop()), `{ rentals { user { rentals { id } } } }`)
+	assert.Nil(t, resp["errors"])
+
+	// one query for the page, one for the owners and one for the rentals of all owners
+	require.Len(t, svc.rentalParams, 2)
+	userIDs := svc.rentalParams[1].UserIDs
+	sort.Ints(userIDs)
+	assert.Equal(t, []int{1, 2, 3}, userIDs)
+	assert.Len(t, svc.userCalls, 1)
+}
+
+func TestHandler_MaxDepth(t *testing.T) {
+	tests := map[string]struct {
+		query         string
+		expectedError bool
+	}{
+		"Deepest selection without cycles": {
+			query: `{ rentals { user { rentals { images { variants { url } } } } } }`,
+		},
+		"Query at the limit": {
+			query: `{ user(id: "1") { rentals { user { rentals { user { rentals { user { id } } } } } } } }`,
+		},
+		"Too deep query": {
+			query:         `{ user(id: "1") { rentals { user { rentals { user { rentals { user { rentals { id } } } } } } } } }`,
+			expectedError: true,
+		},
+		"Too deep fragment": {
+			query: `{ rentals { ...owner } }
+				fragment owner on Rental { user { rentals { user { rentals { user { rentals { user { id } } } } } } } }`,
+			expectedError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			svc := &fakeServices{}
+			resp := execute(t, NewHandler(svc, svc, zap.NewNop()), test.query)
+			if !test.expectedError {
+				assert.Nil(t, resp["errors"])
+				return
+			}
+			require.NotEmpty(t, resp["errors"])
+			assert.Nil(t, resp["data"])
+			assert.Empty(t, svc.rentalParams, "Too deep queries are rejected before any resolver runs")
+			assert.Empty(t, svc.userCalls)
+		})
+	}
+}
+
+func TestHandler_Requests(t *testing.T) {
+	handler := NewHandler(&fakeServices{}, &fakeServices{}, zap.NewNop())
+	tests := map[string]struct {
+		method         string
+		target         string
+		body           string
+		expectedStatus int
+	}{
+		"GET query": {
+			method:         http.MethodGet,
+			target:         "/graphql?query=" + url.QueryEscape(`query($id: ID!) { rental(id: $id) { id } }`) + "&variables=" + url.QueryEscape(`{"id": "1"}`),
+			expectedStatus: http.StatusOK,
+		},
+		"Invalid body": {
+			method:         http.MethodPost,
+			target:         "/graphql",
+			body:           `{"query":`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		"Missing query": {
+			method:         http.MethodPost,
+			target:         "/graphql",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		"Unsupported method": {
+			method:         http.MethodPut,
+			target:         "/graphql",
+			body:           `{}`,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(test.method, test.target, bytes.NewBufferString(test.body)))
+			assert.Equal(t, test.expectedStatus, w.Code, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package gql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+)
+
+// fakeServices serves rentals 1 to 6, rentals 2N-1 and 2N are owned by user N, and records every call.
+type fakeServices struct {
+	mu           sync.Mutex
+	rentalParams []database.RentalParams
+	userCalls    [][]int
+}
+
+func fakeRental(id int) apiv1.Rental {
+	rental := apiv1.Rental{ID: id, Name: "Rental", Price: apiv1.Price{Day: id * 1000}, UserID: (id + 1) / 2,
+		Images: []apiv1.RentalImage{{ID: id, URL: fmt.Sprintf("https://example.com/%d.jpg", id), Caption: "Front",
+			Variants: []apiv1.ImageVariant{{Name: "w160", URL: fmt.Sprintf("https://example.com/%d-w160.jpg", id),
+				Width: 160, Height: 120}}}}}
+	// even rentals are reviewed
+	if id%2 == 0 {
+		rating := 4.5
+		rental.RatingAvg, rental.RatingCount = &rating, 2
+	}
+	return rental
+}
+
+func (s *fakeServices) GetRentalByID(_ context.Context, rentalID int) (*apiv1.Rental, error) {
+	if rentalID > 6 {
+		return nil, database.ErrNotFound
+	}
+	rental := fakeRental(rentalID)
+	rental.User = &apiv1.User{ID: rental.UserID, FirstName: "Joined"}
+	return &rental, nil
+}
+
+func (s *fakeServices) GetRentals(_ context.Context, params database.RentalParams) ([]apiv1.Rental, error) {
+	s.mu.Lock()
+	s.rentalParams = append(s.rentalParams, params)
+	s.mu.Unlock()
+
+	rentals := []apiv1.Rental{}
+	for id := 1; id <= 6; id++ {
+		rental := fakeRental(id)
+		if len(params.UserIDs) > 0 && !containsInt(params.UserIDs, rental.UserID) {
+			continue
+		}
+		rentals = append(rentals, rental)
+	}
+	return rentals, nil
+}
+
+func (s *fakeServices) GetUsersByIDs(_ context.Context, userIDs []int) ([]apiv1.User, error) {
+	s.mu.Lock()
+	s.userCalls = append(s.userCalls, userIDs)
+	s.mu.Unlock()
+
+	users := []apiv1.User{}
+	for _, userID := range userIDs {
+		if userID <= 3 {
+			users = append(users, apiv1.User{ID: userID, FirstName: "Loaded"})
+		}
+	}
+	return users, nil
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func execute(t *testing.T, handler http.Handler, query string) map[string]interface{} {
+	body, err := json.Marshal(request{Query: query})
+	require.Nil(t, err, "Error encoding request")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp map[string]interface{}
+	require.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp), "Error decoding response")
+	return resp
+}
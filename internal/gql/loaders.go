@@ -0,0 +1,66 @@
+package gql
+
+import (
+	"context"
+	"time"
+
+	"github.com/graph-gophers/dataloader/v7"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+)
+
+// loaderWait is how long a loader collects keys before it runs the batch.
+const loaderWait = 5 * time.Millisecond
+
+type loadersKey struct{}
+
+// loaders batch the lookups of one request, so resolving the owners of a page is one query.
+type loaders struct {
+	users       *dataloader.Loader[int, *apiv1.User]
+	userRentals *dataloader.Loader[int, []apiv1.Rental]
+}
+
+func newLoaders(rentalSvc RentalService, userSvc UserService) *loaders {
+	return &loaders{
+		users: dataloader.NewBatchedLoader(
+			func(ctx context.Context, userIDs []int) []*dataloader.Result[*apiv1.User] {
+				users, err := userSvc.GetUsersByIDs(ctx, userIDs)
+				byID := make(map[int]*apiv1.User, len(users))
+				for i := range users {
+					byID[users[i].ID] = &users[i]
+				}
+				results := make([]*dataloader.Result[*apiv1.User], len(userIDs))
+				for i, userID := range userIDs {
+					results[i] = &dataloader.Result[*apiv1.User]{Data: byID[userID], Error: err}
+				}
+				return results
+			},
+			dataloader.WithWait[int, *apiv1.User](loaderWait)),
+		userRentals: dataloader.NewBatchedLoader(
+			func(ctx context.Context, userIDs []int) []*dataloader.Result[[]apiv1.Rental] {
+				rentals, err := rentalSvc.GetRentals(ctx, database.RentalParams{
+					UserIDs: userIDs,
+					Sort:    apiv1.SortsMap["id"],
+				})
+				byUserID := make(map[int][]apiv1.Rental, len(userIDs))
+				for _, rental := range rentals {
+					byUserID[rental.UserID] = append(byUserID[rental.UserID], rental)
+				}
+				results := make([]*dataloader.Result[[]apiv1.Rental], len(userIDs))
+				for i, userID := range userIDs {
+					results[i] = &dataloader.Result[[]apiv1.Rental]{Data: byUserID[userID], Error: err}
+				}
+				return results
+			},
+			dataloader.WithWait[int, []apiv1.Rental](loaderWait)),
+	}
+}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
@@ -0,0 +1,399 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/graph-gophers/graphql-go"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+	"github.com/mkermilska/rentals-challenge/pkg/utils"
+)
+
+type resolver struct {
+	rentalSvc RentalService
+	logger    *zap.Logger
+}
+
+func (r *resolver) Rental(ctx context.Context, args struct{ ID graphql.ID }) (*rentalResolver, error) {
+	rentalID, err := strconv.Atoi(string(args.ID))
+	if err != nil {
+		return nil, errors.New("Incorrect rental ID, please enter a valid number")
+	}
+	rental, err := r.rentalSvc.GetRentalByID(ctx, rentalID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return nil, nil
+		}
+		errorMsg := "Error getting rental"
+		r.logger.Error(errorMsg, zap.Int("rentalID", rentalID), zap.Error(err))
+		return nil, errors.New(errorMsg)
+	}
+	if rental.User != nil {
+		loadersFrom(ctx).users.Prime(ctx, rental.User.ID, rental.User)
+	}
+	return &rentalResolver{rental: *rental, logger: r.logger}, nil
+}
+
+type rentalsArgs struct {
+	Filter *struct {
+		PriceMin  *int32
+		PriceMax  *int32
+		PriceUnit *string
+		IDs       *[]graphql.ID
+		Near      *struct {
+			Lat float64
+			Lng float64
+		}
+		Amenities *[]string
+		RatingMin *float64
+	}
+	Sort *string
+	Page *struct {
+		Limit  *int32
+		Offset *int32
+	}
+}
+
+func (r *resolver) Rentals(ctx context.Context, args rentalsArgs) ([]*rentalResolver, error) {
+	params := database.RentalParams{}
+	if filter := args.Filter; filter != nil {
+		if filter.PriceMin != nil {
+			params.PriceMin = int(*filter.PriceMin)
+		}
+		if filter.PriceMax != nil {
+			params.PriceMax = int(*filter.PriceMax)
+		}
+		if filter.PriceUnit != nil {
+			params.PriceUnit = apiv1.PriceUnitsMap[strings.ToLower(*filter.PriceUnit)]
+		}
+		if filter.IDs != nil {
+			for _, ID := range *filter.IDs {
+				rentalID, err := strconv.Atoi(string(ID))
+				if err != nil {
+					return nil, errors.New("Invalid id exists in ids filter")
+				}
+				params.IDs = append(params.IDs, rentalID)
+			}
+		}
+		if filter.Near != nil {
+			params.Near = *utils.CalculateNearBox(utils.Point{Lat: filter.Near.Lat, Lng: filter.Near.Lng}, 100)
+		}
+		if filter.Amenities != nil {
+			params.Amenities = *filter.Amenities
+		}
+		if filter.RatingMin != nil {
+			params.RatingMin = *filter.RatingMin
+		}
+	}
+	if args.Sort != nil {
+		params.Sort = apiv1.SortsMap[strings.ToLower(*args.Sort)]
+	}
+	if page := args.Page; page != nil {
+		if page.Limit != nil {
+			params.Limit = int(*page.Limit)
+		}
+		if page.Offset != nil {
+			params.Offset = int(*page.Offset)
+		}
+		if params.Limit < 0 || params.Offset < 0 {
+			return nil, errors.New("Page limit and offset must not be negative")
+		}
+	}
+
+	rentals, err := r.rentalSvc.GetRentals(ctx, params)
+	if err != nil {
+		errorMsg := "Error getting rentals"
+		r.logger.Error(errorMsg, zap.Error(err))
+		return nil, errors.New(errorMsg)
+	}
+
+	page := &rentalPage{}
+	seen := make(map[int]bool, len(rentals))
+	resolvers := make([]*rentalResolver, len(rentals))
+	for i, rental := range rentals {
+		resolvers[i] = &rentalResolver{rental: rental, page: page, logger: r.logger}
+		if !seen[rental.UserID] {
+			seen[rental.UserID] = true
+			page.ownerIDs = append(page.ownerIDs, rental.UserID)
+		}
+	}
+	return resolvers, nil
+}
+
+// rentalPage holds the owners of a rentals page. The first resolved owner queues all of them,
+// as the rentals are resolved with limited parallelism and would be batched in parts otherwise.
+type rentalPage struct {
+	once     sync.Once
+	ownerIDs []int
+}
+
+func (r *resolver) User(ctx context.Context, args struct{ ID graphql.ID }) (*userResolver, error) {
+	userID, err := strconv.Atoi(string(args.ID))
+	if err != nil {
+		return nil, errors.New("Incorrect user ID, please enter a valid number")
+	}
+	return loadUser(ctx, r.logger, userID)
+}
+
+func loadUser(ctx context.Context, logger *zap.Logger, userID int) (*userResolver, error) {
+	user, err := loadersFrom(ctx).users.Load(ctx, userID)()
+	if err != nil {
+		errorMsg := "Error getting user"
+		logger.Error(errorMsg, zap.Int("userID", userID), zap.Error(err))
+		return nil, errors.New(errorMsg)
+	}
+	if user == nil {
+		return nil, nil
+	}
+	return &userResolver{user: *user, logger: logger}, nil
+}
+
+type rentalResolver struct {
+	rental apiv1.Rental
+	page   *rentalPage
+	logger *zap.Logger
+}
+
+func (r *rentalResolver) ID() graphql.ID {
+	return graphql.ID(strconv.Itoa(r.rental.ID))
+}
+
+func (r *rentalResolver) Name() string {
+	return r.rental.Name
+}
+
+func (r *rentalResolver) Description() string {
+	return r.rental.Description
+}
+
+func (r *rentalResolver) Type() string {
+	return r.rental.Type
+}
+
+func (r *rentalResolver) Make() string {
+	return r.rental.Make
+}
+
+func (r *rentalResolver) Model() string {
+	return r.rental.Model
+}
+
+func (r *rentalResolver) Year() int32 {
+	return int32(r.rental.Year)
+}
+
+func (r *rentalResolver) Length() float64 {
+	return float64(r.rental.Length)
+}
+
+func (r *rentalResolver) Sleeps() int32 {
+	return int32(r.rental.Sleeps)
+}
+
+func (r *rentalResolver) PrimaryImageURL() string {
+	return r.rental.PrimaryImageURL
+}
+
+func (r *rentalResolver) Price() *priceResolver {
+	return &priceResolver{price: r.rental.Price}
+}
+
+func (r *rentalResolver) Location() *locationResolver {
+	return &locationResolver{location: r.rental.Location}
+}
+
+func (r *rentalResolver) Images() []*imageResolver {
+	images := make([]*imageResolver, len(r.rental.Images))
+	for i, image := range r.rental.Images {
+		images[i] = &imageResolver{image: image}
+	}
+	return images
+}
+
+func (r *rentalResolver) Amenities() []string {
+	if r.rental.Amenities == nil {
+		return []string{}
+	}
+	return r.rental.Amenities
+}
+
+func (r *rentalResolver) RatingAvg() *float64 {
+	return r.rental.RatingAvg
+}
+
+func (r *rentalResolver) RatingCount() int32 {
+	return int32(r.rental.RatingCount)
+}
+
+func (r *rentalResolver) User(ctx context.Context) (*userResolver, error) {
+	if r.rental.User != nil {
+		return &userResolver{user: *r.rental.User, logger: r.logger}, nil
+	}
+	if r.page != nil {
+		r.page.once.Do(func() {
+			loadersFrom(ctx).users.LoadMany(ctx, r.page.ownerIDs)
+		})
+	}
+	return loadUser(ctx, r.logger, r.rental.UserID)
+}
+
+type priceResolver struct {
+	price apiv1.Price
+}
+
+func (r *priceResolver) Day() int32 {
+	return int32(r.price.Day)
+}
+
+func (r *priceResolver) Week() *int32 {
+	return optionalInt32(r.price.Week)
+}
+
+func (r *priceResolver) Month() *int32 {
+	return optionalInt32(r.price.Month)
+}
+
+func (r *priceResolver) SecurityDeposit() *int32 {
+	return optionalInt32(r.price.SecurityDeposit)
+}
+
+func (r *priceResolver) CleaningFee() *int32 {
+	return optionalInt32(r.price.CleaningFee)
+}
+
+func (r *priceResolver) PerMile() *int32 {
+	return optionalInt32(r.price.PerMile)
+}
+
+func optionalInt32(amount *int) *int32 {
+	if amount == nil {
+		return nil
+	}
+	value := int32(*amount)
+	return &value
+}
+
+func (r *priceResolver) Currency() string {
+	return r.price.Currency
+}
+
+type locationResolver struct {
+	location apiv1.Location
+}
+
+func (r *locationResolver) City() string {
+	return r.location.City
+}
+
+func (r *locationResolver) State() string {
+	return r.location.State
+}
+
+func (r *locationResolver) Zip() string {
+	return r.location.Zip
+}
+
+func (r *locationResolver) Country() string {
+	return r.location.Country
+}
+
+func (r *locationResolver) Lat() float64 {
+	return r.location.Lat
+}
+
+func (r *locationResolver) Lng() float64 {
+	return r.location.Lng
+}
+
+type imageResolver struct {
+	image apiv1.RentalImage
+}
+
+func (r *imageResolver) ID() graphql.ID {
+	return graphql.ID(strconv.Itoa(r.image.ID))
+}
+
+func (r *imageResolver) URL() string {
+	return r.image.URL
+}
+
+func (r *imageResolver) Position() int32 {
+	return int32(r.image.Position)
+}
+
+func (r *imageResolver) Caption() string {
+	return r.image.Caption
+}
+
+func (r *imageResolver) Width() *int32 {
+	return optionalInt32(r.image.Width)
+}
+
+func (r *imageResolver) Height() *int32 {
+	return optionalInt32(r.image.Height)
+}
+
+func (r *imageResolver) Variants() []*imageVariantResolver {
+	variants := make([]*imageVariantResolver, len(r.image.Variants))
+	for i, variant := range r.image.Variants {
+		variants[i] = &imageVariantResolver{variant: variant}
+	}
+	return variants
+}
+
+type imageVariantResolver struct {
+	variant apiv1.ImageVariant
+}
+
+func (r *imageVariantResolver) Name() string {
+	return r.variant.Name
+}
+
+func (r *imageVariantResolver) URL() string {
+	return r.variant.URL
+}
+
+func (r *imageVariantResolver) Width() int32 {
+	return int32(r.variant.Width)
+}
+
+func (r *imageVariantResolver) Height() int32 {
+	return int32(r.variant.Height)
+}
+
+type userResolver struct {
+	user   apiv1.User
+	logger *zap.Logger
+}
+
+func (r *userResolver) ID() graphql.ID {
+	return graphql.ID(strconv.Itoa(r.user.ID))
+}
+
+func (r *userResolver) FirstName() string {
+	return r.user.FirstName
+}
+
+func (r *userResolver) LastName() string {
+	return r.user.LastName
+}
+
+func (r *userResolver) Rentals(ctx context.Context) ([]*rentalResolver, error) {
+	rentals, err := loadersFrom(ctx).userRentals.Load(ctx, r.user.ID)()
+	if err != nil {
+		errorMsg := "Error getting rentals of user"
+		r.logger.Error(errorMsg, zap.Int("userID", r.user.ID), zap.Error(err))
+		return nil, errors.New(errorMsg)
+	}
+	resolvers := make([]*rentalResolver, len(rentals))
+	for i, rental := range rentals {
+		resolvers[i] = &rentalResolver{rental: rental, logger: r.logger}
+	}
+	return resolvers, nil
+}
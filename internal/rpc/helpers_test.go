@@ -0,0 +1,56 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/mkermilska/rentals-challenge/api/rentalspb"
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+)
+
+// fakeRentalService serves rentals 1 to 3 and records the params of the last list request.
+type fakeRentalService struct {
+	params database.RentalParams
+	err    error
+}
+
+func (s *fakeRentalService) GetRentalByID(_ context.Context, rentalID int) (*apiv1.Rental, error) {
+	if rentalID > 3 {
+		return nil, database.ErrNotFound
+	}
+	return &apiv1.Rental{ID: rentalID, Name: "Rental", Price: apiv1.Price{Day: 9900}, User: &apiv1.User{ID: 1}}, nil
+}
+
+func (s *fakeRentalService) GetRentals(_ context.Context, params database.RentalParams) ([]apiv1.Rental, error) {
+	s.params = params
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []apiv1.Rental{{ID: 1}, {ID: 2}, {ID: 3}}, nil
+}
+
+func newTestClient(t *testing.T, svc RentalService) rentalspb.RentalServiceClient {
+	listener := bufconn.Listen(1024 * 1024)
+	server := New(Options{}, svc, zap.NewNop())
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.Nil(t, err, "Error connecting to gRPC server")
+	t.Cleanup(func() { conn.Close() })
+	return rentalspb.NewRentalServiceClient(conn)
+}
@@ -0,0 +1,181 @@
+// Package rpc serves the rentals over gRPC, next to the REST API of package web.
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"github.com/mkermilska/rentals-challenge/api/rentalspb"
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+	"github.com/mkermilska/rentals-challenge/pkg/mapper"
+	"github.com/mkermilska/rentals-challenge/pkg/utils"
+)
+
+// defaultRadiusMiles matches the near parameter of the REST API.
+const defaultRadiusMiles = 100
+
+type Options struct {
+	Port int
+}
+
+// RentalService is what the gRPC API needs from service.RentalService.
+type RentalService interface {
+	GetRentalByID(ctx context.Context, rentalID int) (*apiv1.Rental, error)
+	GetRentals(ctx context.Context, params database.RentalParams) ([]apiv1.Rental, error)
+}
+
+type Server struct {
+	rentalspb.UnimplementedRentalServiceServer
+
+	port       int
+	rentalSvc  RentalService
+	logger     *zap.Logger
+	grpcServer *grpc.Server
+}
+
+func New(opts Options, rentalSvc RentalService, logger *zap.Logger) *Server {
+	s := &Server{
+		port:      opts.Port,
+		rentalSvc: rentalSvc,
+		logger:    logger,
+	}
+	s.grpcServer = grpc.NewServer()
+	rentalspb.RegisterRentalServiceServer(s.grpcServer, s)
+	reflection.Register(s.grpcServer)
+	return s
+}
+
+func (s *Server) Start() {
+	s.logger.Info("Starting gRPC Server", zap.Int("port", s.port))
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		s.logger.Error("Error listening for gRPC requests", zap.Error(err))
+		return
+	}
+	if err := s.Serve(listener); err != nil {
+		s.logger.Error("Error starting gRPC Server", zap.Error(err))
+	}
+}
+
+// Serve accepts gRPC connections on the listener until Stop is called.
+func (s *Server) Serve(listener net.Listener) error {
+	return s.grpcServer.Serve(listener)
+}
+
+func (s *Server) Stop() {
+	s.grpcServer.GracefulStop()
+}
+
+func (s *Server) GetRental(ctx context.Context, req *rentalspb.GetRentalRequest) (*rentalspb.Rental, error) {
+	rental, err := s.rentalSvc.GetRentalByID(ctx, int(req.GetId()))
+	if err != nil {
+		errorMsg := "Error getting rental"
+		if errors.Is(err, database.ErrNotFound) {
+			errorMsg = "Rental not found"
+		}
+		s.logger.Error(errorMsg, zap.Int64("rentalID", req.GetId()), zap.Error(err))
+		return nil, serviceError(err, errorMsg)
+	}
+	return mapper.APIRentalToProto(*rental), nil
+}
+
+func (s *Server) ListRentals(req *rentalspb.ListRentalsRequest, stream rentalspb.RentalService_ListRentalsServer) error {
+	params, err := rentalParams(req.GetPriceMin(), req.GetPriceMax(), req.GetLimit(), req.GetOffset(), req.GetSort(), req.GetIncludeUser())
+	if err != nil {
+		return err
+	}
+	for _, id := range req.GetIds() {
+		params.IDs = append(params.IDs, int(id))
+	}
+
+	rentals, err := s.rentalSvc.GetRentals(stream.Context(), params)
+	if err != nil {
+		errorMsg := "Error getting rentals"
+		s.logger.Error(errorMsg, zap.Error(err))
+		return serviceError(err, errorMsg)
+	}
+	for _, rental := range rentals {
+		if err := stream.Send(mapper.APIRentalToProto(rental)); err != nil {
+			s.logger.Info("Error streaming rentals", zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) SearchNear(ctx context.Context, req *rentalspb.SearchNearRequest) (*rentalspb.SearchNearResponse, error) {
+	if req.GetLat() < -90 || req.GetLat() > 90 || req.GetLng() < -180 || req.GetLng() > 180 {
+		return nil, status.Error(codes.InvalidArgument, "Invalid latitude or longitude")
+	}
+	if req.GetRadiusMiles() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "Radius must not be negative")
+	}
+	params, err := rentalParams(req.GetPriceMin(), req.GetPriceMax(), req.GetLimit(), req.GetOffset(), req.GetSort(), req.GetIncludeUser())
+	if err != nil {
+		return nil, err
+	}
+	radius := req.GetRadiusMiles()
+	if radius == 0 {
+		radius = defaultRadiusMiles
+	}
+	params.Near = *utils.CalculateNearBox(utils.Point{Lat: req.GetLat(), Lng: req.GetLng()}, radius)
+
+	rentals, err := s.rentalSvc.GetRentals(ctx, params)
+	if err != nil {
+		errorMsg := "Error searching rentals"
+		s.logger.Error(errorMsg, zap.Error(err))
+		return nil, serviceError(err, errorMsg)
+	}
+	resp := &rentalspb.SearchNearResponse{Rentals: make([]*rentalspb.Rental, len(rentals))}
+	for i, rental := range rentals {
+		resp.Rentals[i] = mapper.APIRentalToProto(rental)
+	}
+	return resp, nil
+}
+
+// rentalParams validates the filters shared by ListRentals and SearchNear, as the REST API does.
+func rentalParams(priceMin, priceMax int64, limit, offset int32, sort string, includeUser bool) (database.RentalParams, error) {
+	params := database.RentalParams{
+		PriceMin:    int(priceMin),
+		PriceMax:    int(priceMax),
+		Limit:       int(limit),
+		Offset:      int(offset),
+		IncludeUser: includeUser,
+	}
+	if priceMin < 0 || priceMax < 0 || limit < 0 || offset < 0 {
+		return params, status.Error(codes.InvalidArgument, "Prices, limit and offset must not be negative")
+	}
+	if sort != "" {
+		column, exists := apiv1.SortsMap[sort]
+		if !exists {
+			return params, status.Error(codes.InvalidArgument, "Sort by given column is not allowed")
+		}
+		params.Sort = column
+	}
+	return params, nil
+}
+
+// serviceError maps the errors of the service layer to gRPC status codes.
+func serviceError(err error, msg string) error {
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		return status.Error(codes.NotFound, msg)
+	case errors.Is(err, database.ErrInvalidQuery):
+		return status.Error(codes.InvalidArgument, msg)
+	case errors.Is(err, database.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, msg)
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, msg)
+	default:
+		return status.Error(codes.Internal, msg)
+	}
+}
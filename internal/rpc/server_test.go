@@ -0,0 +1,109 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/mkermilska/rentals-challenge/api/rentalspb"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+)
+
+func TestServer_GetRental(t *testing.T) {
+	client := newTestClient(t, &fakeRentalService{})
+
+	rental, err := client.GetRental(context.Background(), &rentalspb.GetRentalRequest{Id: 2})
+	require.Nil(t, err, "Error getting rental")
+	assert.Equal(t, int64(2), rental.GetId())
+	assert.Equal(t, int64(9900), rental.GetPrice().GetDay())
+	assert.Equal(t, int64(1), rental.GetUser().GetId())
+
+	_, err = client.GetRental(context.Background(), &rentalspb.GetRentalRequest{Id: 30})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestServer_ListRentals(t *testing.T) {
+	tests := map[string]struct {
+		req            *rentalspb.ListRentalsRequest
+		serviceErr     error
+		expectedIDs    []int64
+		expectedParams database.RentalParams
+		expectedCode   codes.Code
+	}{
+		"All rentals": {
+			req:         &rentalspb.ListRentalsRequest{},
+			expectedIDs: []int64{1, 2, 3},
+		},
+		"Filtered rentals": {
+			req: &rentalspb.ListRentalsRequest{
+				PriceMin: 9000, PriceMax: 20000, Limit: 3, Offset: 1, Ids: []int64{1, 2, 3}, Sort: "price", IncludeUser: true,
+			},
+			expectedIDs: []int64{1, 2, 3},
+			expectedParams: database.RentalParams{
+				PriceMin: 9000, PriceMax: 20000, Limit: 3, Offset: 1, IDs: []int{1, 2, 3}, Sort: "price_per_day", IncludeUser: true,
+			},
+		},
+		"Invalid sort": {
+			req:          &rentalspb.ListRentalsRequest{Sort: "size"},
+			expectedCode: codes.InvalidArgument,
+		},
+		"Negative limit": {
+			req:          &rentalspb.ListRentalsRequest{Limit: -1},
+			expectedCode: codes.InvalidArgument,
+		},
+		"Database timeout": {
+			req:          &rentalspb.ListRentalsRequest{},
+			serviceErr:   database.ErrTimeout,
+			expectedCode: codes.DeadlineExceeded,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			svc := &fakeRentalService{err: test.serviceErr}
+			client := newTestClient(t, svc)
+
+			stream, err := client.ListRentals(context.Background(), test.req)
+			require.Nil(t, err, "Error opening stream")
+			ids := []int64{}
+			for {
+				rental, err := stream.Recv()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					assert.Equal(t, test.expectedCode, status.Code(err))
+					return
+				}
+				ids = append(ids, rental.GetId())
+			}
+			assert.Equal(t, codes.OK, test.expectedCode, "Expected an error")
+			assert.Equal(t, test.expectedIDs, ids)
+			assert.Equal(t, test.expectedParams, svc.params)
+		})
+	}
+}
+
+func TestServer_SearchNear(t *testing.T) {
+	svc := &fakeRentalService{}
+	client := newTestClient(t, svc)
+
+	resp, err := client.SearchNear(context.Background(), &rentalspb.SearchNearRequest{Lat: 33.64, Lng: -117.93})
+	require.Nil(t, err, "Error searching rentals")
+	assert.Len(t, resp.GetRentals(), 3)
+	assert.InDelta(t, 32.19, svc.params.Near.MinLat, 0.01)
+	assert.InDelta(t, 35.09, svc.params.Near.MaxLat, 0.01)
+
+	_, err = client.SearchNear(context.Background(), &rentalspb.SearchNearRequest{Lat: 33.64, Lng: -117.93, RadiusMiles: 10})
+	require.Nil(t, err, "Error searching rentals")
+	assert.InDelta(t, 33.50, svc.params.Near.MinLat, 0.01)
+
+	_, err = client.SearchNear(context.Background(), &rentalspb.SearchNearRequest{Lat: 91, Lng: 0})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
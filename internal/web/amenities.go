@@ -0,0 +1,18 @@
+package web
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+func (a *APIServer) getAmenities(w http.ResponseWriter, r *http.Request) {
+	amenities, err := a.amenitySvc.GetAmenities(r.Context())
+	if err != nil {
+		errorMsg := "Error getting amenities"
+		a.logger.Error(errorMsg, zap.Error(err))
+		a.writeServiceError(w, r, err, errorMsg)
+		return
+	}
+	a.writeJSON(w, r, http.StatusOK, amenities)
+}
@@ -0,0 +1,75 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+)
+
+func TestAPIServer_Amenities(t *testing.T) {
+	tests := map[string]struct {
+		target            string
+		expectedStatus    int
+		expectedParam     string
+		expectedAmenities []string
+	}{
+		"Filter by one amenity": {
+			target:            "/v1/rentals?amenities=ac",
+			expectedStatus:    http.StatusOK,
+			expectedAmenities: []string{"ac"},
+		},
+		"Filter by several amenities": {
+			target:            "/v1/rentals?amenities=ac,pet_friendly",
+			expectedStatus:    http.StatusOK,
+			expectedAmenities: []string{"ac", "pet_friendly"},
+		},
+		"Filter by empty amenity": {
+			target:         "/v1/rentals?amenities=ac,",
+			expectedStatus: http.StatusBadRequest,
+			expectedParam:  "amenities",
+		},
+		"Filter by invalid amenity": {
+			target:         "/v1/rentals?amenities=Pet-Friendly",
+			expectedStatus: http.StatusBadRequest,
+			expectedParam:  "amenities",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fake := newFakeServices()
+			server := New(Options{Amenities: fake}, fake, zap.NewNop())
+			w := httptest.NewRecorder()
+			server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.target, nil))
+
+			require.Equal(t, test.expectedStatus, w.Code, w.Body.String())
+			if test.expectedParam != "" {
+				var problem apiv1.Problem
+				require.Nil(t, json.Unmarshal(w.Body.Bytes(), &problem), "Error decoding problem")
+				assert.Equal(t, test.expectedParam, problem.Param)
+				return
+			}
+			assert.Equal(t, test.expectedAmenities, fake.rentalParams.Amenities)
+		})
+	}
+}
+
+func TestAPIServer_GetAmenities(t *testing.T) {
+	fake := newFakeServices()
+	server := New(Options{Amenities: fake}, fake, zap.NewNop())
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/amenities", nil))
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var amenities []apiv1.Amenity
+	require.Nil(t, json.Unmarshal(w.Body.Bytes(), &amenities), "Error decoding amenities")
+	require.Len(t, amenities, 2)
+	assert.Equal(t, "pet_friendly", amenities[1].Code)
+}
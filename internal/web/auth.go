@@ -0,0 +1,66 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/auth"
+)
+
+// authenticate stores the caller identity in the request context. Requests without credentials
+// pass through anonymously, requests with invalid credentials are rejected.
+func (a *APIServer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.authenticator == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		identity, err := a.authenticator.Authenticate(r)
+		if err != nil {
+			if errors.Is(err, auth.ErrNoCredentials) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			errorMsg := "Invalid credentials"
+			a.logger.Info(errorMsg, zap.Error(err))
+			a.unauthorized(w, r, errorMsg)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), identity)))
+	})
+}
+
+// requireIdentity rejects anonymous requests.
+func (a *APIServer) requireIdentity(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := auth.FromContext(r.Context()); !ok {
+			a.unauthorized(w, r, "Authentication required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireAdmin rejects callers that are not in Options.AdminUserIDs, it runs after requireIdentity.
+func (a *APIServer) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, _ := auth.FromContext(r.Context())
+		if !slices.Contains(a.adminUserIDs, identity.UserID) {
+			a.logger.Info("Admin request denied", zap.Int("userID", identity.UserID))
+			a.writeProblem(w, r, http.StatusForbidden, apiv1.ErrCodeForbidden, "", "Administrator access required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *APIServer) unauthorized(w http.ResponseWriter, r *http.Request, detail string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="rentals", ApiKey realm="rentals"`)
+	a.writeProblem(w, r, http.StatusUnauthorized, apiv1.ErrCodeUnauthorized, "", detail)
+}
@@ -0,0 +1,99 @@
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+)
+
+// writeCacheable writes a JSON body with validators and answers conditional GETs with 304 (not modified).
+// The ETag is a hash of the body and decides alone when the client sends If-None-Match. Last-Modified
+// only reflects updates, so deletions are detected through If-None-Match only.
+func (a *APIServer) writeCacheable(w http.ResponseWriter, r *http.Request, contentType string, body []byte,
+	lastModified time.Time) {
+	sum := sha256.Sum256(body)
+	etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Add("Vary", "Accept")
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if a.cacheControl != "" {
+		w.Header().Set("Cache-Control", a.cacheControl)
+	}
+
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	_, err := w.Write(body)
+	if err != nil {
+		a.logger.Error("Error writing API response", zap.Error(err))
+	}
+}
+
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagMatches(inm, etag)
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ims)
+		return err == nil && !lastModified.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
+// etagMatches compares the If-None-Match list with etag using the weak comparison required for GET.
+// Tags of compressed variants match the tag of the uncompressed body.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || decodedETag(strings.TrimPrefix(candidate, "W/")) == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// etagListed reports whether the If-None-Match list holds etag itself, weak or strong.
+func etagListed(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// encodedETag derives the tag of a compressed variant, strong tags must differ per content encoding.
+func encodedETag(etag, encoding string) string {
+	return strings.TrimSuffix(etag, `"`) + "-" + encoding + `"`
+}
+
+func decodedETag(etag string) string {
+	for _, encoding := range []string{encodingBrotli, encodingGzip} {
+		if suffix := "-" + encoding + `"`; strings.HasSuffix(etag, suffix) {
+			return strings.TrimSuffix(etag, suffix) + `"`
+		}
+	}
+	return etag
+}
+
+func rentalsLastModified(rentals []apiv1.Rental) time.Time {
+	var lastModified time.Time
+	for _, rental := range rentals {
+		if rental.Updated.After(lastModified) {
+			lastModified = rental.Updated
+		}
+	}
+	return lastModified
+}
@@ -0,0 +1,138 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestEtagMatches(t *testing.T) {
+	etag := `"abc"`
+	tests := map[string]struct {
+		ifNoneMatch   string
+		expectedMatch bool
+	}{
+		"Same tag":                {ifNoneMatch: `"abc"`, expectedMatch: true},
+		"Other tag":               {ifNoneMatch: `"xyz"`, expectedMatch: false},
+		"Any tag":                 {ifNoneMatch: `*`, expectedMatch: true},
+		"Weak tag":                {ifNoneMatch: `W/"abc"`, expectedMatch: true},
+		"List with the tag":       {ifNoneMatch: `"xyz", W/"123",  "abc"`, expectedMatch: true},
+		"List without the tag":    {ifNoneMatch: `"xyz", W/"123"`, expectedMatch: false},
+		"Gzip variant":            {ifNoneMatch: `"abc-gzip"`, expectedMatch: true},
+		"Weak brotli variant":     {ifNoneMatch: `W/"abc-br"`, expectedMatch: true},
+		"Variant of another tag":  {ifNoneMatch: `"xyz-gzip"`, expectedMatch: false},
+		"Unquoted tag":            {ifNoneMatch: `abc`, expectedMatch: false},
+		"Tag with other suffix":   {ifNoneMatch: `"abc-deflate"`, expectedMatch: false},
+		"Empty element in a list": {ifNoneMatch: `, "abc"`, expectedMatch: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expectedMatch, etagMatches(test.ifNoneMatch, etag))
+		})
+	}
+}
+
+func TestAPIServer_WriteCacheable(t *testing.T) {
+	lastModified := time.Date(2021, 11, 29, 22, 42, 6, 500, time.UTC)
+	body := []byte(`{"id":1}`)
+	a := &APIServer{logger: zap.NewNop(), cacheControl: "max-age=60"}
+	etag := func() string {
+		w := httptest.NewRecorder()
+		a.writeCacheable(w, httptest.NewRequest(http.MethodGet, "/v1/rentals/1", nil), contentTypeJSON, body, lastModified)
+		return w.Header().Get("ETag")
+	}()
+	require.NotEmpty(t, etag)
+
+	tests := map[string]struct {
+		headers        map[string]string
+		lastModified   time.Time
+		expectedStatus int
+	}{
+		"Unconditional": {
+			lastModified:   lastModified,
+			expectedStatus: http.StatusOK,
+		},
+		"Matching tag": {
+			headers:        map[string]string{"If-None-Match": etag},
+			lastModified:   lastModified,
+			expectedStatus: http.StatusNotModified,
+		},
+		"Matching tag in a list": {
+			headers:        map[string]string{"If-None-Match": `"old", W/` + etag},
+			lastModified:   lastModified,
+			expectedStatus: http.StatusNotModified,
+		},
+		"Changed tag": {
+			headers:        map[string]string{"If-None-Match": `"old"`},
+			lastModified:   lastModified,
+			expectedStatus: http.StatusOK,
+		},
+		"Not modified since": {
+			headers:        map[string]string{"If-Modified-Since": lastModified.Format(http.TimeFormat)},
+			lastModified:   lastModified,
+			expectedStatus: http.StatusNotModified,
+		},
+		"Modified since": {
+			headers:        map[string]string{"If-Modified-Since": lastModified.Add(-time.Second).Format(http.TimeFormat)},
+			lastModified:   lastModified,
+			expectedStatus: http.StatusOK,
+		},
+		"Changed tag takes precedence over an unmodified date": {
+			headers: map[string]string{
+				"If-None-Match":     `"old"`,
+				"If-Modified-Since": lastModified.Add(time.Hour).Format(http.TimeFormat),
+			},
+			lastModified:   lastModified,
+			expectedStatus: http.StatusOK,
+		},
+		"Matching tag takes precedence over a modified date": {
+			headers: map[string]string{
+				"If-None-Match":     etag,
+				"If-Modified-Since": lastModified.Add(-time.Hour).Format(http.TimeFormat),
+			},
+			lastModified:   lastModified,
+			expectedStatus: http.StatusNotModified,
+		},
+		"Invalid date": {
+			headers:        map[string]string{"If-Modified-Since": "yesterday"},
+			lastModified:   lastModified,
+			expectedStatus: http.StatusOK,
+		},
+		"Date without last modified": {
+			headers:        map[string]string{"If-Modified-Since": lastModified.Format(http.TimeFormat)},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/rentals/1", nil)
+			for header, value := range test.headers {
+				req.Header.Set(header, value)
+			}
+			w := httptest.NewRecorder()
+			a.writeCacheable(w, req, contentTypeJSON, body, test.lastModified)
+
+			require.Equal(t, test.expectedStatus, w.Code)
+			assert.Equal(t, etag, w.Header().Get("ETag"))
+			assert.Equal(t, "max-age=60", w.Header().Get("Cache-Control"))
+			assert.Equal(t, "Accept", w.Header().Get("Vary"))
+			if !test.lastModified.IsZero() {
+				assert.Equal(t, "Mon, 29 Nov 2021 22:42:06 GMT", w.Header().Get("Last-Modified"))
+			}
+			if test.expectedStatus == http.StatusNotModified {
+				assert.Empty(t, w.Body.Bytes(), "304 responses carry no body")
+				assert.Empty(t, w.Header().Get("Content-Type"))
+				return
+			}
+			assert.Equal(t, body, w.Body.Bytes())
+			assert.Equal(t, contentTypeJSON, w.Header().Get("Content-Type"))
+		})
+	}
+}
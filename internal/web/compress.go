@@ -0,0 +1,204 @@
+package web
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"go.uber.org/zap"
+)
+
+const (
+	encodingBrotli = "br"
+	encodingGzip   = "gzip"
+)
+
+// compress encodes responses of at least minSize bytes with brotli or gzip, as negotiated through
+// Accept-Encoding. Smaller responses are sent as they are. A minSize of zero disables compression.
+func (a *APIServer) compress(minSize int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if minSize <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: minSize, status: http.StatusOK,
+				ifNoneMatch: r.Header.Get("If-None-Match")}
+			next.ServeHTTP(cw, r)
+			if err := cw.Close(); err != nil {
+				a.logger.Error("Error writing compressed API response", zap.Error(err))
+			}
+		})
+	}
+}
+
+// negotiateEncoding picks the supported encoding with the highest quality, preferring brotli on ties.
+// Codings with a quality of 0 are refused, "*" stands for the codings not listed (RFC 9110 12.5.3).
+func negotiateEncoding(acceptEncoding string) string {
+	qualities := make(map[string]float64, 2)
+	wildcard, hasWildcard := 0.0, false
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, q := parseQuality(part)
+		switch coding {
+		case encodingBrotli, encodingGzip:
+			qualities[coding] = q
+		case "*":
+			wildcard, hasWildcard = q, true
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, coding := range []string{encodingBrotli, encodingGzip} {
+		q, ok := qualities[coding]
+		if !ok && hasWildcard {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+// parseQuality splits a header element like "gzip;q=0.8" into its value and quality.
+func parseQuality(part string) (string, float64) {
+	value, params, _ := strings.Cut(part, ";")
+	q := 1.0
+	for _, param := range strings.Split(params, ";") {
+		name, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if strings.EqualFold(name, "q") {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+	}
+	return strings.ToLower(strings.TrimSpace(value)), q
+}
+
+// compressWriter buffers the response until it knows whether it reaches the compression threshold.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    string
+	minSize     int
+	ifNoneMatch string
+
+	status      int
+	wroteHeader bool
+	decided     bool
+	buf         bytes.Buffer
+	encoder     io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+	cw.status = status
+	if etag := cw.Header().Get("ETag"); status == http.StatusNotModified && etag != "" {
+		// the client revalidates the variant it has stored, which is the compressed one only when the
+		// body was large enough, as the tag it sends tells
+		if encoded := encodedETag(etag, cw.encoding); etagListed(cw.ifNoneMatch, encoded) {
+			cw.Header().Set("ETag", encoded)
+		}
+	}
+	// images are compressed already
+	if status != http.StatusOK || cw.Header().Get("Content-Encoding") != "" ||
+		strings.HasPrefix(cw.Header().Get("Content-Type"), "image/") {
+		cw.passThrough()
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.decided {
+		if cw.encoder != nil {
+			return cw.encoder.Write(p)
+		}
+		return cw.ResponseWriter.Write(p)
+	}
+
+	n, _ := cw.buf.Write(p)
+	if cw.buf.Len() >= cw.minSize {
+		if err := cw.startEncoding(); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// Flush sends the response uncompressed if nothing was decided yet, so streams are not held back.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if err := cw.flushBuffered(); err != nil {
+			return
+		}
+	}
+	if gz, ok := cw.encoder.(*gzip.Writer); ok {
+		_ = gz.Flush()
+	}
+	if br, ok := cw.encoder.(*brotli.Writer); ok {
+		_ = br.Flush()
+	}
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (cw *compressWriter) Close() error {
+	if !cw.decided {
+		return cw.flushBuffered()
+	}
+	if cw.encoder != nil {
+		return cw.encoder.Close()
+	}
+	return nil
+}
+
+func (cw *compressWriter) passThrough() {
+	cw.decided = true
+	cw.ResponseWriter.WriteHeader(cw.status)
+}
+
+func (cw *compressWriter) flushBuffered() error {
+	if !cw.wroteHeader {
+		cw.status = http.StatusOK
+	}
+	cw.passThrough()
+	_, err := cw.ResponseWriter.Write(cw.buf.Bytes())
+	cw.buf.Reset()
+	return err
+}
+
+func (cw *compressWriter) startEncoding() error {
+	cw.decided = true
+	header := cw.Header()
+	header.Set("Content-Encoding", cw.encoding)
+	header.Del("Content-Length")
+	if etag := header.Get("ETag"); etag != "" {
+		header.Set("ETag", encodedETag(etag, cw.encoding))
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	switch cw.encoding {
+	case encodingBrotli:
+		cw.encoder = brotli.NewWriterLevel(cw.ResponseWriter, brotli.DefaultCompression)
+	default:
+		cw.encoder = gzip.NewWriter(cw.ResponseWriter)
+	}
+	_, err := cw.encoder.Write(cw.buf.Bytes())
+	cw.buf.Reset()
+	return err
+}
@@ -0,0 +1,194 @@
+package web
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]struct {
+		acceptEncoding   string
+		expectedEncoding string
+	}{
+		"No header":                   {acceptEncoding: "", expectedEncoding: ""},
+		"Gzip":                        {acceptEncoding: "gzip", expectedEncoding: encodingGzip},
+		"Brotli preferred on ties":    {acceptEncoding: "gzip, deflate, br", expectedEncoding: encodingBrotli},
+		"Higher quality wins":         {acceptEncoding: "br;q=0.5, gzip;q=0.8", expectedEncoding: encodingGzip},
+		"Case and spaces":             {acceptEncoding: " GZIP ; Q=0.9 ", expectedEncoding: encodingGzip},
+		"Unsupported only":            {acceptEncoding: "deflate, identity", expectedEncoding: ""},
+		"Wildcard":                    {acceptEncoding: "*", expectedEncoding: encodingBrotli},
+		"Refused brotli":              {acceptEncoding: "br;q=0", expectedEncoding: ""},
+		"Refused brotli, gzip listed": {acceptEncoding: "br;q=0, gzip", expectedEncoding: encodingGzip},
+		"Refused wildcard":            {acceptEncoding: "*;q=0", expectedEncoding: ""},
+		"Gzip and refused wildcard":   {acceptEncoding: "gzip, *;q=0", expectedEncoding: encodingGzip},
+		"Wildcard and refused brotli": {acceptEncoding: "br;q=0, *", expectedEncoding: encodingGzip},
+		"Listed coding over wildcard": {acceptEncoding: "gzip;q=0.2, *;q=0.9", expectedEncoding: encodingBrotli},
+		"Refused gzip":                {acceptEncoding: "gzip;q=0.000", expectedEncoding: ""},
+		"Invalid quality is ignored":  {acceptEncoding: "gzip;q=high", expectedEncoding: encodingGzip},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expectedEncoding, negotiateEncoding(test.acceptEncoding))
+		})
+	}
+}
+
+func TestNegotiateContentType(t *testing.T) {
+	tests := map[string]struct {
+		accept              string
+		expectedContentType string
+		expectedOK          bool
+	}{
+		"No header":           {accept: "", expectedContentType: contentTypeJSON, expectedOK: true},
+		"Any":                 {accept: "*/*", expectedContentType: contentTypeJSON, expectedOK: true},
+		"CSV":                 {accept: "text/csv", expectedContentType: contentTypeCSV, expectedOK: true},
+		"Msgpack alias":       {accept: "application/x-msgpack", expectedContentType: contentTypeMsgpack, expectedOK: true},
+		"Highest quality":     {accept: "application/json;q=0.5, text/csv", expectedContentType: contentTypeCSV, expectedOK: true},
+		"Unsupported skipped": {accept: "text/html, application/json;q=0.1", expectedContentType: contentTypeJSON, expectedOK: true},
+		"Unsupported only":    {accept: "text/html, image/png", expectedOK: false},
+		"Refused only":        {accept: "application/json;q=0", expectedOK: false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			contentType, ok := negotiateContentType(test.accept)
+			assert.Equal(t, test.expectedOK, ok)
+			assert.Equal(t, test.expectedContentType, contentType)
+		})
+	}
+}
+
+func TestAPIServer_Compress(t *testing.T) {
+	large := strings.Repeat(`{"name":"Rental"}`, 100)
+	tests := map[string]struct {
+		method           string
+		acceptEncoding   string
+		status           int
+		contentType      string
+		body             string
+		etag             string
+		ifNoneMatch      string
+		expectedEncoding string
+		expectedETag     string
+	}{
+		"Gzip large response": {
+			acceptEncoding:   "gzip",
+			body:             large,
+			etag:             `"abc"`,
+			expectedEncoding: encodingGzip,
+			expectedETag:     `"abc-gzip"`,
+		},
+		"Brotli large response": {
+			acceptEncoding:   "gzip, br",
+			body:             large,
+			expectedEncoding: encodingBrotli,
+		},
+		"Small response is not compressed": {
+			acceptEncoding: "gzip",
+			body:           `{"id":1}`,
+			etag:           `"abc"`,
+			expectedETag:   `"abc"`,
+		},
+		"Refused encodings": {
+			acceptEncoding: "br;q=0, gzip;q=0",
+			body:           large,
+		},
+		"Error responses are not compressed": {
+			acceptEncoding: "gzip",
+			status:         http.StatusInternalServerError,
+			body:           large,
+		},
+		"Images are not compressed": {
+			acceptEncoding: "gzip",
+			contentType:    "image/png",
+			body:           large,
+		},
+		"Not modified tag of the compressed variant": {
+			acceptEncoding: "gzip",
+			status:         http.StatusNotModified,
+			etag:           `"abc"`,
+			ifNoneMatch:    `W/"abc-gzip"`,
+			expectedETag:   `"abc-gzip"`,
+		},
+		"Not modified tag of a small response": {
+			acceptEncoding: "gzip",
+			status:         http.StatusNotModified,
+			etag:           `"abc"`,
+			ifNoneMatch:    `"abc"`,
+			expectedETag:   `"abc"`,
+		},
+		"Not modified tag of a variant in another encoding": {
+			acceptEncoding: "gzip",
+			status:         http.StatusNotModified,
+			etag:           `"abc"`,
+			ifNoneMatch:    `"abc-br"`,
+			expectedETag:   `"abc"`,
+		},
+		"HEAD is not compressed": {
+			method:         http.MethodHead,
+			acceptEncoding: "gzip",
+			body:           large,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			a := &APIServer{logger: zap.NewNop()}
+			handler := a.compress(512)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if test.contentType != "" {
+					w.Header().Set("Content-Type", test.contentType)
+				}
+				if test.etag != "" {
+					w.Header().Set("ETag", test.etag)
+				}
+				if test.status != 0 {
+					w.WriteHeader(test.status)
+				}
+				_, _ = io.WriteString(w, test.body)
+			}))
+			method := test.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/v1/rentals", nil)
+			req.Header.Set("Accept-Encoding", test.acceptEncoding)
+			if test.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", test.ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			expectedStatus := test.status
+			if expectedStatus == 0 {
+				expectedStatus = http.StatusOK
+			}
+			require.Equal(t, expectedStatus, w.Code)
+			assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+			assert.Equal(t, test.expectedEncoding, w.Header().Get("Content-Encoding"))
+			assert.Equal(t, test.expectedETag, w.Header().Get("ETag"))
+
+			var body io.Reader = w.Body
+			switch test.expectedEncoding {
+			case encodingGzip:
+				gz, err := gzip.NewReader(w.Body)
+				require.Nil(t, err, "Error reading gzip body")
+				body = gz
+			case encodingBrotli:
+				body = brotli.NewReader(w.Body)
+			}
+			decoded, err := io.ReadAll(body)
+			require.Nil(t, err, "Error decoding body")
+			assert.Equal(t, test.body, string(decoded))
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/cors"
+)
+
+type CORSOptions struct {
+	// AllowedOrigins enables CORS when not empty. Origins may contain one "*" wildcard.
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	// MaxAge is how long, in seconds, browsers may cache preflight responses.
+	MaxAge int
+}
+
+// exposedHeaders are the response headers, besides the CORS-safelisted ones, that browsers may read.
+var exposedHeaders = []string{
+	middleware.RequestIDHeader,
+	"ETag",
+	"Location",
+	"Retry-After",
+	"X-RateLimit-Limit",
+	"X-RateLimit-Remaining",
+	"X-RateLimit-Reset",
+}
+
+// corsHandler answers preflight requests and adds CORS headers to responses for allowed origins.
+func corsHandler(opts CORSOptions) func(http.Handler) http.Handler {
+	if len(opts.AllowedOrigins) == 0 {
+		return func(next http.Handler) http.Handler {
+			return next
+		}
+	}
+	return cors.Handler(cors.Options{
+		AllowedOrigins: opts.AllowedOrigins,
+		AllowedMethods: opts.AllowedMethods,
+		AllowedHeaders: opts.AllowedHeaders,
+		ExposedHeaders: exposedHeaders,
+		MaxAge:         opts.MaxAge,
+	})
+}
@@ -0,0 +1,98 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestAPIServer_CORS(t *testing.T) {
+	corsOptions := CORSOptions{
+		AllowedOrigins: []string{"https://app.example.com", "https://*.rentals.example.com"},
+		AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
+		AllowedHeaders: []string{"Content-Type", "X-API-Key"},
+		MaxAge:         600,
+	}
+	tests := map[string]struct {
+		cors                 CORSOptions
+		method               string
+		origin               string
+		requestMethod        string
+		expectedAllowOrigin  string
+		expectedAllowMethods string
+		expectedMaxAge       string
+	}{
+		"Preflight of an allowed origin": {
+			cors:                 corsOptions,
+			method:               http.MethodOptions,
+			origin:               "https://app.example.com",
+			requestMethod:        http.MethodPut,
+			expectedAllowOrigin:  "https://app.example.com",
+			expectedAllowMethods: http.MethodPut,
+			expectedMaxAge:       "600",
+		},
+		"Preflight of a wildcard origin": {
+			cors:                 corsOptions,
+			method:               http.MethodOptions,
+			origin:               "https://admin.rentals.example.com",
+			requestMethod:        http.MethodDelete,
+			expectedAllowOrigin:  "https://admin.rentals.example.com",
+			expectedAllowMethods: http.MethodDelete,
+			expectedMaxAge:       "600",
+		},
+		"Preflight of a disallowed origin": {
+			cors:          corsOptions,
+			method:        http.MethodOptions,
+			origin:        "https://evil.example.com",
+			requestMethod: http.MethodPut,
+		},
+		"Preflight of a disallowed method": {
+			cors:          corsOptions,
+			method:        http.MethodOptions,
+			origin:        "https://app.example.com",
+			requestMethod: http.MethodPatch,
+		},
+		"Request of an allowed origin": {
+			cors:                corsOptions,
+			method:              http.MethodGet,
+			origin:              "https://app.example.com",
+			expectedAllowOrigin: "https://app.example.com",
+		},
+		"Request of a disallowed origin": {
+			cors:   corsOptions,
+			method: http.MethodGet,
+			origin: "https://evil.example.com",
+		},
+		"CORS disabled": {
+			method: http.MethodGet,
+			origin: "https://app.example.com",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := New(Options{CORS: test.cors}, newFakeServices(), zap.NewNop())
+			req := httptest.NewRequest(test.method, "/v1/rentals/1", nil)
+			req.Header.Set("Origin", test.origin)
+			if test.requestMethod != "" {
+				req.Header.Set("Access-Control-Request-Method", test.requestMethod)
+				req.Header.Set("Access-Control-Request-Headers", "X-API-Key")
+			}
+			w := httptest.NewRecorder()
+			server.Handler().ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedAllowOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, test.expectedAllowMethods, w.Header().Get("Access-Control-Allow-Methods"))
+			assert.Equal(t, test.expectedMaxAge, w.Header().Get("Access-Control-Max-Age"))
+			if test.method == http.MethodGet {
+				assert.Equal(t, http.StatusOK, w.Code)
+				if test.expectedAllowOrigin != "" {
+					assert.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "Etag")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/currency"
+)
+
+func (a *APIServer) getExchangeRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := a.currencySvc.GetExchangeRates(r.Context())
+	if err != nil {
+		errorMsg := "Error getting exchange rates"
+		a.logger.Error(errorMsg, zap.Error(err))
+		a.writeServiceError(w, r, err, errorMsg)
+		return
+	}
+	a.writeJSON(w, r, http.StatusOK, rates)
+}
+
+func (a *APIServer) putExchangeRates(w http.ResponseWriter, r *http.Request) {
+	var input []apiv1.ExchangeRate
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRentalBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&input); err != nil {
+		errorMsg := "Invalid exchange rates in request body"
+		a.logger.Info(errorMsg, zap.Error(err))
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, "", errorMsg)
+		return
+	}
+
+	rates, err := a.currencySvc.SetExchangeRates(r.Context(), input)
+	if err != nil {
+		if errors.Is(err, currency.ErrInvalidRate) {
+			errorMsg := "Invalid exchange rates: " + reason(err, currency.ErrInvalidRate)
+			a.logger.Info(errorMsg)
+			a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, "", errorMsg)
+			return
+		}
+		errorMsg := "Error updating exchange rates"
+		a.logger.Error(errorMsg, zap.Error(err))
+		a.writeServiceError(w, r, err, errorMsg)
+		return
+	}
+	a.writeJSON(w, r, http.StatusOK, rates)
+}
+
+// parseCurrency reads the currency parameter, upper cased. It is empty when the parameter is missing.
+func parseCurrency(query url.Values) (string, *paramError) {
+	if !query.Has("currency") {
+		return "", nil
+	}
+	code := strings.ToUpper(query.Get("currency"))
+	if !currency.ValidCode(code) {
+		return "", &paramError{"currency", "Currency must be a three letter ISO 4217 code", nil}
+	}
+	return code, nil
+}
+
+// readRates loads the exchange rates when prices are requested in another currency, answering 400
+// when no rate of the currency is known. The rates are nil when code is empty.
+func (a *APIServer) readRates(w http.ResponseWriter, r *http.Request, code string) (currency.Rates, bool) {
+	if code == "" {
+		return nil, true
+	}
+	if a.currencySvc == nil {
+		errorMsg := "Currency conversion is not available"
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidParameter, "currency", errorMsg)
+		return nil, false
+	}
+	rates, err := a.currencySvc.GetRates(r.Context())
+	if err != nil {
+		errorMsg := "Error getting exchange rates"
+		a.logger.Error(errorMsg, zap.Error(err))
+		a.writeServiceError(w, r, err, errorMsg)
+		return nil, false
+	}
+	if !rates.Has(code) {
+		errorMsg := "No exchange rate is known for currency " + code
+		a.logger.Info(errorMsg)
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidParameter, "currency", errorMsg)
+		return nil, false
+	}
+	return rates, true
+}
+
+// convertRentals returns copies of the rentals with prices in the to currency, the rentals may be
+// shared with the cache.
+func convertRentals(rentals []apiv1.Rental, rates currency.Rates, to string) []apiv1.Rental {
+	converted := make([]apiv1.Rental, len(rentals))
+	for i, rental := range rentals {
+		rental.Price = rates.ConvertPrice(rental.Price, to)
+		converted[i] = rental
+	}
+	return converted
+}
@@ -0,0 +1,146 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/auth"
+)
+
+func TestAPIServer_Currency(t *testing.T) {
+	tests := map[string]struct {
+		method          string
+		target          string
+		body            string
+		apiKey          string
+		expectedStatus  int
+		expectedParam   string
+		expectedProblem string
+		expectedPrices  []apiv1.Price
+	}{
+		"Get rental in its currency": {
+			method:         http.MethodGet,
+			target:         "/v1/rentals/1",
+			expectedStatus: http.StatusOK,
+			expectedPrices: []apiv1.Price{{Day: 16900, Currency: "USD"}},
+		},
+		"Get rental in euros": {
+			method:         http.MethodGet,
+			target:         "/v1/rentals/1?currency=EUR",
+			expectedStatus: http.StatusOK,
+			expectedPrices: []apiv1.Price{{Day: 15548, Currency: "EUR"}},
+		},
+		"List rentals in lower case currency": {
+			method:         http.MethodGet,
+			target:         "/v1/rentals?currency=cad",
+			expectedStatus: http.StatusOK,
+			expectedPrices: []apiv1.Price{{Day: 22815, Currency: "CAD"}, {Day: 22815, Currency: "CAD"}},
+		},
+		"Currency without a rate": {
+			method:          http.MethodGet,
+			target:          "/v1/rentals?currency=GBP&price_min=10000",
+			expectedStatus:  http.StatusBadRequest,
+			expectedParam:   "currency",
+			expectedProblem: "No exchange rate is known for currency GBP",
+		},
+		"Invalid currency code": {
+			method:         http.MethodGet,
+			target:         "/v1/rentals/1?currency=EURO",
+			expectedStatus: http.StatusBadRequest,
+			expectedParam:  "currency",
+		},
+		"List exchange rates": {
+			method:         http.MethodGet,
+			target:         "/v1/exchange-rates",
+			expectedStatus: http.StatusOK,
+		},
+		"Update exchange rates": {
+			method:         http.MethodPut,
+			target:         "/v1/admin/exchange-rates",
+			body:           `[{"currency": "CAD", "rate": 1.36}]`,
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusOK,
+		},
+		"Update exchange rates without authentication": {
+			method:         http.MethodPut,
+			target:         "/v1/admin/exchange-rates",
+			body:           `[{"currency": "CAD", "rate": 1.36}]`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"Update exchange rates as another user": {
+			method:         http.MethodPut,
+			target:         "/v1/admin/exchange-rates",
+			body:           `[{"currency": "CAD", "rate": 1.36}]`,
+			apiKey:         "user-key",
+			expectedStatus: http.StatusForbidden,
+		},
+		"Update the rate of the base currency": {
+			method:          http.MethodPut,
+			target:          "/v1/admin/exchange-rates",
+			body:            `[{"currency": "USD", "rate": 1.1}]`,
+			apiKey:          "admin-key",
+			expectedStatus:  http.StatusBadRequest,
+			expectedProblem: "Invalid exchange rates: the rate of the base currency USD must be 1",
+		},
+	}
+
+	fake := newFakeServices()
+	server := New(Options{
+		Authenticator: auth.NewAPIKeyAuthenticator(map[string]int{"admin-key": 1, "user-key": 2}),
+		Currencies:    fake,
+		AdminUserIDs:  []int{1},
+	}, fake, zap.NewNop())
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.target, bytes.NewBufferString(test.body))
+			req.Header.Set("Content-Type", "application/json")
+			if test.apiKey != "" {
+				req.Header.Set("X-API-Key", test.apiKey)
+			}
+			w := httptest.NewRecorder()
+			server.Handler().ServeHTTP(w, req)
+
+			require.Equal(t, test.expectedStatus, w.Code, w.Body.String())
+			if test.expectedParam != "" || test.expectedProblem != "" {
+				var problem apiv1.Problem
+				require.Nil(t, json.Unmarshal(w.Body.Bytes(), &problem), "Error decoding problem")
+				assert.Equal(t, test.expectedParam, problem.Param)
+				if test.expectedProblem != "" {
+					assert.Equal(t, test.expectedProblem, problem.Detail)
+				}
+			}
+			if test.expectedPrices != nil {
+				var rentals []apiv1.Rental
+				if err := json.Unmarshal(w.Body.Bytes(), &rentals); err != nil {
+					var rental apiv1.Rental
+					require.Nil(t, json.Unmarshal(w.Body.Bytes(), &rental), "Error decoding rental")
+					rentals = []apiv1.Rental{rental}
+				}
+				prices := make([]apiv1.Price, len(rentals))
+				for i, rental := range rentals {
+					prices[i] = rental.Price
+				}
+				assert.Equal(t, test.expectedPrices, prices)
+			}
+		})
+	}
+}
+
+func TestAPIServer_CurrencyLastModified(t *testing.T) {
+	fake := newFakeServices()
+	server := New(Options{Currencies: fake}, fake, zap.NewNop())
+	for target, expectLastModified := range map[string]bool{"/v1/rentals/1": true, "/v1/rentals/1?currency=EUR": false} {
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, expectLastModified, w.Header().Get("Last-Modified") != "", target)
+	}
+}
@@ -0,0 +1,68 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+	"github.com/mkermilska/rentals-challenge/pkg/service"
+)
+
+// requestIDHeader echoes the request ID generated by middleware.RequestID back to the client.
+func requestIDHeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(middleware.RequestIDHeader, middleware.GetReqID(r.Context()))
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *APIServer) writeProblem(w http.ResponseWriter, r *http.Request, status int, code, param, detail string) {
+	problem := apiv1.Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      code,
+		Param:     param,
+		RequestID: middleware.GetReqID(r.Context()),
+	}
+
+	out, err := json.Marshal(problem)
+	if err != nil {
+		a.logger.Error("Error parsing problem", zap.Any("problem", problem), zap.Error(err))
+		http.Error(w, detail, status)
+		return
+	}
+
+	w.Header().Set("Content-Type", apiv1.ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_, err = w.Write(out)
+	if err != nil {
+		a.logger.Error("Error writing API response", zap.Error(err))
+	}
+}
+
+// writeServiceError maps an error returned by the service layer to a problem response.
+func (a *APIServer) writeServiceError(w http.ResponseWriter, r *http.Request, err error, detail string) {
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		a.writeProblem(w, r, http.StatusNotFound, apiv1.ErrCodeNotFound, "", detail)
+	case errors.Is(err, service.ErrForbidden):
+		a.writeProblem(w, r, http.StatusForbidden, apiv1.ErrCodeForbidden, "", detail)
+	case errors.Is(err, database.ErrConflict):
+		a.writeProblem(w, r, http.StatusConflict, apiv1.ErrCodeConflict, "", detail)
+	case errors.Is(err, database.ErrInvalidQuery):
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidQuery, "", detail)
+	case errors.Is(err, database.ErrTimeout):
+		a.writeProblem(w, r, http.StatusGatewayTimeout, apiv1.ErrCodeTimeout, "", detail)
+	default:
+		a.writeProblem(w, r, http.StatusInternalServerError, apiv1.ErrCodeInternal, "", detail)
+	}
+}
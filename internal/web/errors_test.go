@@ -0,0 +1,106 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+	"github.com/mkermilska/rentals-challenge/pkg/service"
+)
+
+func TestAPIServer_WriteServiceError(t *testing.T) {
+	tests := map[string]struct {
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		"Not found": {
+			err:            database.ErrNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   apiv1.ErrCodeNotFound,
+		},
+		"Wrapped not found": {
+			err:            fmt.Errorf("not found rentals with id 3: %w", database.ErrNotFound),
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   apiv1.ErrCodeNotFound,
+		},
+		"Forbidden": {
+			err:            fmt.Errorf("%w: rental 2 is owned by another user", service.ErrForbidden),
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   apiv1.ErrCodeForbidden,
+		},
+		"Conflict": {
+			err:            database.ErrConflict,
+			expectedStatus: http.StatusConflict,
+			expectedCode:   apiv1.ErrCodeConflict,
+		},
+		"Invalid query": {
+			err:            database.ErrInvalidQuery,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   apiv1.ErrCodeInvalidQuery,
+		},
+		"Timeout": {
+			err:            fmt.Errorf("%w: %w", database.ErrTimeout, context.DeadlineExceeded),
+			expectedStatus: http.StatusGatewayTimeout,
+			expectedCode:   apiv1.ErrCodeTimeout,
+		},
+		"Other error": {
+			err:            errors.New("connection reset"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   apiv1.ErrCodeInternal,
+		},
+	}
+
+	a := &APIServer{logger: zap.NewNop()}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/rentals/3", nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.RequestIDKey, "req-1"))
+			w := httptest.NewRecorder()
+			a.writeServiceError(w, req, test.err, "Error getting rental")
+
+			require.Equal(t, test.expectedStatus, w.Code)
+			assert.Equal(t, apiv1.ProblemContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+			var problem apiv1.Problem
+			require.Nil(t, json.Unmarshal(w.Body.Bytes(), &problem), "Error decoding problem")
+			assert.Equal(t, apiv1.Problem{
+				Type:      "about:blank",
+				Title:     http.StatusText(test.expectedStatus),
+				Status:    test.expectedStatus,
+				Detail:    "Error getting rental",
+				Instance:  "/v1/rentals/3",
+				Code:      test.expectedCode,
+				RequestID: "req-1",
+			}, problem)
+		})
+	}
+}
+
+func TestAPIServer_WriteProblem(t *testing.T) {
+	server := New(Options{}, newFakeServices(), zap.NewNop())
+	req := httptest.NewRequest(http.MethodGet, "/v1/rentals/abc", nil)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, apiv1.ProblemContentType, w.Header().Get("Content-Type"))
+	var problem apiv1.Problem
+	require.Nil(t, json.Unmarshal(w.Body.Bytes(), &problem), "Error decoding problem")
+	assert.Equal(t, http.StatusBadRequest, problem.Status)
+	assert.Equal(t, apiv1.ErrCodeInvalidParameter, problem.Code)
+	assert.Equal(t, "rentalID", problem.Param)
+	assert.NotEmpty(t, problem.RequestID)
+	assert.Equal(t, problem.RequestID, w.Header().Get(middleware.RequestIDHeader), "The request id is echoed")
+}
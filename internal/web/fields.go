@@ -0,0 +1,169 @@
+package web
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+)
+
+type rentalField struct {
+	name  string
+	value func(rental apiv1.Rental) interface{}
+}
+
+// rentalFields lists the leaf fields of apiv1.Rental in response order, nested objects use dotted names.
+// They drive the fields parameter and the columns of CSV responses.
+var rentalFields = []rentalField{
+	{"id", func(r apiv1.Rental) interface{} { return r.ID }},
+	{"name", func(r apiv1.Rental) interface{} { return r.Name }},
+	{"description", func(r apiv1.Rental) interface{} { return r.Description }},
+	{"type", func(r apiv1.Rental) interface{} { return r.Type }},
+	{"make", func(r apiv1.Rental) interface{} { return r.Make }},
+	{"model", func(r apiv1.Rental) interface{} { return r.Model }},
+	{"year", func(r apiv1.Rental) interface{} { return r.Year }},
+	{"length", func(r apiv1.Rental) interface{} { return r.Length }},
+	{"sleeps", func(r apiv1.Rental) interface{} { return r.Sleeps }},
+	{"primary_image_url", func(r apiv1.Rental) interface{} { return r.PrimaryImageURL }},
+	{"price.day", func(r apiv1.Rental) interface{} { return r.Price.Day }},
+	{"price.week", optionalValue(func(p apiv1.Price) *int { return p.Week })},
+	{"price.month", optionalValue(func(p apiv1.Price) *int { return p.Month })},
+	{"price.security_deposit", optionalValue(func(p apiv1.Price) *int { return p.SecurityDeposit })},
+	{"price.cleaning_fee", optionalValue(func(p apiv1.Price) *int { return p.CleaningFee })},
+	{"price.per_mile", optionalValue(func(p apiv1.Price) *int { return p.PerMile })},
+	{"price.currency", func(r apiv1.Rental) interface{} { return r.Price.Currency }},
+	{"location.city", func(r apiv1.Rental) interface{} { return r.Location.City }},
+	{"location.state", func(r apiv1.Rental) interface{} { return r.Location.State }},
+	{"location.zip", func(r apiv1.Rental) interface{} { return r.Location.Zip }},
+	{"location.country", func(r apiv1.Rental) interface{} { return r.Location.Country }},
+	{"location.lat", func(r apiv1.Rental) interface{} { return r.Location.Lat }},
+	{"location.lng", func(r apiv1.Rental) interface{} { return r.Location.Lng }},
+	{"images", func(r apiv1.Rental) interface{} { return r.Images }},
+	{"amenities", func(r apiv1.Rental) interface{} { return r.Amenities }},
+	{"rating_avg", ratingValue},
+	{"rating_count", func(r apiv1.Rental) interface{} { return r.RatingCount }},
+	{"user.id", userValue(func(u *apiv1.User) interface{} { return u.ID })},
+	{"user.first_name", userValue(func(u *apiv1.User) interface{} { return u.FirstName })},
+	{"user.last_name", userValue(func(u *apiv1.User) interface{} { return u.LastName })},
+}
+
+// userValue reads a user field, nil when the owner was not included.
+func userValue(value func(u *apiv1.User) interface{}) func(r apiv1.Rental) interface{} {
+	return func(r apiv1.Rental) interface{} {
+		if r.User == nil {
+			return nil
+		}
+		return value(r.User)
+	}
+}
+
+// optionalValue reads a charge of the price, nil when the rental does not offer it.
+func optionalValue(value func(p apiv1.Price) *int) func(r apiv1.Rental) interface{} {
+	return func(r apiv1.Rental) interface{} {
+		amount := value(r.Price)
+		if amount == nil {
+			return nil
+		}
+		return *amount
+	}
+}
+
+// ratingValue reads the average rating, nil for rentals without reviews.
+func ratingValue(r apiv1.Rental) interface{} {
+	if r.RatingAvg == nil {
+		return nil
+	}
+	return *r.RatingAvg
+}
+
+// parseFields resolves a comma separated list of field names. A nested object name, like price,
+// selects all of its fields.
+func parseFields(value string) ([]rentalField, error) {
+	selected := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, field := range rentalFields {
+			if field.name == name || strings.HasPrefix(field.name, name+".") {
+				selected[field.name] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+	}
+
+	fields := make([]rentalField, 0, len(selected))
+	for _, field := range rentalFields {
+		if selected[field.name] {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+func selectsUser(fields []rentalField) bool {
+	for _, field := range fields {
+		if strings.HasPrefix(field.name, "user.") {
+			return true
+		}
+	}
+	return false
+}
+
+// projectRental builds the nested object holding only the given fields. Fields of an owner that
+// was not included are left out.
+func projectRental(rental apiv1.Rental, fields []rentalField) map[string]interface{} {
+	out := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		value := field.value(rental)
+		if value == nil {
+			continue
+		}
+		obj, path := out, strings.Split(field.name, ".")
+		for _, key := range path[:len(path)-1] {
+			nested, ok := obj[key].(map[string]interface{})
+			if !ok {
+				nested = make(map[string]interface{})
+				obj[key] = nested
+			}
+			obj = nested
+		}
+		obj[path[len(path)-1]] = value
+	}
+	return out
+}
+
+func projectRentals(rentals []apiv1.Rental, fields []rentalField) []map[string]interface{} {
+	out := make([]map[string]interface{}, len(rentals))
+	for i, rental := range rentals {
+		out[i] = projectRental(rental, fields)
+	}
+	return out
+}
+
+func formatCSVValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case int:
+		return strconv.Itoa(v)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []apiv1.RentalImage:
+		// the gallery is a single column of space separated URLs
+		urls := make([]string, len(v))
+		for i, image := range v {
+			urls[i] = image.URL
+		}
+		return strings.Join(urls, " ")
+	case []string:
+		return strings.Join(v, " ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
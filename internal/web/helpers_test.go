@@ -0,0 +1,396 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/currency"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+	"github.com/mkermilska/rentals-challenge/pkg/mapper"
+	"github.com/mkermilska/rentals-challenge/pkg/pricing"
+	"github.com/mkermilska/rentals-challenge/pkg/service"
+)
+
+// fakeServices implements every service of the API over fixed data:
+//   - rentals 1 and 2, owned by users 1 and 2, with images 1 and 2 on rental 1
+//   - reviews of rentals 1 and 2, booking 1 of rental 1 by user 1 is completed, booking 2 was made
+//     by user 2, booking 3 did not end yet and booking 4 was already reviewed
+//   - pricing of rental 1 at 10000 per day with a minimum of 2 nights
+//   - saved search 1 of user 1
+//   - the rates of USD, CAD and EUR, and a catalogue of two amenities
+//   - events 1 to 3, priced 100, 200 and 300 per day, more are added with addEvent
+//
+// It records the arguments tests check and reports cacheStats when set.
+type fakeServices struct {
+	mu      sync.Mutex
+	events  []apiv1.RentalEvent
+	changes chan database.RentalChange
+
+	cacheStats   *service.CacheStats
+	rentalParams database.RentalParams
+	searchParams database.RentalParams
+	callerID     int
+}
+
+func newFakeServices() *fakeServices {
+	s := &fakeServices{changes: make(chan database.RentalChange, 1)}
+	for id := 1; id <= 3; id++ {
+		s.events = append(s.events, fakeRentalEvent(int64(id), id*100))
+	}
+	return s
+}
+
+func fakeRental(id int) apiv1.Rental {
+	rating := 4.5
+	return apiv1.Rental{
+		ID:              id,
+		Name:            fmt.Sprintf("Rental %d", id),
+		Description:     "A camper van",
+		Type:            "camper-van",
+		Make:            "Volkswagen",
+		Model:           "Westfalia",
+		Year:            1984,
+		Length:          16,
+		Sleeps:          4,
+		PrimaryImageURL: "https://example.com/image.jpg",
+		Price:           apiv1.Price{Day: 16900, Currency: "USD"},
+		Location: apiv1.Location{
+			City: "Costa Mesa", State: "CA", Zip: "92627", Country: "US", Lat: 33.64, Lng: -117.93,
+		},
+		Images:      []apiv1.RentalImage{{ID: id, URL: "https://example.com/image.jpg", Caption: "Front"}},
+		Amenities:   []string{"ac", "pet_friendly"},
+		RatingAvg:   &rating,
+		RatingCount: 2,
+		User:        &apiv1.User{ID: id, FirstName: "John", LastName: "Smith"},
+		Updated:     time.Date(2021, 11, 29, 22, 42, 6, 0, time.UTC),
+	}
+}
+
+func fakeRentalEvent(id int64, price int) apiv1.RentalEvent {
+	return apiv1.RentalEvent{
+		ID: id, Type: apiv1.EventRentalUpdated, RentalID: int(id),
+		Rental: apiv1.Rental{ID: int(id), Price: apiv1.Price{Day: price}},
+	}
+}
+
+func (s *fakeServices) GetRentalByID(_ context.Context, rentalID int) (*apiv1.Rental, error) {
+	if rentalID > 2 {
+		return nil, database.ErrNotFound
+	}
+	rental := fakeRental(rentalID)
+	return &rental, nil
+}
+
+func (s *fakeServices) GetRentals(_ context.Context, params database.RentalParams) ([]apiv1.Rental, error) {
+	s.mu.Lock()
+	s.rentalParams = params
+	s.mu.Unlock()
+	rentals := []apiv1.Rental{}
+	for _, rental := range []apiv1.Rental{fakeRental(1), fakeRental(2)} {
+		if params.IDs == nil || containsID(params.IDs, rental.ID) {
+			rentals = append(rentals, rental)
+		}
+	}
+	if !params.IncludeUser {
+		for i := range rentals {
+			rentals[i].User = nil
+		}
+	}
+	return rentals, nil
+}
+
+func containsID(IDs []int, ID int) bool {
+	for _, id := range IDs {
+		if id == ID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *fakeServices) CreateRental(_ context.Context, ownerID int, input apiv1.RentalInput) (*apiv1.Rental, error) {
+	s.callerID = ownerID
+	for _, code := range input.Amenities {
+		if code == "hot_tub" {
+			return nil, fmt.Errorf("%w: %q is not in the catalogue", service.ErrUnknownAmenity, code)
+		}
+	}
+	rental := fakeRental(3)
+	rental.Name = input.Name
+	rental.Price = input.Price
+	if rental.Price.Currency == "" {
+		rental.Price.Currency = apiv1.DefaultCurrency
+	}
+	if input.Amenities != nil {
+		rental.Amenities = input.Amenities
+	}
+	rental.User.ID = ownerID
+	return &rental, nil
+}
+
+func (s *fakeServices) UpdateRental(ctx context.Context, ownerID, rentalID int, input apiv1.RentalInput) (*apiv1.Rental, error) {
+	if err := s.DeleteRental(ctx, ownerID, rentalID); err != nil {
+		return nil, err
+	}
+	rental := fakeRental(rentalID)
+	rental.Name = input.Name
+	return &rental, nil
+}
+
+func (s *fakeServices) DeleteRental(_ context.Context, ownerID, rentalID int) error {
+	s.callerID = ownerID
+	if rentalID > 2 {
+		return database.ErrNotFound
+	}
+	if rentalID != ownerID {
+		return service.ErrForbidden
+	}
+	return nil
+}
+
+func (s *fakeServices) CacheStats() *service.CacheStats {
+	return s.cacheStats
+}
+
+func (s *fakeServices) GetRentalImages(_ context.Context, rentalID int) ([]apiv1.RentalImage, error) {
+	if rentalID != 1 {
+		return nil, database.ErrNotFound
+	}
+	return []apiv1.RentalImage{
+		{ID: 1, URL: "https://example.com/front.jpg", Position: 0},
+		{ID: 2, URL: "https://example.com/back.jpg", Position: 1},
+	}, nil
+}
+
+func (s *fakeServices) AddRentalImage(ctx context.Context, ownerID, rentalID int, input apiv1.RentalImageInput) (*apiv1.RentalImage, error) {
+	images, err := s.ownedImages(ctx, ownerID, rentalID)
+	if err != nil {
+		return nil, err
+	}
+	position := len(images)
+	if input.Position != nil {
+		position = min(*input.Position, position)
+	}
+	return &apiv1.RentalImage{ID: 3, URL: input.URL, Position: position, Caption: input.Caption,
+		Width: input.Width, Height: input.Height}, nil
+}
+
+func (s *fakeServices) ReorderRentalImages(ctx context.Context, ownerID, rentalID int, order apiv1.ImageOrder) ([]apiv1.RentalImage, error) {
+	images, err := s.ownedImages(ctx, ownerID, rentalID)
+	if err != nil {
+		return nil, err
+	}
+	if len(order.ImageIDs) != len(images) {
+		return nil, fmt.Errorf("%w: rental %d has %d images, %d are listed", service.ErrInvalidImageOrder,
+			rentalID, len(images), len(order.ImageIDs))
+	}
+	reordered := make([]apiv1.RentalImage, len(images))
+	for i, imageID := range order.ImageIDs {
+		reordered[i] = images[imageID-1]
+		reordered[i].Position = i
+	}
+	return reordered, nil
+}
+
+func (s *fakeServices) DeleteRentalImage(ctx context.Context, ownerID, rentalID, imageID int) error {
+	images, err := s.ownedImages(ctx, ownerID, rentalID)
+	if err != nil {
+		return err
+	}
+	if imageID > len(images) {
+		return database.ErrNotFound
+	}
+	return nil
+}
+
+func (s *fakeServices) ownedImages(ctx context.Context, ownerID, rentalID int) ([]apiv1.RentalImage, error) {
+	images, err := s.GetRentalImages(ctx, rentalID)
+	if err != nil {
+		return nil, err
+	}
+	if ownerID != 1 {
+		return nil, service.ErrForbidden
+	}
+	return images, nil
+}
+
+// UploadRentalImage accepts uploads starting with "GIF" to rental 1 of user 1.
+func (s *fakeServices) UploadRentalImage(_ context.Context, ownerID, rentalID int, data []byte, caption string,
+	position *int) (*apiv1.RentalImage, error) {
+	switch {
+	case rentalID != 1:
+		return nil, database.ErrNotFound
+	case ownerID != 1:
+		return nil, service.ErrForbidden
+	case !bytes.HasPrefix(data, []byte("GIF")):
+		return nil, fmt.Errorf("%w: unknown format", service.ErrInvalidImage)
+	}
+	image := &apiv1.RentalImage{ID: 3, URL: "http://localhost/media/rentals/1/abc/original.gif", Position: 2,
+		Caption: caption, Variants: []apiv1.ImageVariant{
+			{Name: "w160", URL: "http://localhost/media/rentals/1/abc/w160.png", Width: 160, Height: 120},
+		}}
+	if position != nil {
+		image.Position = *position
+	}
+	return image, nil
+}
+
+func (s *fakeServices) GetAmenities(_ context.Context) ([]apiv1.Amenity, error) {
+	return []apiv1.Amenity{
+		{Code: "ac", Name: "Air conditioning", Category: "comfort"},
+		{Code: "pet_friendly", Name: "Pet friendly", Category: "rules"},
+	}, nil
+}
+
+func (s *fakeServices) GetReviews(_ context.Context, rentalID, limit, offset int) ([]apiv1.Review, error) {
+	if rentalID > 2 {
+		return nil, database.ErrNotFound
+	}
+	reviews := []apiv1.Review{}
+	for id := 2 + offset; id > 0 && len(reviews) < limit; id-- {
+		reviews = append(reviews, apiv1.Review{ID: id, RentalID: rentalID, BookingID: id + 3, UserID: 2, Rating: 4,
+			Text: "Great van", Created: time.Date(2023, 9, 21, 10, 0, 0, 0, time.UTC)})
+	}
+	return reviews, nil
+}
+
+func (s *fakeServices) CreateReview(_ context.Context, userID, rentalID int, input apiv1.ReviewInput) (*apiv1.Review, error) {
+	switch {
+	case rentalID > 2:
+		return nil, database.ErrNotFound
+	case rentalID != 1 || input.BookingID > 4:
+		return nil, fmt.Errorf("%w: booking %d is not a booking of rental %d", service.ErrInvalidBooking,
+			input.BookingID, rentalID)
+	case input.BookingID == 2 || userID != 1:
+		return nil, service.ErrForbidden
+	case input.BookingID == 3:
+		return nil, fmt.Errorf("%w: booking 3 ends on 2030-07-08", service.ErrBookingNotCompleted)
+	case input.BookingID == 4:
+		return nil, database.ErrConflict
+	}
+	return &apiv1.Review{ID: 3, RentalID: rentalID, BookingID: input.BookingID, UserID: userID, Rating: input.Rating,
+		Text: input.Text, Created: time.Date(2023, 9, 21, 10, 0, 0, 0, time.UTC)}, nil
+}
+
+func (s *fakeServices) GetPricing(_ context.Context, rentalID int) (*apiv1.PricingRules, error) {
+	if rentalID != 1 {
+		return nil, database.ErrNotFound
+	}
+	return &apiv1.PricingRules{Seasons: []apiv1.Season{}, MinNights: 2}, nil
+}
+
+func (s *fakeServices) SetPricing(ctx context.Context, ownerID, rentalID int, rules apiv1.PricingRules) (*apiv1.PricingRules, error) {
+	if _, err := s.GetPricing(ctx, rentalID); err != nil {
+		return nil, err
+	}
+	if ownerID != 1 {
+		return nil, service.ErrForbidden
+	}
+	rentalPricing, err := mapper.APIPricingRulesToRentalPricing(rentalID, rules)
+	if err != nil {
+		return nil, err
+	}
+	if err := mapper.RentalPricingToRules(10000, *rentalPricing).Validate(); err != nil {
+		return nil, err
+	}
+	return &rules, nil
+}
+
+func (s *fakeServices) GetQuote(_ context.Context, rentalID int, from, to time.Time) (*apiv1.Quote, error) {
+	if rentalID != 1 {
+		return nil, database.ErrNotFound
+	}
+	quote, err := pricing.NewQuote(pricing.Rules{PricePerDay: 10000, MinNights: 2}, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return mapper.QuoteToAPIQuote(rentalID, *quote), nil
+}
+
+func (s *fakeServices) CreateSavedSearch(_ context.Context, ownerID int, input apiv1.SavedSearchInput,
+	params database.RentalParams) (*apiv1.SavedSearch, error) {
+	s.searchParams = params
+	return &apiv1.SavedSearch{ID: 2, UserID: ownerID, Query: input.Query, CallbackURL: input.CallbackURL, Secret: "secret"}, nil
+}
+
+func (s *fakeServices) GetSavedSearch(_ context.Context, ownerID, searchID int) (*apiv1.SavedSearch, error) {
+	switch {
+	case searchID != 1:
+		return nil, database.ErrNotFound
+	case ownerID != 1:
+		return nil, service.ErrForbidden
+	}
+	return &apiv1.SavedSearch{ID: 1, UserID: 1, Query: "price_max=10000", CallbackURL: "http://localhost/hook"}, nil
+}
+
+func (s *fakeServices) GetSavedSearches(ctx context.Context, ownerID int) ([]apiv1.SavedSearch, error) {
+	if ownerID != 1 {
+		return []apiv1.SavedSearch{}, nil
+	}
+	search, _ := s.GetSavedSearch(ctx, ownerID, 1)
+	return []apiv1.SavedSearch{*search}, nil
+}
+
+func (s *fakeServices) DeleteSavedSearch(ctx context.Context, ownerID, searchID int) error {
+	_, err := s.GetSavedSearch(ctx, ownerID, searchID)
+	return err
+}
+
+func (s *fakeServices) GetWebhookDeliveries(ctx context.Context, ownerID, searchID, limit int) ([]apiv1.WebhookDelivery, error) {
+	if _, err := s.GetSavedSearch(ctx, ownerID, searchID); err != nil {
+		return nil, err
+	}
+	return []apiv1.WebhookDelivery{{ID: 1, EventID: "evt", Attempt: 1, StatusCode: limit}}, nil
+}
+
+func (s *fakeServices) GetExchangeRates(_ context.Context) ([]apiv1.ExchangeRate, error) {
+	return []apiv1.ExchangeRate{{Currency: "CAD", Rate: 1.35}, {Currency: "EUR", Rate: 0.92}, {Currency: "USD", Rate: 1}}, nil
+}
+
+func (s *fakeServices) SetExchangeRates(ctx context.Context, rates []apiv1.ExchangeRate) ([]apiv1.ExchangeRate, error) {
+	if err := currency.Validate(rates); err != nil {
+		return nil, err
+	}
+	return s.GetExchangeRates(ctx)
+}
+
+func (s *fakeServices) GetRates(ctx context.Context) (currency.Rates, error) {
+	rates, err := s.GetExchangeRates(ctx)
+	return currency.NewRates(rates), err
+}
+
+// addEvent adds an event and notifies the subscribers.
+func (s *fakeServices) addEvent(event apiv1.RentalEvent) {
+	s.mu.Lock()
+	s.events = append(s.events, event)
+	s.mu.Unlock()
+	s.changes <- database.RentalChange{Op: database.ChangeUpdate, ID: event.RentalID}
+}
+
+func (s *fakeServices) LastRentalEventID(context.Context) (int64, error) {
+	return 3, nil
+}
+
+func (s *fakeServices) GetRentalEvents(_ context.Context, params database.RentalParams, afterID int64) (*service.RentalEventPage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	page := &service.RentalEventPage{Events: []apiv1.RentalEvent{}, Next: afterID}
+	for _, event := range s.events {
+		if event.ID <= afterID {
+			continue
+		}
+		page.Next = event.ID
+		if params.Matches(database.Rental{ID: event.RentalID, PricePerDay: event.Rental.Price.Day}) {
+			page.Events = append(page.Events, event)
+		}
+	}
+	return page, nil
+}
+
+func (s *fakeServices) SubscribeChanges(int) (<-chan database.RentalChange, func()) {
+	return s.changes, func() {}
+}
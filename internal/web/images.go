@@ -0,0 +1,133 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/auth"
+	"github.com/mkermilska/rentals-challenge/pkg/service"
+)
+
+func (a *APIServer) getRentalImages(w http.ResponseWriter, r *http.Request) {
+	rentalID, ok := a.readRentalID(w, r)
+	if !ok {
+		return
+	}
+
+	images, err := a.imageSvc.GetRentalImages(r.Context(), rentalID)
+	if err != nil {
+		errorMsg := "Error getting rental images"
+		a.logger.Error(errorMsg, zap.Int("rentalID", rentalID), zap.Error(err))
+		a.writeServiceError(w, r, err, errorMsg)
+		return
+	}
+	a.writeJSON(w, r, http.StatusOK, images)
+}
+
+func (a *APIServer) addRentalImage(w http.ResponseWriter, r *http.Request) {
+	identity, _ := auth.FromContext(r.Context())
+	rentalID, ok := a.readRentalID(w, r)
+	if !ok {
+		return
+	}
+	input := apiv1.RentalImageInput{}
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRentalBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&input); err != nil {
+		errorMsg := "Invalid image in request body"
+		a.logger.Info(errorMsg, zap.Error(err))
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, "", errorMsg)
+		return
+	}
+	if param, errorMsg := validateImageInput(input); errorMsg != "" {
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, param, errorMsg)
+		return
+	}
+
+	image, err := a.imageSvc.AddRentalImage(r.Context(), identity.UserID, rentalID, input)
+	if err != nil {
+		errorMsg := "Error adding rental image"
+		a.logger.Error(errorMsg, zap.Int("rentalID", rentalID), zap.Error(err))
+		a.writeServiceError(w, r, err, errorMsg)
+		return
+	}
+	a.writeJSON(w, r, http.StatusCreated, image)
+}
+
+func (a *APIServer) reorderRentalImages(w http.ResponseWriter, r *http.Request) {
+	identity, _ := auth.FromContext(r.Context())
+	rentalID, ok := a.readRentalID(w, r)
+	if !ok {
+		return
+	}
+	input := apiv1.ImageOrder{}
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRentalBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&input); err != nil {
+		errorMsg := "Invalid image order in request body"
+		a.logger.Info(errorMsg, zap.Error(err))
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, "", errorMsg)
+		return
+	}
+
+	images, err := a.imageSvc.ReorderRentalImages(r.Context(), identity.UserID, rentalID, input)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidImageOrder) {
+			errorMsg := "Invalid image order: " + reason(err, service.ErrInvalidImageOrder)
+			a.logger.Info(errorMsg, zap.Int("rentalID", rentalID))
+			a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, "image_ids", errorMsg)
+			return
+		}
+		errorMsg := "Error reordering rental images"
+		a.logger.Error(errorMsg, zap.Int("rentalID", rentalID), zap.Error(err))
+		a.writeServiceError(w, r, err, errorMsg)
+		return
+	}
+	a.writeJSON(w, r, http.StatusOK, images)
+}
+
+func (a *APIServer) deleteRentalImage(w http.ResponseWriter, r *http.Request) {
+	identity, _ := auth.FromContext(r.Context())
+	rentalID, ok := a.readRentalID(w, r)
+	if !ok {
+		return
+	}
+	imageID, err := strconv.Atoi(chi.URLParam(r, "imageID"))
+	if err != nil {
+		errorMsg := "Incorrect image ID, please enter a valid number"
+		a.logger.Info(errorMsg, zap.String("imageID", chi.URLParam(r, "imageID")), zap.Error(err))
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidParameter, "imageID", errorMsg)
+		return
+	}
+
+	err = a.imageSvc.DeleteRentalImage(r.Context(), identity.UserID, rentalID, imageID)
+	if err != nil {
+		errorMsg := "Error deleting rental image"
+		a.logger.Error(errorMsg, zap.Int("rentalID", rentalID), zap.Int("imageID", imageID), zap.Error(err))
+		a.writeServiceError(w, r, err, errorMsg)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func validateImageInput(input apiv1.RentalImageInput) (param, errorMsg string) {
+	imageURL, err := url.Parse(input.URL)
+	switch {
+	case err != nil || (imageURL.Scheme != "http" && imageURL.Scheme != "https") || imageURL.Host == "":
+		return "url", "Image URL must be an absolute http or https URL"
+	case input.Position != nil && *input.Position < 0:
+		return "position", "Position can not be negative"
+	case input.Width != nil && *input.Width <= 0:
+		return "width", "Width must be positive"
+	case input.Height != nil && *input.Height <= 0:
+		return "height", "Height must be positive"
+	}
+	return "", ""
+}
@@ -0,0 +1,154 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/auth"
+)
+
+func TestAPIServer_Images(t *testing.T) {
+	tests := map[string]struct {
+		method            string
+		target            string
+		body              string
+		apiKey            string
+		expectedStatus    int
+		expectedParam     string
+		expectedProblem   string
+		expectedImageURLs []string
+	}{
+		"Get images": {
+			method:            http.MethodGet,
+			target:            "/v1/rentals/1/images",
+			expectedStatus:    http.StatusOK,
+			expectedImageURLs: []string{"https://example.com/front.jpg", "https://example.com/back.jpg"},
+		},
+		"Get images of missing rental": {
+			method:         http.MethodGet,
+			target:         "/v1/rentals/30/images",
+			expectedStatus: http.StatusNotFound,
+		},
+		"Add image": {
+			method:            http.MethodPost,
+			target:            "/v1/rentals/1/images",
+			body:              `{"url": "https://example.com/side.jpg", "caption": "Side", "width": 1600, "height": 1200}`,
+			apiKey:            "key-1",
+			expectedStatus:    http.StatusCreated,
+			expectedImageURLs: []string{"https://example.com/side.jpg"},
+		},
+		"Add image without authentication": {
+			method:         http.MethodPost,
+			target:         "/v1/rentals/1/images",
+			body:           `{"url": "https://example.com/side.jpg"}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"Add image to rental of another user": {
+			method:         http.MethodPost,
+			target:         "/v1/rentals/1/images",
+			body:           `{"url": "https://example.com/side.jpg"}`,
+			apiKey:         "key-2",
+			expectedStatus: http.StatusForbidden,
+		},
+		"Add image with relative URL": {
+			method:          http.MethodPost,
+			target:          "/v1/rentals/1/images",
+			body:            `{"url": "/images/side.jpg"}`,
+			apiKey:          "key-1",
+			expectedStatus:  http.StatusBadRequest,
+			expectedParam:   "url",
+			expectedProblem: "Image URL must be an absolute http or https URL",
+		},
+		"Add image with negative position": {
+			method:         http.MethodPost,
+			target:         "/v1/rentals/1/images",
+			body:           `{"url": "https://example.com/side.jpg", "position": -1}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusBadRequest,
+			expectedParam:  "position",
+		},
+		"Reorder images": {
+			method:            http.MethodPost,
+			target:            "/v1/rentals/1/images:reorder",
+			body:              `{"image_ids": [2, 1]}`,
+			apiKey:            "key-1",
+			expectedStatus:    http.StatusOK,
+			expectedImageURLs: []string{"https://example.com/back.jpg", "https://example.com/front.jpg"},
+		},
+		"Reorder some of the images": {
+			method:          http.MethodPost,
+			target:          "/v1/rentals/1/images:reorder",
+			body:            `{"image_ids": [2]}`,
+			apiKey:          "key-1",
+			expectedStatus:  http.StatusBadRequest,
+			expectedParam:   "image_ids",
+			expectedProblem: "Invalid image order: rental 1 has 2 images, 1 are listed",
+		},
+		"Delete image": {
+			method:         http.MethodDelete,
+			target:         "/v1/rentals/1/images/2",
+			apiKey:         "key-1",
+			expectedStatus: http.StatusNoContent,
+		},
+		"Delete missing image": {
+			method:         http.MethodDelete,
+			target:         "/v1/rentals/1/images/5",
+			apiKey:         "key-1",
+			expectedStatus: http.StatusNotFound,
+		},
+		"Delete image with invalid ID": {
+			method:         http.MethodDelete,
+			target:         "/v1/rentals/1/images/front",
+			apiKey:         "key-1",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	fake := newFakeServices()
+	server := New(Options{
+		Authenticator: auth.NewAPIKeyAuthenticator(map[string]int{"key-1": 1, "key-2": 2}),
+		Images:        fake,
+	}, fake, zap.NewNop())
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.target, bytes.NewBufferString(test.body))
+			req.Header.Set("Content-Type", "application/json")
+			if test.apiKey != "" {
+				req.Header.Set("X-API-Key", test.apiKey)
+			}
+			w := httptest.NewRecorder()
+			server.Handler().ServeHTTP(w, req)
+
+			require.Equal(t, test.expectedStatus, w.Code, w.Body.String())
+			if test.expectedParam != "" || test.expectedProblem != "" {
+				var problem apiv1.Problem
+				require.Nil(t, json.Unmarshal(w.Body.Bytes(), &problem), "Error decoding problem")
+				assert.Equal(t, test.expectedParam, problem.Param)
+				if test.expectedProblem != "" {
+					assert.Equal(t, test.expectedProblem, problem.Detail)
+				}
+			}
+			if test.expectedImageURLs != nil {
+				var images []apiv1.RentalImage
+				if err := json.Unmarshal(w.Body.Bytes(), &images); err != nil {
+					var image apiv1.RentalImage
+					require.Nil(t, json.Unmarshal(w.Body.Bytes(), &image), "Error decoding image")
+					images = []apiv1.RentalImage{image}
+				}
+				urls := make([]string, len(images))
+				for i, image := range images {
+					urls[i] = image.URL
+				}
+				assert.Equal(t, test.expectedImageURLs, urls)
+			}
+		})
+	}
+}
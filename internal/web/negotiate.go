@@ -0,0 +1,109 @@
+package web
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+)
+
+const (
+	contentTypeJSON    = "application/json"
+	contentTypeCSV     = "text/csv"
+	contentTypeMsgpack = "application/msgpack"
+)
+
+// mediaTypes maps the accepted media types, including common aliases, to the content type served.
+var mediaTypes = map[string]string{
+	"application/json":        contentTypeJSON,
+	"application/*":           contentTypeJSON,
+	"*/*":                     contentTypeJSON,
+	"text/csv":                contentTypeCSV,
+	"text/*":                  contentTypeCSV,
+	"application/msgpack":     contentTypeMsgpack,
+	"application/x-msgpack":   contentTypeMsgpack,
+	"application/vnd.msgpack": contentTypeMsgpack,
+}
+
+// negotiateContentType picks the representation with the highest quality in the Accept header.
+// Without an Accept header JSON is served.
+func negotiateContentType(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return contentTypeJSON, true
+	}
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, q := parseQuality(part)
+		contentType, ok := mediaTypes[mediaType]
+		if !ok || q <= bestQ {
+			continue
+		}
+		best, bestQ = contentType, q
+	}
+	return best, best != ""
+}
+
+// encodeRental serializes one rental, trimmed to fields when they are given.
+func encodeRental(contentType string, rental apiv1.Rental, fields []rentalField) ([]byte, error) {
+	if contentType == contentTypeCSV {
+		return rentalsCSV([]apiv1.Rental{rental}, fields)
+	}
+	if fields == nil {
+		return marshal(contentType, rental)
+	}
+	return marshal(contentType, projectRental(rental, fields))
+}
+
+// encodeRentals serializes rentals, trimmed to fields when they are given.
+func encodeRentals(contentType string, rentals []apiv1.Rental, fields []rentalField) ([]byte, error) {
+	if contentType == contentTypeCSV {
+		return rentalsCSV(rentals, fields)
+	}
+	if fields == nil {
+		return marshal(contentType, rentals)
+	}
+	return marshal(contentType, projectRentals(rentals, fields))
+}
+
+func marshal(contentType string, v interface{}) ([]byte, error) {
+	if contentType != contentTypeMsgpack {
+		return json.Marshal(v)
+	}
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// rentalsCSV writes a header row with the dotted field names and one row per rental.
+func rentalsCSV(rentals []apiv1.Rental, fields []rentalField) ([]byte, error) {
+	if fields == nil {
+		fields = rentalFields
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	row := make([]string, len(fields))
+	for i, field := range fields {
+		row[i] = field.name
+	}
+	if err := w.Write(row); err != nil {
+		return nil, err
+	}
+	for _, rental := range rentals {
+		for i, field := range fields {
+			row[i] = formatCSVValue(field.value(rental))
+		}
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
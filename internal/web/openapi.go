@@ -0,0 +1,94 @@
+package web
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+)
+
+//go:embed static/swagger.html
+var swaggerPage []byte
+
+// newOpenAPIRouter loads the embedded OpenAPI document and builds a router finding its operations.
+func newOpenAPIRouter() (routers.Router, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(apiv1.OpenAPI)
+	if err != nil {
+		return nil, fmt.Errorf("error loading openapi document: %w", err)
+	}
+	if err = doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid openapi document: %w", err)
+	}
+	return gorillamux.NewRouter(doc)
+}
+
+// validateRequest rejects requests that do not match the OpenAPI document. Authentication is left
+// to the authenticate middleware, requests to operations missing from the document pass through.
+func (a *APIServer) validateRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.openAPIRouter == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		route, pathParams, err := a.openAPIRouter.FindRoute(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		err = openapi3filter.ValidateRequest(r.Context(), &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			},
+		})
+		if err != nil {
+			a.logger.Info("Request does not match the API specification", zap.Error(err))
+			var requestErr *openapi3filter.RequestError
+			switch {
+			case errors.As(err, &requestErr) && requestErr.Parameter != nil:
+				name := requestErr.Parameter.Name
+				errorMsg := fmt.Sprintf("Invalid value for %s parameter", name)
+				a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidParameter, name, errorMsg)
+			case errors.As(err, &requestErr) && requestErr.RequestBody != nil:
+				param, reason := "", requestErr.Reason
+				var schemaErr *openapi3.SchemaError
+				if errors.As(err, &schemaErr) {
+					param, reason = strings.Join(schemaErr.JSONPointer(), "."), schemaErr.Reason
+				} else if requestErr.Err != nil {
+					reason = requestErr.Err.Error()
+				}
+				a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, param, "Invalid request body: "+reason)
+			default:
+				a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidParameter, "", err.Error())
+			}
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *APIServer) getOpenAPI(w http.ResponseWriter, r *http.Request) {
+	a.writeCacheable(w, r, contentTypeJSON, apiv1.OpenAPI, time.Time{})
+}
+
+func (a *APIServer) getDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, err := w.Write(swaggerPage)
+	if err != nil {
+		a.logger.Error("Error writing API response", zap.Error(err))
+	}
+}
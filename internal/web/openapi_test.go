@@ -0,0 +1,267 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/mkermilska/rentals-challenge/pkg/auth"
+)
+
+func TestAPIServer_OpenAPIResponses(t *testing.T) {
+	router, err := newOpenAPIRouter()
+	require.Nil(t, err, "Error loading OpenAPI document")
+
+	fake := newFakeServices()
+	server := New(Options{
+		Authenticator: auth.NewAPIKeyAuthenticator(map[string]int{"key-1": 1}),
+		Amenities:     fake,
+		Reviews:       fake,
+	}, fake, zap.NewNop())
+	httpServer := httptest.NewServer(server.Handler())
+	defer httpServer.Close()
+
+	validBody := `{"name": "Camper", "type": "camper-van", "price": {"day": 9900}}`
+	tests := map[string]struct {
+		method         string
+		path           string
+		body           string
+		apiKey         string
+		expectedStatus int
+	}{
+		"List rentals": {
+			method:         http.MethodGet,
+			path:           "/v1/rentals?sort=price&limit=2",
+			expectedStatus: http.StatusOK,
+		},
+		"List rentals with fields": {
+			method:         http.MethodGet,
+			path:           "/v1/rentals?fields=id,price,location.lat,location.lng",
+			expectedStatus: http.StatusOK,
+		},
+		"List rentals without owner": {
+			method:         http.MethodGet,
+			path:           "/v1/rentals?include=",
+			expectedStatus: http.StatusOK,
+		},
+		"List rentals with invalid price_min": {
+			method:         http.MethodGet,
+			path:           "/v1/rentals?price_min=16k",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"List rentals by weekly price": {
+			method:         http.MethodGet,
+			path:           "/v1/rentals?price_max=60000&price_unit=week&sort=week",
+			expectedStatus: http.StatusOK,
+		},
+		"List rentals with invalid price_unit": {
+			method:         http.MethodGet,
+			path:           "/v1/rentals?price_max=60000&price_unit=hour",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"List rentals with invalid sort": {
+			method:         http.MethodGet,
+			path:           "/v1/rentals?sort=size",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"List rentals with invalid near": {
+			method:         http.MethodGet,
+			path:           "/v1/rentals?near=33.64,-117.93,18",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"List rentals with amenities": {
+			method:         http.MethodGet,
+			path:           "/v1/rentals?amenities=ac,pet_friendly",
+			expectedStatus: http.StatusOK,
+		},
+		"List rentals with invalid amenities": {
+			method:         http.MethodGet,
+			path:           "/v1/rentals?amenities=ac,,Pet",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"List rentals by rating": {
+			method:         http.MethodGet,
+			path:           "/v1/rentals?rating_min=4&sort=rating",
+			expectedStatus: http.StatusOK,
+		},
+		"List rentals with invalid rating_min": {
+			method:         http.MethodGet,
+			path:           "/v1/rentals?rating_min=6",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"List reviews": {
+			method:         http.MethodGet,
+			path:           "/v1/rentals/1/reviews?limit=10",
+			expectedStatus: http.StatusOK,
+		},
+		"Create review": {
+			method:         http.MethodPost,
+			path:           "/v1/rentals/1/reviews",
+			body:           `{"booking_id": 1, "rating": 5, "text": "Spotless van"}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusCreated,
+		},
+		"Create second review of booking": {
+			method:         http.MethodPost,
+			path:           "/v1/rentals/1/reviews",
+			body:           `{"booking_id": 4, "rating": 5, "text": "Spotless van"}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusConflict,
+		},
+		"List amenities": {
+			method:         http.MethodGet,
+			path:           "/v1/amenities",
+			expectedStatus: http.StatusOK,
+		},
+		"Get rental": {
+			method:         http.MethodGet,
+			path:           "/v1/rentals/1",
+			expectedStatus: http.StatusOK,
+		},
+		"Get missing rental": {
+			method:         http.MethodGet,
+			path:           "/v1/rentals/3000",
+			expectedStatus: http.StatusNotFound,
+		},
+		"Get rental with invalid id": {
+			method:         http.MethodGet,
+			path:           "/v1/rentals/id30",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"Batch get rentals": {
+			method:         http.MethodPost,
+			path:           "/v1/rentals:batchGet",
+			body:           `{"ids": [2, 3000, 1]}`,
+			expectedStatus: http.StatusOK,
+		},
+		"Batch get without ids": {
+			method:         http.MethodPost,
+			path:           "/v1/rentals:batchGet",
+			body:           `{"ids": []}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		"Create rental anonymously": {
+			method:         http.MethodPost,
+			path:           "/v1/rentals",
+			body:           validBody,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"Create rental": {
+			method:         http.MethodPost,
+			path:           "/v1/rentals",
+			body:           validBody,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusCreated,
+		},
+		"Create rental with all charges": {
+			method: http.MethodPost,
+			path:   "/v1/rentals",
+			body: `{"name": "Camper", "type": "camper-van", "price": {"day": 9900, "week": 59400, "month": 237600,
+				"security_deposit": 50000, "cleaning_fee": 7500, "per_mile": 35, "currency": "CAD"}}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusCreated,
+		},
+		"Create rental with amenities": {
+			method:         http.MethodPost,
+			path:           "/v1/rentals",
+			body:           `{"name": "Camper", "type": "camper-van", "price": {"day": 9900}, "amenities": ["ac", "shower"]}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusCreated,
+		},
+		"Create rental with unknown amenity": {
+			method:         http.MethodPost,
+			path:           "/v1/rentals",
+			body:           `{"name": "Camper", "type": "camper-van", "price": {"day": 9900}, "amenities": ["hot_tub"]}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"Create rental with negative cleaning fee": {
+			method:         http.MethodPost,
+			path:           "/v1/rentals",
+			body:           `{"name": "Camper", "type": "camper-van", "price": {"day": 9900, "cleaning_fee": -1}}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"Create rental with unknown field": {
+			method:         http.MethodPost,
+			path:           "/v1/rentals",
+			body:           `{"name": "Camper", "type": "camper-van", "color": "red"}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"Update own rental": {
+			method:         http.MethodPut,
+			path:           "/v1/rentals/1",
+			body:           validBody,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusOK,
+		},
+		"Update rental of another owner": {
+			method:         http.MethodPut,
+			path:           "/v1/rentals/2",
+			body:           validBody,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusForbidden,
+		},
+		"Delete own rental": {
+			method:         http.MethodDelete,
+			path:           "/v1/rentals/1",
+			apiKey:         "key-1",
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest(test.method, httpServer.URL+test.path, bytes.NewBufferString(test.body))
+			require.Nil(t, err, "Error creating request")
+			if test.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if test.apiKey != "" {
+				req.Header.Set(auth.APIKeyHeader, test.apiKey)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			require.Nil(t, err, "Error sending request")
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			require.Nil(t, err, "Error reading response")
+			assert.Equal(t, test.expectedStatus, resp.StatusCode, string(body))
+
+			// the router matches paths only, the request is rebuilt without the test server host
+			specReq := httptest.NewRequest(test.method, test.path, nil)
+			route, pathParams, err := router.FindRoute(specReq)
+			require.Nil(t, err, "Operation missing from the OpenAPI document")
+			err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: &openapi3filter.RequestValidationInput{
+					Request:    specReq,
+					PathParams: pathParams,
+					Route:      route,
+				},
+				Status:  resp.StatusCode,
+				Header:  resp.Header,
+				Body:    io.NopCloser(bytes.NewReader(body)),
+				Options: &openapi3filter.Options{IncludeResponseStatus: true},
+			})
+			assert.Nil(t, err, "Response does not match the OpenAPI document")
+		})
+	}
+}
+
+func TestAPIServer_OpenAPIDocument(t *testing.T) {
+	server := New(Options{}, newFakeServices(), zap.NewNop())
+	for _, path := range []string{"/v1/openapi.json", "/v1/docs"} {
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, w.Code, path)
+	}
+}
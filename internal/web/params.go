@@ -0,0 +1,149 @@
+package web
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+	"github.com/mkermilska/rentals-challenge/pkg/utils"
+)
+
+// paramError describes the query parameter that could not be parsed.
+type paramError struct {
+	param string
+	msg   string
+	err   error
+}
+
+// parseRentalParams reads the filters, sort and pagination of the rentals list from query.
+// The projection parameters fields and include are left to readProjection.
+func parseRentalParams(query url.Values) (database.RentalParams, *paramError) {
+	//reading the input params could be simplified with using a library.
+	queryParams := database.RentalParams{}
+	if query.Has("price_min") {
+		minPrice, err := strconv.Atoi(query.Get("price_min"))
+		if err != nil {
+			return queryParams, &paramError{"price_min", "Invalid value for price_min parameter", err}
+		}
+		queryParams.PriceMin = minPrice
+	}
+
+	if query.Has("price_max") {
+		maxPrice, err := strconv.Atoi(query.Get("price_max"))
+		if err != nil {
+			return queryParams, &paramError{"price_max", "Invalid value for price_max parameter", err}
+		}
+		queryParams.PriceMax = maxPrice
+	}
+
+	if query.Has("price_unit") {
+		column, exists := apiv1.PriceUnitsMap[query.Get("price_unit")]
+		if !exists {
+			return queryParams, &paramError{"price_unit", "Price unit must be one of the fields of price", nil}
+		}
+		queryParams.PriceUnit = column
+	}
+
+	if query.Has("ids") {
+		IDs := query.Get("ids")
+		if IDs == "" {
+			return queryParams, &paramError{"ids", "Empty ids parameter", nil}
+		}
+		for _, ID := range strings.Split(IDs, ",") {
+			rentalID, err := strconv.Atoi(ID)
+			if err != nil {
+				return queryParams, &paramError{"ids", "Invalid id exists in ids parameter", err}
+			}
+			queryParams.IDs = append(queryParams.IDs, rentalID)
+		}
+	}
+
+	if query.Has("near") {
+		near := query.Get("near")
+		if near == "" {
+			return queryParams, &paramError{"near", "Empty near parameter", nil}
+		}
+		nearPoint := strings.Split(near, ",")
+		if len(nearPoint) != 2 {
+			return queryParams, &paramError{"near", "Near parameter expects comma separated pair of float numbers", nil}
+		}
+		latPoint, err := strconv.ParseFloat(nearPoint[0], 64)
+		if err != nil {
+			return queryParams, &paramError{"near", "Invalid latitude value in near parameter", err}
+		}
+		lngPoint, err := strconv.ParseFloat(nearPoint[1], 64)
+		if err != nil {
+			return queryParams, &paramError{"near", "Invalid longitude value in near parameter", err}
+		}
+		queryParams.Near = *utils.CalculateNearBox(utils.Point{
+			Lat: latPoint,
+			Lng: lngPoint,
+		}, 100)
+	}
+
+	if query.Has("amenities") {
+		for _, code := range strings.Split(query.Get("amenities"), ",") {
+			if !validAmenityCode(code) {
+				return queryParams, &paramError{"amenities", "Amenities must be comma separated amenity codes, like ac,pet_friendly", nil}
+			}
+			queryParams.Amenities = append(queryParams.Amenities, code)
+		}
+	}
+
+	if query.Has("rating_min") {
+		ratingMin, err := strconv.ParseFloat(query.Get("rating_min"), 64)
+		if err != nil || !(ratingMin >= 1 && ratingMin <= 5) {
+			return queryParams, &paramError{"rating_min", "Minimum rating must be a number between 1 and 5", err}
+		}
+		queryParams.RatingMin = ratingMin
+	}
+
+	if query.Has("limit") {
+		limit, err := strconv.Atoi(query.Get("limit"))
+		if err != nil {
+			return queryParams, &paramError{"limit", "Invalid value for limit parameter", err}
+		}
+		queryParams.Limit = limit
+	}
+
+	if query.Has("offset") {
+		offset, err := strconv.Atoi(query.Get("offset"))
+		if err != nil {
+			return queryParams, &paramError{"offset", "Invalid value for offset parameter", err}
+		}
+		queryParams.Offset = offset
+	}
+
+	if query.Has("sort") {
+		sortBy := query.Get("sort")
+		if sortBy == "" {
+			return queryParams, &paramError{"sort", "Empty sort parameter", nil}
+		}
+		if _, exists := apiv1.SortsMap[sortBy]; !exists {
+			return queryParams, &paramError{"sort", "Sort by given column is not allowed", nil}
+		}
+		queryParams.Sort = apiv1.SortsMap[sortBy]
+	}
+
+	code, paramErr := parseCurrency(query)
+	if paramErr != nil {
+		return queryParams, paramErr
+	}
+	queryParams.Currency = code
+	return queryParams, nil
+}
+
+// validAmenityCode reports whether code looks like a code of the amenities catalogue.
+func validAmenityCode(code string) bool {
+	if code == "" {
+		return false
+	}
+	for _, c := range code {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '_' {
+			return false
+		}
+	}
+	return true
+}
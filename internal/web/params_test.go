@@ -0,0 +1,25 @@
+package web
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+)
+
+func TestParseRentalParams_PriceUnitSorts(t *testing.T) {
+	for unit := range apiv1.PriceUnitsMap {
+		t.Run(unit, func(t *testing.T) {
+			params, paramErr := parseRentalParams(url.Values{"price_unit": {unit}, "sort": {unit}})
+			require.Nil(t, paramErr)
+			assert.Equal(t, params.PriceUnit, params.Sort, "The price unit sorts by the charge it filters")
+		})
+	}
+
+	params, paramErr := parseRentalParams(url.Values{"price_unit": {"day"}, "sort": {"price"}})
+	require.Nil(t, paramErr)
+	assert.Equal(t, params.PriceUnit, params.Sort, "price sorts by the daily charge")
+}
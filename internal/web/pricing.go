@@ -0,0 +1,116 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/auth"
+	"github.com/mkermilska/rentals-challenge/pkg/pricing"
+)
+
+func (a *APIServer) getPricing(w http.ResponseWriter, r *http.Request) {
+	rentalID, ok := a.readRentalID(w, r)
+	if !ok {
+		return
+	}
+
+	rules, err := a.pricingSvc.GetPricing(r.Context(), rentalID)
+	if err != nil {
+		errorMsg := "Error getting rental pricing"
+		a.logger.Error(errorMsg, zap.Int("rentalID", rentalID), zap.Error(err))
+		a.writeServiceError(w, r, err, errorMsg)
+		return
+	}
+	a.writeJSON(w, r, http.StatusOK, rules)
+}
+
+func (a *APIServer) putPricing(w http.ResponseWriter, r *http.Request) {
+	identity, _ := auth.FromContext(r.Context())
+	rentalID, ok := a.readRentalID(w, r)
+	if !ok {
+		return
+	}
+	input := apiv1.PricingRules{}
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRentalBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&input); err != nil {
+		errorMsg := "Invalid pricing rules in request body"
+		a.logger.Info(errorMsg, zap.Error(err))
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, "", errorMsg)
+		return
+	}
+
+	rules, err := a.pricingSvc.SetPricing(r.Context(), identity.UserID, rentalID, input)
+	if err != nil {
+		if errors.Is(err, pricing.ErrInvalidRules) {
+			errorMsg := "Invalid pricing rules: " + reason(err, pricing.ErrInvalidRules)
+			a.logger.Info(errorMsg, zap.Int("rentalID", rentalID))
+			a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, "", errorMsg)
+			return
+		}
+		errorMsg := "Error updating rental pricing"
+		a.logger.Error(errorMsg, zap.Int("rentalID", rentalID), zap.Error(err))
+		a.writeServiceError(w, r, err, errorMsg)
+		return
+	}
+	a.writeJSON(w, r, http.StatusOK, rules)
+}
+
+func (a *APIServer) getQuote(w http.ResponseWriter, r *http.Request) {
+	rentalID, ok := a.readRentalID(w, r)
+	if !ok {
+		return
+	}
+	var dates [2]time.Time
+	for i, param := range []string{"from", "to"} {
+		date, err := time.Parse(pricing.DateLayout, r.URL.Query().Get(param))
+		if err != nil {
+			errorMsg := "Dates must be formatted as YYYY-MM-DD"
+			a.logger.Info(errorMsg, zap.String(param, r.URL.Query().Get(param)), zap.Error(err))
+			a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidParameter, param, errorMsg)
+			return
+		}
+		dates[i] = date
+	}
+
+	quote, err := a.pricingSvc.GetQuote(r.Context(), rentalID, dates[0], dates[1])
+	if err != nil {
+		for _, stayErr := range []error{pricing.ErrInvalidStay, pricing.ErrMinNights} {
+			if errors.Is(err, stayErr) {
+				errorMsg := "Stay not available: " + reason(err, stayErr)
+				a.logger.Info(errorMsg, zap.Int("rentalID", rentalID))
+				a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidParameter, "to", errorMsg)
+				return
+			}
+		}
+		errorMsg := "Error quoting rental"
+		a.logger.Error(errorMsg, zap.Int("rentalID", rentalID), zap.Error(err))
+		a.writeServiceError(w, r, err, errorMsg)
+		return
+	}
+	a.writeJSON(w, r, http.StatusOK, quote)
+}
+
+func (a *APIServer) readRentalID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	rentalID, err := strconv.Atoi(chi.URLParam(r, "rentalID"))
+	if err != nil {
+		errorMsg := "Incorrect rental ID, please enter a valid number"
+		a.logger.Info(errorMsg, zap.String("rentalID", chi.URLParam(r, "rentalID")), zap.Error(err))
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidParameter, "rentalID", errorMsg)
+		return 0, false
+	}
+	return rentalID, true
+}
+
+// reason strips the sentinel from the message of an error created as fmt.Errorf("%w: reason", sentinel).
+func reason(err, sentinel error) string {
+	return strings.TrimPrefix(err.Error(), sentinel.Error()+": ")
+}
@@ -0,0 +1,144 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/auth"
+)
+
+func TestAPIServer_Pricing(t *testing.T) {
+	tests := map[string]struct {
+		method          string
+		target          string
+		body            string
+		apiKey          string
+		expectedStatus  int
+		expectedParam   string
+		expectedProblem string
+	}{
+		"Get pricing": {
+			method:         http.MethodGet,
+			target:         "/v1/rentals/1/pricing",
+			expectedStatus: http.StatusOK,
+		},
+		"Get pricing of missing rental": {
+			method:         http.MethodGet,
+			target:         "/v1/rentals/30/pricing",
+			expectedStatus: http.StatusNotFound,
+		},
+		"Set pricing": {
+			method:         http.MethodPut,
+			target:         "/v1/rentals/1/pricing",
+			body:           `{"seasons": [{"name": "Summer", "start": "2024-07-01", "end": "2024-08-31", "price_per_day": 15000}], "weekly_discount_percent": 10}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusOK,
+		},
+		"Set pricing without authentication": {
+			method:         http.MethodPut,
+			target:         "/v1/rentals/1/pricing",
+			body:           `{"weekly_discount_percent": 10}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"Set pricing of another user": {
+			method:         http.MethodPut,
+			target:         "/v1/rentals/1/pricing",
+			body:           `{"weekly_discount_percent": 10}`,
+			apiKey:         "key-2",
+			expectedStatus: http.StatusForbidden,
+		},
+		"Set overlapping seasons": {
+			method: http.MethodPut,
+			target: "/v1/rentals/1/pricing",
+			body: `{"seasons": [{"name": "Summer", "start": "2024-07-01", "end": "2024-08-31", "price_per_day": 15000},
+				{"name": "Fall", "start": "2024-08-15", "end": "2024-09-30", "price_per_day": 12000}]}`,
+			apiKey:          "key-1",
+			expectedStatus:  http.StatusBadRequest,
+			expectedProblem: `Invalid pricing rules: seasons "Summer" and "Fall" overlap`,
+		},
+		"Set invalid season date": {
+			method:         http.MethodPut,
+			target:         "/v1/rentals/1/pricing",
+			body:           `{"seasons": [{"name": "Summer", "start": "July", "end": "2024-08-31", "price_per_day": 15000}]}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusBadRequest,
+			expectedParam:  "seasons.0.start",
+		},
+		"Quote stay": {
+			method:         http.MethodGet,
+			target:         "/v1/rentals/1/quote?from=2024-06-03&to=2024-06-06",
+			expectedStatus: http.StatusOK,
+		},
+		"Quote stay below minimum nights": {
+			method:          http.MethodGet,
+			target:          "/v1/rentals/1/quote?from=2024-06-03&to=2024-06-04",
+			expectedStatus:  http.StatusBadRequest,
+			expectedParam:   "to",
+			expectedProblem: "Stay not available: the stay has 1 nights, at least 2 are required",
+		},
+		"Quote without dates": {
+			method:         http.MethodGet,
+			target:         "/v1/rentals/1/quote?from=2024-06-03",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"Quote missing rental": {
+			method:         http.MethodGet,
+			target:         "/v1/rentals/30/quote?from=2024-06-03&to=2024-06-06",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	fake := newFakeServices()
+	server := New(Options{
+		Authenticator: auth.NewAPIKeyAuthenticator(map[string]int{"key-1": 1, "key-2": 2}),
+		Pricing:       fake,
+	}, fake, zap.NewNop())
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.target, bytes.NewBufferString(test.body))
+			req.Header.Set("Content-Type", "application/json")
+			if test.apiKey != "" {
+				req.Header.Set("X-API-Key", test.apiKey)
+			}
+			w := httptest.NewRecorder()
+			server.Handler().ServeHTTP(w, req)
+
+			require.Equal(t, test.expectedStatus, w.Code, w.Body.String())
+			if test.expectedParam != "" || test.expectedProblem != "" {
+				var problem apiv1.Problem
+				require.Nil(t, json.Unmarshal(w.Body.Bytes(), &problem), "Error decoding problem")
+				assert.Equal(t, test.expectedParam, problem.Param)
+				if test.expectedProblem != "" {
+					assert.Equal(t, test.expectedProblem, problem.Detail)
+				}
+			}
+		})
+	}
+}
+
+func TestAPIServer_GetQuote(t *testing.T) {
+	fake := newFakeServices()
+	server := New(Options{Pricing: fake}, fake, zap.NewNop())
+	req := httptest.NewRequest(http.MethodGet, "/v1/rentals/1/quote?from=2024-06-03&to=2024-06-06", nil)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var quote apiv1.Quote
+	require.Nil(t, json.Unmarshal(w.Body.Bytes(), &quote), "Error decoding quote")
+	assert.Equal(t, apiv1.Quote{
+		RentalID: 1, From: "2024-06-03", To: "2024-06-06", Nights: 3,
+		LineItems: []apiv1.QuoteLineItem{
+			{Kind: apiv1.QuoteItemNights, Description: "Nights", Quantity: 3, UnitPrice: 10000, Amount: 30000},
+		},
+		Total: 30000,
+	}, quote)
+}
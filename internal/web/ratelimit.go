@@ -0,0 +1,134 @@
+package web
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/auth"
+	"github.com/mkermilska/rentals-challenge/pkg/ratelimit"
+)
+
+// Route names used to override the default rate limit per route.
+const (
+	RouteRentalsGet      = "rentals.get"
+	RouteRentalsList     = "rentals.list"
+	RouteRentalsNear     = "rentals.near"
+	RouteRentalsWrite    = "rentals.write"
+	RouteRentalsBatchGet = "rentals.batchGet"
+	RouteRentalsStream   = "rentals.stream"
+	RouteRentalsQuote    = "rentals.quote"
+	RouteExchangeRates   = "exchangeRates"
+	RouteAmenities       = "amenities"
+	RouteSavedSearches   = "searches"
+	RouteGraphQL         = "graphql"
+	RouteMedia           = "media"
+)
+
+// Routes lists every route name accepted in Options.RouteRateLimits.
+var Routes = []string{
+	RouteRentalsGet, RouteRentalsList, RouteRentalsNear, RouteRentalsWrite, RouteRentalsBatchGet, RouteRentalsStream,
+	RouteRentalsQuote, RouteExchangeRates, RouteAmenities, RouteSavedSearches, RouteGraphQL, RouteMedia,
+}
+
+// newLimiters builds a limiter for every route with an override and a shared default one.
+func newLimiters(opts Options) (*ratelimit.Limiter, map[string]*ratelimit.Limiter) {
+	var defaultLimiter *ratelimit.Limiter
+	if opts.RateLimit.Burst > 0 {
+		defaultLimiter = ratelimit.New(opts.RateLimit)
+	}
+	routeLimiters := make(map[string]*ratelimit.Limiter, len(opts.RouteRateLimits))
+	for route, limit := range opts.RouteRateLimits {
+		routeLimiters[route] = ratelimit.New(limit)
+	}
+	return defaultLimiter, routeLimiters
+}
+
+// rateLimit throttles requests to the named route per authenticated user, or per client IP for anonymous callers.
+// Listing with the near parameter counts as the separate rentals.near route.
+func (a *APIServer) rateLimit(route string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name := route
+			if name == RouteRentalsList && r.URL.Query().Has("near") {
+				name = RouteRentalsNear
+			}
+			limiter, ok := a.routeLimiters[name]
+			if !ok {
+				limiter = a.defaultLimiter
+			}
+			if limiter == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			result := limiter.Allow(a.clientKey(r))
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.Reset.Seconds()))))
+			if !result.Allowed {
+				errorMsg := "Too many requests, please retry later"
+				a.logger.Info(errorMsg, zap.String("route", name), zap.String("remoteAddr", r.RemoteAddr))
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+				a.writeProblem(w, r, http.StatusTooManyRequests, apiv1.ErrCodeRateLimited, "", errorMsg)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientKey keys the buckets on the identity set by authenticate. Credentials that were not
+// authenticated are ignored, so sending made up keys does not give new buckets.
+func (a *APIServer) clientKey(r *http.Request) string {
+	if identity, ok := auth.FromContext(r.Context()); ok {
+		return "user:" + strconv.Itoa(identity.UserID)
+	}
+	return "ip:" + a.clientIP(r)
+}
+
+// clientIP returns the address of the connection, or the one a trusted proxy received the
+// request from. Each proxy appends the address it received the request from to X-Forwarded-For,
+// so the rightmost address that is not a trusted proxy is the client, the ones before it may be
+// made up by the client.
+func (a *APIServer) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !a.trustedProxy(host) {
+		return host
+	}
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(forwarded[i])
+		if addr == "" {
+			continue
+		}
+		if !a.trustedProxy(addr) {
+			return addr
+		}
+		host = addr
+	}
+	return host
+}
+
+func (a *APIServer) trustedProxy(host string) bool {
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range a.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/mkermilska/rentals-challenge/pkg/auth"
+	"github.com/mkermilska/rentals-challenge/pkg/ratelimit"
+)
+
+func TestAPIServer_RateLimit(t *testing.T) {
+	tests := map[string]struct {
+		authenticator  auth.Authenticator
+		apiKeys        []string
+		expectedStatus []int
+	}{
+		"Anonymous requests share the IP bucket": {
+			apiKeys:        []string{"", "", ""},
+			expectedStatus: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		"Rotating keys without authenticator share the IP bucket": {
+			apiKeys:        []string{"fake-1", "fake-2", "fake-3"},
+			expectedStatus: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		"Rotating keys not checked by a JWT authenticator share the IP bucket": {
+			authenticator:  auth.NewJWTAuthenticator(&auth.KeySet{}, auth.JWTOptions{}),
+			apiKeys:        []string{"fake-1", "fake-2", "fake-3"},
+			expectedStatus: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		"Authenticated users have their own bucket": {
+			authenticator:  auth.NewAPIKeyAuthenticator(map[string]int{"key-1": 1, "key-2": 2}),
+			apiKeys:        []string{"key-1", "key-1", "key-2", "key-1", ""},
+			expectedStatus: []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusTooManyRequests, http.StatusOK},
+		},
+		"Keys of the same user share the bucket": {
+			authenticator:  auth.NewAPIKeyAuthenticator(map[string]int{"key-1": 1, "key-1b": 1}),
+			apiKeys:        []string{"key-1", "key-1b", "key-1"},
+			expectedStatus: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := New(Options{
+				Authenticator: test.authenticator,
+				RateLimit:     ratelimit.Limit{Rate: 0.001, Burst: 2},
+			}, newFakeServices(), zap.NewNop())
+			for i, apiKey := range test.apiKeys {
+				req := httptest.NewRequest(http.MethodGet, "/v1/rentals/1", nil)
+				if apiKey != "" {
+					req.Header.Set(auth.APIKeyHeader, apiKey)
+				}
+				w := httptest.NewRecorder()
+				server.Handler().ServeHTTP(w, req)
+				assert.Equal(t, test.expectedStatus[i], w.Code, fmt.Sprintf("Request %d: %s", i+1, w.Body.String()))
+			}
+		})
+	}
+}
+
+func TestAPIServer_ClientIP(t *testing.T) {
+	tests := map[string]struct {
+		remoteAddr     string
+		forwardedFor   []string
+		expectedClient string
+	}{
+		"Connection without a proxy": {
+			remoteAddr: "203.0.113.7:4000", expectedClient: "203.0.113.7",
+		},
+		"Forwarded header from an untrusted peer is ignored": {
+			remoteAddr: "203.0.113.7:4000", forwardedFor: []string{"198.51.100.1"}, expectedClient: "203.0.113.7",
+		},
+		"Client behind a trusted proxy": {
+			remoteAddr: "10.0.0.2:4000", forwardedFor: []string{"198.51.100.1"}, expectedClient: "198.51.100.1",
+		},
+		"Addresses made up by the client are skipped": {
+			remoteAddr: "10.0.0.2:4000", forwardedFor: []string{"192.0.2.9, 198.51.100.1"}, expectedClient: "198.51.100.1",
+		},
+		"Chained trusted proxies over several headers": {
+			remoteAddr: "10.0.0.2:4000", forwardedFor: []string{"192.0.2.9, 198.51.100.1", "10.0.0.3"}, expectedClient: "198.51.100.1",
+		},
+		"Trusted proxy without a forwarded header": {
+			remoteAddr: "10.0.0.2:4000", expectedClient: "10.0.0.2",
+		},
+		"IPv4 mapped address of a trusted proxy": {
+			remoteAddr: "[::ffff:10.0.0.2]:4000", forwardedFor: []string{"198.51.100.1"}, expectedClient: "198.51.100.1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := New(Options{TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}}, newFakeServices(), zap.NewNop())
+			req := httptest.NewRequest(http.MethodGet, "/v1/rentals/1", nil)
+			req.RemoteAddr = test.remoteAddr
+			for _, forwardedFor := range test.forwardedFor {
+				req.Header.Add("X-Forwarded-For", forwardedFor)
+			}
+			assert.Equal(t, test.expectedClient, server.clientIP(req))
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+)
+
+// defaultBatchGetMaxIDs applies when Options.BatchGetMaxIDs is not set.
+const defaultBatchGetMaxIDs = 100
+
+// batchGetRentals returns the rentals with the requested ids in request order, with a not_found
+// entry for every id without a rental.
+func (a *APIServer) batchGetRentals(w http.ResponseWriter, r *http.Request) {
+	req := apiv1.BatchGetRequest{}
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRentalBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		errorMsg := "Invalid ids in request body"
+		a.logger.Info(errorMsg, zap.Error(err))
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, "", errorMsg)
+		return
+	}
+	if len(req.IDs) == 0 {
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, "ids", "Empty ids list")
+		return
+	}
+	if len(req.IDs) > a.batchGetMaxIDs {
+		errorMsg := fmt.Sprintf("At most %d ids can be requested at once", a.batchGetMaxIDs)
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, "ids", errorMsg)
+		return
+	}
+
+	uniqueIDs := make([]int, 0, len(req.IDs))
+	seen := make(map[int]bool, len(req.IDs))
+	for _, ID := range req.IDs {
+		if !seen[ID] {
+			seen[ID] = true
+			uniqueIDs = append(uniqueIDs, ID)
+		}
+	}
+	rentals, err := a.rentalSvc.GetRentals(r.Context(), database.RentalParams{
+		IDs:         uniqueIDs,
+		IncludeUser: true,
+	})
+	if err != nil {
+		errorMsg := "Error getting rentals"
+		a.logger.Error(errorMsg, zap.Error(err))
+		a.writeServiceError(w, r, err, errorMsg)
+		return
+	}
+
+	byID := make(map[int]apiv1.Rental, len(rentals))
+	for _, rental := range rentals {
+		byID[rental.ID] = rental
+	}
+	resp := apiv1.BatchGetResponse{Results: make([]apiv1.BatchGetResult, len(req.IDs))}
+	for i, ID := range req.IDs {
+		resp.Results[i].ID = ID
+		if rental, ok := byID[ID]; ok {
+			resp.Results[i].Rental = &rental
+		} else {
+			resp.Results[i].Error = apiv1.ErrCodeNotFound
+		}
+	}
+
+	out, err := json.Marshal(resp)
+	if err != nil {
+		errorMsg := "Error parsing rentals"
+		a.logger.Error(errorMsg, zap.Error(err))
+		a.writeProblem(w, r, http.StatusInternalServerError, apiv1.ErrCodeInternal, "", errorMsg)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(out)
+	if err != nil {
+		a.logger.Error("Error writing API response", zap.Error(err))
+	}
+}
@@ -0,0 +1,84 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+)
+
+func TestAPIServer_BatchGetRentals(t *testing.T) {
+	tests := map[string]struct {
+		body            string
+		expectedStatus  int
+		expectedIDs     []int
+		expectedErrors  []string
+		expectedProblem string
+	}{
+		"Results in request order": {
+			body:           `{"ids": [2, 1]}`,
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int{2, 1},
+			expectedErrors: []string{"", ""},
+		},
+		"Missing rentals": {
+			body:           `{"ids": [3000, 1, 4000]}`,
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int{3000, 1, 4000},
+			expectedErrors: []string{apiv1.ErrCodeNotFound, "", apiv1.ErrCodeNotFound},
+		},
+		"Repeated ids": {
+			body:           `{"ids": [1, 1]}`,
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int{1, 1},
+			expectedErrors: []string{"", ""},
+		},
+		"Too many ids": {
+			body:            `{"ids": [1, 2, 3, 4]}`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedProblem: "At most 3 ids can be requested at once",
+		},
+		"Invalid ids": {
+			body:            `{"ids": ["1"]}`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedProblem: "Invalid request body: value must be an integer",
+		},
+	}
+
+	server := New(Options{BatchGetMaxIDs: 3}, newFakeServices(), zap.NewNop())
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/rentals:batchGet", bytes.NewBufferString(test.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			server.Handler().ServeHTTP(w, req)
+			require.Equal(t, test.expectedStatus, w.Code, w.Body.String())
+
+			if test.expectedProblem != "" {
+				var problem apiv1.Problem
+				require.Nil(t, json.Unmarshal(w.Body.Bytes(), &problem), "Error decoding problem")
+				assert.Equal(t, test.expectedProblem, problem.Detail)
+				return
+			}
+			var resp apiv1.BatchGetResponse
+			require.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp), "Error decoding response")
+			IDs, errors := []int{}, []string{}
+			for _, result := range resp.Results {
+				IDs = append(IDs, result.ID)
+				errors = append(errors, result.Error)
+				if result.Error == "" {
+					assert.Equal(t, result.ID, result.Rental.ID)
+				}
+			}
+			assert.Equal(t, test.expectedIDs, IDs)
+			assert.Equal(t, test.expectedErrors, errors)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/auth"
+	"github.com/mkermilska/rentals-challenge/pkg/currency"
+	"github.com/mkermilska/rentals-challenge/pkg/service"
+)
+
+const maxRentalBodyBytes = 1 << 20
+
+func (a *APIServer) createRental(w http.ResponseWriter, r *http.Request) {
+	identity, _ := auth.FromContext(r.Context())
+	input, ok := a.readRentalInput(w, r)
+	if !ok {
+		return
+	}
+
+	rental, err := a.rentalSvc.CreateRental(r.Context(), identity.UserID, *input)
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownAmenity) {
+			errorMsg := "Unknown amenity: " + reason(err, service.ErrUnknownAmenity)
+			a.logger.Info(errorMsg)
+			a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, "amenities", errorMsg)
+			return
+		}
+		errorMsg := "Error creating rental"
+		a.logger.Error(errorMsg, zap.Error(err))
+		a.writeServiceError(w, r, err, errorMsg)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/v1/rentals/%d", rental.ID))
+	a.writeRental(w, r, http.StatusCreated, rental)
+}
+
+func (a *APIServer) updateRental(w http.ResponseWriter, r *http.Request) {
+	identity, _ := auth.FromContext(r.Context())
+	rentalID, err := strconv.Atoi(chi.URLParam(r, "rentalID"))
+	if err != nil {
+		errorMsg := "Incorrect rental ID, please enter a valid number"
+		a.logger.Error(errorMsg, zap.String("rentalID", chi.URLParam(r, "rentalID")), zap.Error(err))
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidParameter, "rentalID", errorMsg)
+		return
+	}
+	input, ok := a.readRentalInput(w, r)
+	if !ok {
+		return
+	}
+
+	rental, err := a.rentalSvc.UpdateRental(r.Context(), identity.UserID, rentalID, *input)
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownAmenity) {
+			errorMsg := "Unknown amenity: " + reason(err, service.ErrUnknownAmenity)
+			a.logger.Info(errorMsg)
+			a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, "amenities", errorMsg)
+			return
+		}
+		errorMsg := "Error updating rental"
+		a.logger.Error(errorMsg, zap.Int("rentalID", rentalID), zap.Error(err))
+		a.writeServiceError(w, r, err, errorMsg)
+		return
+	}
+
+	a.writeRental(w, r, http.StatusOK, rental)
+}
+
+func (a *APIServer) deleteRental(w http.ResponseWriter, r *http.Request) {
+	identity, _ := auth.FromContext(r.Context())
+	rentalID, err := strconv.Atoi(chi.URLParam(r, "rentalID"))
+	if err != nil {
+		errorMsg := "Incorrect rental ID, please enter a valid number"
+		a.logger.Error(errorMsg, zap.String("rentalID", chi.URLParam(r, "rentalID")), zap.Error(err))
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidParameter, "rentalID", errorMsg)
+		return
+	}
+
+	err = a.rentalSvc.DeleteRental(r.Context(), identity.UserID, rentalID)
+	if err != nil {
+		errorMsg := "Error deleting rental"
+		a.logger.Error(errorMsg, zap.Int("rentalID", rentalID), zap.Error(err))
+		a.writeServiceError(w, r, err, errorMsg)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *APIServer) readRentalInput(w http.ResponseWriter, r *http.Request) (*apiv1.RentalInput, bool) {
+	input := apiv1.RentalInput{}
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRentalBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&input); err != nil {
+		errorMsg := "Invalid rental in request body"
+		a.logger.Info(errorMsg, zap.Error(err))
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, "", errorMsg)
+		return nil, false
+	}
+
+	if param, errorMsg := validateRentalInput(input); errorMsg != "" {
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, param, errorMsg)
+		return nil, false
+	}
+	return &input, true
+}
+
+func validateRentalInput(input apiv1.RentalInput) (param, errorMsg string) {
+	switch {
+	case input.Name == "":
+		return "name", "Rental name is required"
+	case input.Type == "":
+		return "type", "Rental type is required"
+	case input.Sleeps < 0:
+		return "sleeps", "Sleeps can not be negative"
+	case input.Price.Day < 0:
+		return "price.day", "Price per day can not be negative"
+	case isNegative(input.Price.Week):
+		return "price.week", "Price per week can not be negative"
+	case isNegative(input.Price.Month):
+		return "price.month", "Price per month can not be negative"
+	case isNegative(input.Price.SecurityDeposit):
+		return "price.security_deposit", "Security deposit can not be negative"
+	case isNegative(input.Price.CleaningFee):
+		return "price.cleaning_fee", "Cleaning fee can not be negative"
+	case isNegative(input.Price.PerMile):
+		return "price.per_mile", "Price per mile can not be negative"
+	case input.Price.Currency != "" && !currency.ValidCode(input.Price.Currency):
+		return "price.currency", "Currency must be a three letter ISO 4217 code"
+	case input.Location.Lat < -90 || input.Location.Lat > 90:
+		return "location.lat", "Latitude must be between -90 and 90"
+	case input.Location.Lng < -180 || input.Location.Lng > 180:
+		return "location.lng", "Longitude must be between -180 and 180"
+	}
+	for _, code := range input.Amenities {
+		if !validAmenityCode(code) {
+			return "amenities", "Amenities must be amenity codes, like pet_friendly"
+		}
+	}
+	return "", ""
+}
+
+func isNegative(amount *int) bool {
+	return amount != nil && *amount < 0
+}
+
+func (a *APIServer) writeRental(w http.ResponseWriter, r *http.Request, status int, rental *apiv1.Rental) {
+	out, err := json.Marshal(rental)
+	if err != nil {
+		errorMsg := "Error parsing rental"
+		a.logger.Error(errorMsg, zap.Any("rental", rental), zap.Error(err))
+		a.writeProblem(w, r, http.StatusInternalServerError, apiv1.ErrCodeInternal, "", errorMsg)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err = w.Write(out)
+	if err != nil {
+		a.logger.Error("Error writing API response", zap.Error(err))
+	}
+}
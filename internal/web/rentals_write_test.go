@@ -0,0 +1,132 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/auth"
+)
+
+func TestAPIServer_RentalWriteOwnership(t *testing.T) {
+	body := `{"name": "Camper", "type": "camper-van", "price": {"day": 9900}}`
+	tests := map[string]struct {
+		method           string
+		target           string
+		apiKey           string
+		expectedStatus   int
+		expectedCallerID int
+	}{
+		"Create without credentials": {
+			method:         http.MethodPost,
+			target:         "/v1/rentals",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"Create with invalid credentials": {
+			method:         http.MethodPost,
+			target:         "/v1/rentals",
+			apiKey:         "unknown",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"Create as the caller": {
+			method:           http.MethodPost,
+			target:           "/v1/rentals",
+			apiKey:           "key-2",
+			expectedStatus:   http.StatusCreated,
+			expectedCallerID: 2,
+		},
+		"Update without credentials": {
+			method:         http.MethodPut,
+			target:         "/v1/rentals/1",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"Update rental of another user": {
+			method:           http.MethodPut,
+			target:           "/v1/rentals/1",
+			apiKey:           "key-2",
+			expectedStatus:   http.StatusForbidden,
+			expectedCallerID: 2,
+		},
+		"Update own rental": {
+			method:           http.MethodPut,
+			target:           "/v1/rentals/1",
+			apiKey:           "key-1",
+			expectedStatus:   http.StatusOK,
+			expectedCallerID: 1,
+		},
+		"Delete without credentials": {
+			method:         http.MethodDelete,
+			target:         "/v1/rentals/2",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"Delete rental of another user": {
+			method:           http.MethodDelete,
+			target:           "/v1/rentals/2",
+			apiKey:           "key-1",
+			expectedStatus:   http.StatusForbidden,
+			expectedCallerID: 1,
+		},
+		"Delete own rental": {
+			method:           http.MethodDelete,
+			target:           "/v1/rentals/2",
+			apiKey:           "key-2",
+			expectedStatus:   http.StatusNoContent,
+			expectedCallerID: 2,
+		},
+		"Delete missing rental": {
+			method:           http.MethodDelete,
+			target:           "/v1/rentals/30",
+			apiKey:           "key-2",
+			expectedStatus:   http.StatusNotFound,
+			expectedCallerID: 2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fake := newFakeServices()
+			server := New(Options{
+				Authenticator: auth.NewAPIKeyAuthenticator(map[string]int{"key-1": 1, "key-2": 2}),
+			}, fake, zap.NewNop())
+			var reqBody *bytes.Buffer
+			if test.method == http.MethodDelete {
+				reqBody = &bytes.Buffer{}
+			} else {
+				reqBody = bytes.NewBufferString(body)
+			}
+			req := httptest.NewRequest(test.method, test.target, reqBody)
+			if reqBody.Len() > 0 {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if test.apiKey != "" {
+				req.Header.Set(auth.APIKeyHeader, test.apiKey)
+			}
+			w := httptest.NewRecorder()
+			server.Handler().ServeHTTP(w, req)
+
+			require.Equal(t, test.expectedStatus, w.Code, w.Body.String())
+			assert.Equal(t, test.expectedCallerID, fake.callerID, "The service gets the authenticated caller")
+			switch test.expectedStatus {
+			case http.StatusUnauthorized:
+				assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+				assert.Equal(t, apiv1.ProblemContentType, w.Header().Get("Content-Type"))
+			case http.StatusForbidden:
+				var problem apiv1.Problem
+				require.Nil(t, json.Unmarshal(w.Body.Bytes(), &problem), "Error decoding problem")
+				assert.Equal(t, apiv1.ErrCodeForbidden, problem.Code)
+			case http.StatusCreated:
+				var rental apiv1.Rental
+				require.Nil(t, json.Unmarshal(w.Body.Bytes(), &rental), "Error decoding rental")
+				require.NotNil(t, rental.User)
+				assert.Equal(t, 2, rental.User.ID, "The caller owns the new rental")
+			}
+		})
+	}
+}
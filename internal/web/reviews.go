@@ -0,0 +1,125 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/auth"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+	"github.com/mkermilska/rentals-challenge/pkg/service"
+)
+
+// Page size of the reviews of a rental.
+const (
+	defaultReviewsLimit = 20
+	maxReviewsLimit     = 100
+)
+
+const maxReviewTextLength = 2000
+
+func (a *APIServer) getReviews(w http.ResponseWriter, r *http.Request) {
+	rentalID, ok := a.readRentalID(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	limit := defaultReviewsLimit
+	if query.Has("limit") {
+		var err error
+		limit, err = strconv.Atoi(query.Get("limit"))
+		if err != nil || limit < 1 || limit > maxReviewsLimit {
+			errorMsg := fmt.Sprintf("Limit must be a number between 1 and %d", maxReviewsLimit)
+			a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidParameter, "limit", errorMsg)
+			return
+		}
+	}
+	offset := 0
+	if query.Has("offset") {
+		var err error
+		offset, err = strconv.Atoi(query.Get("offset"))
+		if err != nil || offset < 0 {
+			errorMsg := "Offset must be a number not below 0"
+			a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidParameter, "offset", errorMsg)
+			return
+		}
+	}
+
+	reviews, err := a.reviewSvc.GetReviews(r.Context(), rentalID, limit, offset)
+	if err != nil {
+		errorMsg := "Error getting reviews"
+		a.logger.Error(errorMsg, zap.Int("rentalID", rentalID), zap.Error(err))
+		a.writeServiceError(w, r, err, errorMsg)
+		return
+	}
+	a.writeJSON(w, r, http.StatusOK, reviews)
+}
+
+func (a *APIServer) createReview(w http.ResponseWriter, r *http.Request) {
+	identity, _ := auth.FromContext(r.Context())
+	rentalID, ok := a.readRentalID(w, r)
+	if !ok {
+		return
+	}
+	input := apiv1.ReviewInput{}
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRentalBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&input); err != nil {
+		errorMsg := "Invalid review in request body"
+		a.logger.Info(errorMsg, zap.Error(err))
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, "", errorMsg)
+		return
+	}
+	input.Text = strings.TrimSpace(input.Text)
+	if param, errorMsg := validateReviewInput(input); errorMsg != "" {
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, param, errorMsg)
+		return
+	}
+
+	review, err := a.reviewSvc.CreateReview(r.Context(), identity.UserID, rentalID, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidBooking):
+			errorMsg := "Invalid booking: " + reason(err, service.ErrInvalidBooking)
+			a.logger.Info(errorMsg, zap.Int("rentalID", rentalID))
+			a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, "booking_id", errorMsg)
+			return
+		case errors.Is(err, service.ErrBookingNotCompleted):
+			errorMsg := "Booking is not completed: " + reason(err, service.ErrBookingNotCompleted)
+			a.logger.Info(errorMsg, zap.Int("rentalID", rentalID))
+			a.writeProblem(w, r, http.StatusConflict, apiv1.ErrCodeConflict, "booking_id", errorMsg)
+			return
+		case errors.Is(err, database.ErrConflict):
+			errorMsg := "Booking was already reviewed"
+			a.logger.Info(errorMsg, zap.Int("rentalID", rentalID), zap.Int("bookingID", input.BookingID))
+			a.writeProblem(w, r, http.StatusConflict, apiv1.ErrCodeConflict, "booking_id", errorMsg)
+			return
+		}
+		errorMsg := "Error creating review"
+		a.logger.Error(errorMsg, zap.Int("rentalID", rentalID), zap.Error(err))
+		a.writeServiceError(w, r, err, errorMsg)
+		return
+	}
+	a.writeJSON(w, r, http.StatusCreated, review)
+}
+
+func validateReviewInput(input apiv1.ReviewInput) (param, errorMsg string) {
+	switch {
+	case input.BookingID <= 0:
+		return "booking_id", "Booking ID must be positive"
+	case input.Rating < 1 || input.Rating > 5:
+		return "rating", "Rating must be a number of stars between 1 and 5"
+	case input.Text == "":
+		return "text", "Text must not be empty"
+	case utf8.RuneCountInString(input.Text) > maxReviewTextLength:
+		return "text", fmt.Sprintf("Text must not be longer than %d characters", maxReviewTextLength)
+	}
+	return "", ""
+}
@@ -0,0 +1,165 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/auth"
+)
+
+func TestAPIServer_Reviews(t *testing.T) {
+	tests := map[string]struct {
+		method          string
+		target          string
+		body            string
+		apiKey          string
+		expectedStatus  int
+		expectedParam   string
+		expectedReviews int
+	}{
+		"List reviews": {
+			method:          http.MethodGet,
+			target:          "/v1/rentals/1/reviews",
+			expectedStatus:  http.StatusOK,
+			expectedReviews: 2,
+		},
+		"List a page of reviews": {
+			method:          http.MethodGet,
+			target:          "/v1/rentals/1/reviews?limit=1&offset=1",
+			expectedStatus:  http.StatusOK,
+			expectedReviews: 1,
+		},
+		"List reviews with invalid limit": {
+			method:         http.MethodGet,
+			target:         "/v1/rentals/1/reviews?limit=500",
+			expectedStatus: http.StatusBadRequest,
+			expectedParam:  "limit",
+		},
+		"List reviews of missing rental": {
+			method:         http.MethodGet,
+			target:         "/v1/rentals/30/reviews",
+			expectedStatus: http.StatusNotFound,
+		},
+		"Create review": {
+			method:         http.MethodPost,
+			target:         "/v1/rentals/1/reviews",
+			body:           `{"booking_id": 1, "rating": 5, "text": "  Spotless van  "}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusCreated,
+		},
+		"Create review anonymously": {
+			method:         http.MethodPost,
+			target:         "/v1/rentals/1/reviews",
+			body:           `{"booking_id": 1, "rating": 5, "text": "Spotless van"}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"Create review with too many stars": {
+			method:         http.MethodPost,
+			target:         "/v1/rentals/1/reviews",
+			body:           `{"booking_id": 1, "rating": 6, "text": "Spotless van"}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusBadRequest,
+			expectedParam:  "rating",
+		},
+		"Create review without text": {
+			method:         http.MethodPost,
+			target:         "/v1/rentals/1/reviews",
+			body:           `{"booking_id": 1, "rating": 5, "text": "   "}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusBadRequest,
+			expectedParam:  "text",
+		},
+		"Create review with too long text": {
+			method:         http.MethodPost,
+			target:         "/v1/rentals/1/reviews",
+			body:           `{"booking_id": 1, "rating": 5, "text": "` + strings.Repeat("ü", 2001) + `"}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusBadRequest,
+			expectedParam:  "text",
+		},
+		"Create review of booking of another rental": {
+			method:         http.MethodPost,
+			target:         "/v1/rentals/1/reviews",
+			body:           `{"booking_id": 30, "rating": 5, "text": "Spotless van"}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusBadRequest,
+			expectedParam:  "booking_id",
+		},
+		"Create review of booking of another user": {
+			method:         http.MethodPost,
+			target:         "/v1/rentals/1/reviews",
+			body:           `{"booking_id": 2, "rating": 5, "text": "Spotless van"}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusForbidden,
+		},
+		"Create review of booking not completed": {
+			method:         http.MethodPost,
+			target:         "/v1/rentals/1/reviews",
+			body:           `{"booking_id": 3, "rating": 5, "text": "Spotless van"}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusConflict,
+			expectedParam:  "booking_id",
+		},
+		"Create second review of booking": {
+			method:         http.MethodPost,
+			target:         "/v1/rentals/1/reviews",
+			body:           `{"booking_id": 4, "rating": 5, "text": "Spotless van"}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusConflict,
+			expectedParam:  "booking_id",
+		},
+		"Create review of missing rental": {
+			method:         http.MethodPost,
+			target:         "/v1/rentals/30/reviews",
+			body:           `{"booking_id": 1, "rating": 5, "text": "Spotless van"}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	fake := newFakeServices()
+	server := New(Options{
+		Authenticator: auth.NewAPIKeyAuthenticator(map[string]int{"key-1": 1}),
+		Reviews:       fake,
+	}, fake, zap.NewNop())
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.target, bytes.NewBufferString(test.body))
+			if test.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if test.apiKey != "" {
+				req.Header.Set(auth.APIKeyHeader, test.apiKey)
+			}
+			w := httptest.NewRecorder()
+			server.Handler().ServeHTTP(w, req)
+
+			require.Equal(t, test.expectedStatus, w.Code, w.Body.String())
+			if test.expectedParam != "" {
+				var problem apiv1.Problem
+				require.Nil(t, json.Unmarshal(w.Body.Bytes(), &problem), "Error decoding problem")
+				assert.Equal(t, test.expectedParam, problem.Param)
+			}
+			switch test.expectedStatus {
+			case http.StatusOK:
+				var reviews []apiv1.Review
+				require.Nil(t, json.Unmarshal(w.Body.Bytes(), &reviews), "Error decoding reviews")
+				assert.Len(t, reviews, test.expectedReviews)
+			case http.StatusCreated:
+				var review apiv1.Review
+				require.Nil(t, json.Unmarshal(w.Body.Bytes(), &review), "Error decoding review")
+				assert.Equal(t, "Spotless van", review.Text, "The text is trimmed")
+				assert.Equal(t, 1, review.UserID)
+			}
+		})
+	}
+}
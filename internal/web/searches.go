@@ -0,0 +1,184 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/auth"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+	"github.com/mkermilska/rentals-challenge/pkg/webhook"
+)
+
+// Page size of the webhook delivery log.
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+)
+
+func (a *APIServer) createSavedSearch(w http.ResponseWriter, r *http.Request) {
+	identity, _ := auth.FromContext(r.Context())
+	input := apiv1.SavedSearchInput{}
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRentalBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&input); err != nil {
+		errorMsg := "Invalid saved search in request body"
+		a.logger.Info(errorMsg, zap.Error(err))
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, "", errorMsg)
+		return
+	}
+
+	if err := webhook.CheckCallbackURL(input.CallbackURL); err != nil {
+		errorMsg := "Callback URL must be an absolute http or https URL"
+		if errors.Is(err, webhook.ErrPrivateTarget) {
+			errorMsg = "Callback URL must point to a public address"
+		}
+		a.logger.Info(errorMsg, zap.String("callbackURL", input.CallbackURL), zap.Error(err))
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, "callback_url", errorMsg)
+		return
+	}
+	query, err := url.ParseQuery(input.Query)
+	if err != nil {
+		errorMsg := "Invalid query string in query"
+		a.logger.Info(errorMsg, zap.Error(err))
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, "query", errorMsg)
+		return
+	}
+	params, paramErr := parseRentalParams(query)
+	if paramErr != nil {
+		a.logger.Info(paramErr.msg, zap.Error(paramErr.err))
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, "query", paramErr.msg)
+		return
+	}
+	if _, ok := a.readRates(w, r, params.Currency); !ok {
+		return
+	}
+	// the webhooks carry the owners of the added rentals
+	params.IncludeUser = true
+
+	search, err := a.searchSvc.CreateSavedSearch(r.Context(), identity.UserID, input, params)
+	if err != nil {
+		errorMsg := "Error creating saved search"
+		a.logger.Error(errorMsg, zap.Error(err))
+		a.writeServiceError(w, r, err, errorMsg)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/v1/saved-searches/%d", search.ID))
+	a.writeJSON(w, r, http.StatusCreated, search)
+}
+
+func (a *APIServer) getSavedSearches(w http.ResponseWriter, r *http.Request) {
+	identity, _ := auth.FromContext(r.Context())
+	searches, err := a.searchSvc.GetSavedSearches(r.Context(), identity.UserID)
+	if err != nil {
+		errorMsg := "Error getting saved searches"
+		a.logger.Error(errorMsg, zap.Error(err))
+		a.writeServiceError(w, r, err, errorMsg)
+		return
+	}
+	a.writeJSON(w, r, http.StatusOK, searches)
+}
+
+func (a *APIServer) getSavedSearch(w http.ResponseWriter, r *http.Request) {
+	identity, _ := auth.FromContext(r.Context())
+	searchID, ok := a.readSearchID(w, r)
+	if !ok {
+		return
+	}
+	search, err := a.searchSvc.GetSavedSearch(r.Context(), identity.UserID, searchID)
+	if err != nil {
+		errorMsg := "Error getting saved search"
+		if errors.Is(err, database.ErrNotFound) {
+			errorMsg = "Saved search not found"
+		}
+		a.logger.Error(errorMsg, zap.Int("searchID", searchID), zap.Error(err))
+		a.writeServiceError(w, r, err, errorMsg)
+		return
+	}
+	a.writeJSON(w, r, http.StatusOK, search)
+}
+
+func (a *APIServer) deleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	identity, _ := auth.FromContext(r.Context())
+	searchID, ok := a.readSearchID(w, r)
+	if !ok {
+		return
+	}
+	err := a.searchSvc.DeleteSavedSearch(r.Context(), identity.UserID, searchID)
+	if err != nil {
+		errorMsg := "Error deleting saved search"
+		if errors.Is(err, database.ErrNotFound) {
+			errorMsg = "Saved search not found"
+		}
+		a.logger.Error(errorMsg, zap.Int("searchID", searchID), zap.Error(err))
+		a.writeServiceError(w, r, err, errorMsg)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *APIServer) getWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	identity, _ := auth.FromContext(r.Context())
+	searchID, ok := a.readSearchID(w, r)
+	if !ok {
+		return
+	}
+	limit := defaultDeliveriesLimit
+	if r.URL.Query().Has("limit") {
+		var err error
+		limit, err = strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil || limit < 1 || limit > maxDeliveriesLimit {
+			errorMsg := fmt.Sprintf("Limit must be a number between 1 and %d", maxDeliveriesLimit)
+			a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidParameter, "limit", errorMsg)
+			return
+		}
+	}
+
+	deliveries, err := a.searchSvc.GetWebhookDeliveries(r.Context(), identity.UserID, searchID, limit)
+	if err != nil {
+		errorMsg := "Error getting webhook deliveries"
+		if errors.Is(err, database.ErrNotFound) {
+			errorMsg = "Saved search not found"
+		}
+		a.logger.Error(errorMsg, zap.Int("searchID", searchID), zap.Error(err))
+		a.writeServiceError(w, r, err, errorMsg)
+		return
+	}
+	a.writeJSON(w, r, http.StatusOK, deliveries)
+}
+
+func (a *APIServer) readSearchID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	searchID, err := strconv.Atoi(chi.URLParam(r, "searchID"))
+	if err != nil {
+		errorMsg := "Incorrect saved search ID, please enter a valid number"
+		a.logger.Info(errorMsg, zap.String("searchID", chi.URLParam(r, "searchID")), zap.Error(err))
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidParameter, "searchID", errorMsg)
+		return 0, false
+	}
+	return searchID, true
+}
+
+func (a *APIServer) writeJSON(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	out, err := json.Marshal(v)
+	if err != nil {
+		errorMsg := "Error parsing response"
+		a.logger.Error(errorMsg, zap.Error(err))
+		a.writeProblem(w, r, http.StatusInternalServerError, apiv1.ErrCodeInternal, "", errorMsg)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err = w.Write(out)
+	if err != nil {
+		a.logger.Error("Error writing API response", zap.Error(err))
+	}
+}
@@ -0,0 +1,171 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/auth"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+)
+
+func TestAPIServer_SavedSearches(t *testing.T) {
+	tests := map[string]struct {
+		method         string
+		target         string
+		body           string
+		apiKey         string
+		expectedStatus int
+		expectedParam  string
+	}{
+		"Create saved search": {
+			method:         http.MethodPost,
+			target:         "/v1/saved-searches",
+			body:           `{"query": "price_max=10000&sort=price", "callback_url": "https://example.com/hook"}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusCreated,
+		},
+		"Create without authentication": {
+			method:         http.MethodPost,
+			target:         "/v1/saved-searches",
+			body:           `{"query": "price_max=10000", "callback_url": "https://example.com/hook"}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"Create with invalid query": {
+			method:         http.MethodPost,
+			target:         "/v1/saved-searches",
+			body:           `{"query": "sort=size", "callback_url": "https://example.com/hook"}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusBadRequest,
+			expectedParam:  "query",
+		},
+		"Create with relative callback URL": {
+			method:         http.MethodPost,
+			target:         "/v1/saved-searches",
+			body:           `{"query": "", "callback_url": "/hook"}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusBadRequest,
+			expectedParam:  "callback_url",
+		},
+		"Create with loopback callback URL": {
+			method:         http.MethodPost,
+			target:         "/v1/saved-searches",
+			body:           `{"query": "", "callback_url": "http://127.0.0.1:8080/hook"}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusBadRequest,
+			expectedParam:  "callback_url",
+		},
+		"Create with metadata service callback URL": {
+			method:         http.MethodPost,
+			target:         "/v1/saved-searches",
+			body:           `{"query": "", "callback_url": "http://169.254.169.254/latest/meta-data"}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusBadRequest,
+			expectedParam:  "callback_url",
+		},
+		"Create with internal service callback URL": {
+			method:         http.MethodPost,
+			target:         "/v1/saved-searches",
+			body:           `{"query": "", "callback_url": "http://rentals-db:5432/"}`,
+			apiKey:         "key-1",
+			expectedStatus: http.StatusBadRequest,
+			expectedParam:  "callback_url",
+		},
+		"List saved searches": {
+			method:         http.MethodGet,
+			target:         "/v1/saved-searches",
+			apiKey:         "key-1",
+			expectedStatus: http.StatusOK,
+		},
+		"Get saved search": {
+			method:         http.MethodGet,
+			target:         "/v1/saved-searches/1",
+			apiKey:         "key-1",
+			expectedStatus: http.StatusOK,
+		},
+		"Get saved search of another user": {
+			method:         http.MethodGet,
+			target:         "/v1/saved-searches/1",
+			apiKey:         "key-2",
+			expectedStatus: http.StatusForbidden,
+		},
+		"Get missing saved search": {
+			method:         http.MethodGet,
+			target:         "/v1/saved-searches/30",
+			apiKey:         "key-1",
+			expectedStatus: http.StatusNotFound,
+		},
+		"Delete saved search": {
+			method:         http.MethodDelete,
+			target:         "/v1/saved-searches/1",
+			apiKey:         "key-1",
+			expectedStatus: http.StatusNoContent,
+		},
+		"List deliveries": {
+			method:         http.MethodGet,
+			target:         "/v1/saved-searches/1/deliveries?limit=20",
+			apiKey:         "key-1",
+			expectedStatus: http.StatusOK,
+		},
+		"List deliveries with invalid limit": {
+			method:         http.MethodGet,
+			target:         "/v1/saved-searches/1/deliveries?limit=0",
+			apiKey:         "key-1",
+			expectedStatus: http.StatusBadRequest,
+			expectedParam:  "limit",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fake := newFakeServices()
+			server := New(Options{
+				Authenticator: auth.NewAPIKeyAuthenticator(map[string]int{"key-1": 1, "key-2": 2}),
+				SavedSearches: fake,
+			}, fake, zap.NewNop())
+			req := httptest.NewRequest(test.method, test.target, bytes.NewBufferString(test.body))
+			req.Header.Set("Content-Type", "application/json")
+			if test.apiKey != "" {
+				req.Header.Set("X-API-Key", test.apiKey)
+			}
+			w := httptest.NewRecorder()
+			server.Handler().ServeHTTP(w, req)
+
+			require.Equal(t, test.expectedStatus, w.Code, w.Body.String())
+			if test.expectedParam != "" {
+				var problem apiv1.Problem
+				require.Nil(t, json.Unmarshal(w.Body.Bytes(), &problem), "Error decoding problem")
+				assert.Equal(t, test.expectedParam, problem.Param)
+			}
+		})
+	}
+}
+
+func TestAPIServer_CreateSavedSearch(t *testing.T) {
+	fake := newFakeServices()
+	server := New(Options{
+		Authenticator: auth.NewAPIKeyAuthenticator(map[string]int{"key-1": 1}),
+		SavedSearches: fake,
+	}, fake, zap.NewNop())
+	body := `{"query": "price_min=9000&ids=4,5&limit=10", "callback_url": "https://example.com/hook"}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/saved-searches", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", "key-1")
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, "/v1/saved-searches/2", w.Header().Get("Location"))
+	var search apiv1.SavedSearch
+	require.Nil(t, json.Unmarshal(w.Body.Bytes(), &search), "Error decoding saved search")
+	assert.Equal(t, 1, search.UserID)
+	assert.Equal(t, "secret", search.Secret)
+	assert.Equal(t, database.RentalParams{PriceMin: 9000, IDs: []int{4, 5}, Limit: 10, IncludeUser: true}, fake.searchParams)
+}
@@ -1,34 +1,214 @@
 package web
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/netip"
 	"strconv"
 	"strings"
 	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/getkin/kin-openapi/routers"
 	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
 	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/auth"
+	"github.com/mkermilska/rentals-challenge/pkg/currency"
 	"github.com/mkermilska/rentals-challenge/pkg/database"
+	"github.com/mkermilska/rentals-challenge/pkg/ratelimit"
 	"github.com/mkermilska/rentals-challenge/pkg/service"
-	"github.com/mkermilska/rentals-challenge/pkg/utils"
+	"github.com/mkermilska/rentals-challenge/pkg/storage"
 )
 
+type Options struct {
+	Port int
+	// Authenticator resolves callers of the API. Without it all write endpoints answer 401.
+	Authenticator auth.Authenticator
+	// RateLimit applies to every route without an entry in RouteRateLimits. A zero limit disables it.
+	RateLimit       ratelimit.Limit
+	RouteRateLimits map[string]ratelimit.Limit
+	// TrustedProxies are the networks of the reverse proxies whose X-Forwarded-For header names the
+	// client IP of anonymous requests. Without them anonymous clients are keyed by the connection's
+	// address, so all the clients behind a proxy share one bucket.
+	TrustedProxies []netip.Prefix
+	CORS           CORSOptions
+	// CacheControl is sent with every cacheable GET response, e.g. "public, max-age=60".
+	CacheControl string
+	// CompressMinSize is the smallest response body, in bytes, that is compressed. Zero disables compression.
+	CompressMinSize int
+	// BatchGetMaxIDs caps the ids of one batch get request, 100 when zero.
+	BatchGetMaxIDs int
+	// SavedSearches enables the saved-searches endpoints when set.
+	SavedSearches SavedSearchService
+	// GraphQL is served at /graphql when set.
+	GraphQL http.Handler
+	// RentalEvents enables the rentals stream when set.
+	RentalEvents RentalEventService
+	// Pricing enables the pricing rules and quote endpoints when set.
+	Pricing PricingService
+	// StreamHeartbeat is the interval of the heartbeats sent on the rentals stream, 15s when zero.
+	StreamHeartbeat time.Duration
+	// Currencies enables the currency parameter of rental lookups and the exchange rates endpoints when set.
+	Currencies CurrencyService
+	// AdminUserIDs are the users allowed to call the /v1/admin and /debug endpoints.
+	AdminUserIDs []int
+	// Images enables the endpoints changing the image galleries of rentals when set.
+	Images RentalImageService
+	// Uploads enables the image upload endpoint when set.
+	Uploads RentalImageUploadService
+	// MaxUploadBytes caps the body of an image upload, 10 MiB when zero.
+	MaxUploadBytes int64
+	// Media is served at /media when set. It holds the uploaded images and their variants.
+	Media storage.BlobStore
+	// Amenities enables the amenities catalogue endpoint when set.
+	Amenities AmenityService
+	// Reviews enables the review endpoints of rentals when set.
+	Reviews ReviewService
+}
+
+// RentalService is what the API needs from service.RentalService.
+type RentalService interface {
+	GetRentalByID(ctx context.Context, rentalID int) (*apiv1.Rental, error)
+	GetRentals(ctx context.Context, params database.RentalParams) ([]apiv1.Rental, error)
+	CreateRental(ctx context.Context, ownerID int, input apiv1.RentalInput) (*apiv1.Rental, error)
+	UpdateRental(ctx context.Context, ownerID, rentalID int, input apiv1.RentalInput) (*apiv1.Rental, error)
+	DeleteRental(ctx context.Context, ownerID, rentalID int) error
+	CacheStats() *service.CacheStats
+}
+
+// SavedSearchService is what the API needs from service.SavedSearchService.
+type SavedSearchService interface {
+	CreateSavedSearch(ctx context.Context, ownerID int, input apiv1.SavedSearchInput, params database.RentalParams) (*apiv1.SavedSearch, error)
+	GetSavedSearch(ctx context.Context, ownerID, searchID int) (*apiv1.SavedSearch, error)
+	GetSavedSearches(ctx context.Context, ownerID int) ([]apiv1.SavedSearch, error)
+	DeleteSavedSearch(ctx context.Context, ownerID, searchID int) error
+	GetWebhookDeliveries(ctx context.Context, ownerID, searchID, limit int) ([]apiv1.WebhookDelivery, error)
+}
+
+// RentalEventService is what the rentals stream needs from service.RentalService.
+type RentalEventService interface {
+	LastRentalEventID(ctx context.Context) (int64, error)
+	GetRentalEvents(ctx context.Context, params database.RentalParams, afterID int64) (*service.RentalEventPage, error)
+	SubscribeChanges(buffer int) (<-chan database.RentalChange, func())
+}
+
+// PricingService is what the API needs from service.PricingService.
+type PricingService interface {
+	GetPricing(ctx context.Context, rentalID int) (*apiv1.PricingRules, error)
+	SetPricing(ctx context.Context, ownerID, rentalID int, rules apiv1.PricingRules) (*apiv1.PricingRules, error)
+	GetQuote(ctx context.Context, rentalID int, from, to time.Time) (*apiv1.Quote, error)
+}
+
+// RentalImageService is what the image endpoints need from service.RentalService.
+type RentalImageService interface {
+	GetRentalImages(ctx context.Context, rentalID int) ([]apiv1.RentalImage, error)
+	AddRentalImage(ctx context.Context, ownerID, rentalID int, input apiv1.RentalImageInput) (*apiv1.RentalImage, error)
+	ReorderRentalImages(ctx context.Context, ownerID, rentalID int, order apiv1.ImageOrder) ([]apiv1.RentalImage, error)
+	DeleteRentalImage(ctx context.Context, ownerID, rentalID, imageID int) error
+}
+
+// RentalImageUploadService is what the upload endpoint needs from service.RentalService.
+type RentalImageUploadService interface {
+	UploadRentalImage(ctx context.Context, ownerID, rentalID int, data []byte, caption string,
+		position *int) (*apiv1.RentalImage, error)
+}
+
+// AmenityService is what the amenities endpoint needs from service.RentalService.
+type AmenityService interface {
+	GetAmenities(ctx context.Context) ([]apiv1.Amenity, error)
+}
+
+// ReviewService is what the review endpoints need from service.RentalService.
+type ReviewService interface {
+	GetReviews(ctx context.Context, rentalID, limit, offset int) ([]apiv1.Review, error)
+	CreateReview(ctx context.Context, userID, rentalID int, input apiv1.ReviewInput) (*apiv1.Review, error)
+}
+
+// CurrencyService is what the API needs from service.CurrencyService.
+type CurrencyService interface {
+	GetExchangeRates(ctx context.Context) ([]apiv1.ExchangeRate, error)
+	SetExchangeRates(ctx context.Context, rates []apiv1.ExchangeRate) ([]apiv1.ExchangeRate, error)
+	GetRates(ctx context.Context) (currency.Rates, error)
+}
+
 type APIServer struct {
-	port       int
-	rentalSvc  service.RentalService
-	logger     *zap.Logger
-	httpServer *http.Server
+	port            int
+	rentalSvc       RentalService
+	openAPIRouter   routers.Router
+	authenticator   auth.Authenticator
+	defaultLimiter  *ratelimit.Limiter
+	routeLimiters   map[string]*ratelimit.Limiter
+	trustedProxies  []netip.Prefix
+	cors            CORSOptions
+	cacheControl    string
+	compressMinSize int
+	batchGetMaxIDs  int
+	searchSvc       SavedSearchService
+	graphQL         http.Handler
+	eventSvc        RentalEventService
+	pricingSvc      PricingService
+	streamHeartbeat time.Duration
+	currencySvc     CurrencyService
+	adminUserIDs    []int
+	imageSvc        RentalImageService
+	uploadSvc       RentalImageUploadService
+	maxUploadBytes  int64
+	media           storage.BlobStore
+	amenitySvc      AmenityService
+	reviewSvc       ReviewService
+	logger          *zap.Logger
+	httpServer      *http.Server
 }
 
-func New(port int, rentalSvc *service.RentalService, logger *zap.Logger) *APIServer {
+func New(opts Options, rentalSvc RentalService, logger *zap.Logger) *APIServer {
+	defaultLimiter, routeLimiters := newLimiters(opts)
+	batchGetMaxIDs := opts.BatchGetMaxIDs
+	if batchGetMaxIDs <= 0 {
+		batchGetMaxIDs = defaultBatchGetMaxIDs
+	}
+	streamHeartbeat := opts.StreamHeartbeat
+	if streamHeartbeat <= 0 {
+		streamHeartbeat = defaultStreamHeartbeat
+	}
+	maxUploadBytes := opts.MaxUploadBytes
+	if maxUploadBytes <= 0 {
+		maxUploadBytes = defaultMaxUploadBytes
+	}
+	openAPIRouter, err := newOpenAPIRouter()
+	if err != nil {
+		logger.Error("Request validation is disabled", zap.Error(err))
+	}
 	return &APIServer{
-		port:      port,
-		rentalSvc: *rentalSvc,
-		logger:    logger,
+		port:            opts.Port,
+		rentalSvc:       rentalSvc,
+		openAPIRouter:   openAPIRouter,
+		authenticator:   opts.Authenticator,
+		defaultLimiter:  defaultLimiter,
+		routeLimiters:   routeLimiters,
+		trustedProxies:  opts.TrustedProxies,
+		cors:            opts.CORS,
+		cacheControl:    opts.CacheControl,
+		compressMinSize: opts.CompressMinSize,
+		batchGetMaxIDs:  batchGetMaxIDs,
+		searchSvc:       opts.SavedSearches,
+		graphQL:         opts.GraphQL,
+		eventSvc:        opts.RentalEvents,
+		pricingSvc:      opts.Pricing,
+		streamHeartbeat: streamHeartbeat,
+		currencySvc:     opts.Currencies,
+		adminUserIDs:    opts.AdminUserIDs,
+		imageSvc:        opts.Images,
+		uploadSvc:       opts.Uploads,
+		maxUploadBytes:  maxUploadBytes,
+		media:           opts.Media,
+		amenitySvc:      opts.Amenities,
+		reviewSvc:       opts.Reviews,
+		logger:          logger,
 	}
 }
 
@@ -36,7 +216,7 @@ func (a *APIServer) Start() {
 	a.logger.Info("Starting API Server", zap.Int("port", a.port))
 	a.httpServer = &http.Server{
 		Addr:              fmt.Sprintf(":%d", a.port),
-		Handler:           a.handler(),
+		Handler:           a.Handler(),
 		ReadHeaderTimeout: 2 * time.Second,
 	}
 	err := a.httpServer.ListenAndServe()
@@ -45,176 +225,217 @@ func (a *APIServer) Start() {
 	}
 }
 
-func (a *APIServer) handler() http.Handler {
+// Handler returns the routes of the API with all middlewares, as served by Start.
+func (a *APIServer) Handler() http.Handler {
 	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(requestIDHeader)
+	r.Use(corsHandler(a.cors))
+	r.Use(a.authenticate)
+	r.Use(a.compress(a.compressMinSize))
+
+	r.With(a.requireIdentity, a.requireAdmin).Get("/debug/cache", a.getCacheStats)
+	if a.graphQL != nil {
+		r.With(a.rateLimit(RouteGraphQL)).Handle("/graphql", a.graphQL)
+	}
+	if a.media != nil {
+		r.With(a.rateLimit(RouteMedia)).Get("/media/*", a.getMedia)
+	}
 
 	r.Route("/v1", func(r chi.Router) {
-		r.Get("/rentals", a.getRentals)
-		r.Get("/rentals/{rentalID}", a.getRentalByID)
+		r.Get("/openapi.json", a.getOpenAPI)
+		r.Get("/docs", a.getDocs)
+
+		r.With(a.rateLimit(RouteRentalsList), a.validateRequest).Get("/rentals", a.getRentals)
+		r.With(a.rateLimit(RouteRentalsGet), a.validateRequest).Get("/rentals/{rentalID}", a.getRentalByID)
+		r.With(a.rateLimit(RouteRentalsBatchGet), a.validateRequest).Post("/rentals:batchGet", a.batchGetRentals)
+		if a.eventSvc != nil {
+			r.With(a.rateLimit(RouteRentalsStream), a.validateRequest).Get("/rentals/stream", a.streamRentals)
+		}
+
+		r.Group(func(r chi.Router) {
+			r.Use(a.requireIdentity)
+			r.Use(a.rateLimit(RouteRentalsWrite))
+			r.Use(a.validateRequest)
+			r.Post("/rentals", a.createRental)
+			r.Put("/rentals/{rentalID}", a.updateRental)
+			r.Delete("/rentals/{rentalID}", a.deleteRental)
+		})
+
+		if a.pricingSvc != nil {
+			r.With(a.rateLimit(RouteRentalsGet), a.validateRequest).Get("/rentals/{rentalID}/pricing", a.getPricing)
+			r.With(a.rateLimit(RouteRentalsQuote), a.validateRequest).Get("/rentals/{rentalID}/quote", a.getQuote)
+			r.With(a.requireIdentity, a.rateLimit(RouteRentalsWrite), a.validateRequest).
+				Put("/rentals/{rentalID}/pricing", a.putPricing)
+		}
+
+		if a.imageSvc != nil {
+			r.With(a.rateLimit(RouteRentalsGet), a.validateRequest).Get("/rentals/{rentalID}/images", a.getRentalImages)
+			r.Group(func(r chi.Router) {
+				r.Use(a.requireIdentity)
+				r.Use(a.rateLimit(RouteRentalsWrite))
+				r.Use(a.validateRequest)
+				r.Post("/rentals/{rentalID}/images", a.addRentalImage)
+				r.Post("/rentals/{rentalID}/images:reorder", a.reorderRentalImages)
+				r.Delete("/rentals/{rentalID}/images/{imageID}", a.deleteRentalImage)
+			})
+		}
+		if a.uploadSvc != nil {
+			// not validated against the OpenAPI document, which would buffer the whole upload first
+			r.With(a.requireIdentity, a.rateLimit(RouteRentalsWrite)).
+				Post("/rentals/{rentalID}/images:upload", a.uploadRentalImage)
+		}
+
+		if a.reviewSvc != nil {
+			r.With(a.rateLimit(RouteRentalsGet), a.validateRequest).Get("/rentals/{rentalID}/reviews", a.getReviews)
+			r.With(a.requireIdentity, a.rateLimit(RouteRentalsWrite), a.validateRequest).
+				Post("/rentals/{rentalID}/reviews", a.createReview)
+		}
+
+		if a.amenitySvc != nil {
+			r.With(a.rateLimit(RouteAmenities), a.validateRequest).Get("/amenities", a.getAmenities)
+		}
+
+		if a.currencySvc != nil {
+			r.With(a.rateLimit(RouteExchangeRates), a.validateRequest).Get("/exchange-rates", a.getExchangeRates)
+			r.With(a.requireIdentity, a.requireAdmin, a.rateLimit(RouteExchangeRates), a.validateRequest).
+				Put("/admin/exchange-rates", a.putExchangeRates)
+		}
+
+		if a.searchSvc != nil {
+			r.Group(func(r chi.Router) {
+				r.Use(a.requireIdentity)
+				r.Use(a.rateLimit(RouteSavedSearches))
+				r.Use(a.validateRequest)
+				r.Post("/saved-searches", a.createSavedSearch)
+				r.Get("/saved-searches", a.getSavedSearches)
+				r.Get("/saved-searches/{searchID}", a.getSavedSearch)
+				r.Delete("/saved-searches/{searchID}", a.deleteSavedSearch)
+				r.Get("/saved-searches/{searchID}/deliveries", a.getWebhookDeliveries)
+			})
+		}
 	})
 
 	return r
 }
 
 func (a *APIServer) getRentalByID(w http.ResponseWriter, r *http.Request) {
+	contentType, ok := a.negotiate(w, r)
+	if !ok {
+		return
+	}
+	fields, includeUser, ok := a.readProjection(w, r)
+	if !ok {
+		return
+	}
 	rentalID, err := strconv.Atoi(chi.URLParam(r, "rentalID"))
 	if err != nil {
 		errorMsg := "Incorrect rental ID, please enter a valid number"
 		a.logger.Error(errorMsg, zap.String("rentalID", chi.URLParam(r, "rentalID")), zap.Error(err))
-		http.Error(w, errorMsg, http.StatusBadRequest)
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidParameter, "rentalID", errorMsg)
+		return
+	}
+	code, paramErr := parseCurrency(r.URL.Query())
+	if paramErr != nil {
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidParameter, paramErr.param, paramErr.msg)
+		return
+	}
+	rates, ok := a.readRates(w, r, code)
+	if !ok {
 		return
 	}
 
-	rental, err := a.rentalSvc.GetRentalByID(rentalID)
+	rental, err := a.rentalSvc.GetRentalByID(r.Context(), rentalID)
 	if err != nil {
-		errorMsg := "Rental not found"
+		errorMsg := "Error getting rental"
+		if errors.Is(err, database.ErrNotFound) {
+			errorMsg = "Rental not found"
+		}
 		a.logger.Error(errorMsg, zap.Int("rentalID", rentalID), zap.Error(err))
-		http.Error(w, errorMsg, http.StatusNotFound)
+		a.writeServiceError(w, r, err, errorMsg)
 		return
 	}
 
-	out, err := json.Marshal(rental)
+	if !includeUser {
+		withoutUser := *rental
+		withoutUser.User = nil
+		rental = &withoutUser
+	}
+	lastModified := rental.Updated
+	if rates != nil {
+		// converted prices follow the exchange rates, which change independently of the rental
+		rental = &convertRentals([]apiv1.Rental{*rental}, rates, code)[0]
+		lastModified = time.Time{}
+	}
+	out, err := encodeRental(contentType, *rental, fields)
 	if err != nil {
 		errorMsg := "Error parsing rental"
 		a.logger.Error(errorMsg, zap.Any("rental", rental), zap.Error(err))
-		http.Error(w, errorMsg, http.StatusInternalServerError)
+		a.writeProblem(w, r, http.StatusInternalServerError, apiv1.ErrCodeInternal, "", errorMsg)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	_, err = w.Write(out)
-	if err != nil {
-		a.logger.Error("Error writing API response", zap.Error(err))
-	}
+	a.writeCacheable(w, r, contentType, out, lastModified)
 }
 
 func (a *APIServer) getRentals(w http.ResponseWriter, r *http.Request) {
-	//reading the input params could be simplified with using a library.
-	queryParams := database.RentalParams{}
-	if r.URL.Query().Has("price_min") {
-		minPrice, err := strconv.Atoi(r.URL.Query().Get("price_min"))
-		if err != nil {
-			errorMsg := "Invalid value for price_min parameter"
-			a.logger.Error(errorMsg, zap.Error(err))
-			http.Error(w, errorMsg, http.StatusBadRequest)
-			return
-		}
-		queryParams.PriceMin = minPrice
-	}
-
-	if r.URL.Query().Has("price_max") {
-		maxPrice, err := strconv.Atoi(r.URL.Query().Get("price_max"))
-		if err != nil {
-			errorMsg := "Invalid value for price_max parameter"
-			a.logger.Error(errorMsg, zap.Error(err))
-			http.Error(w, errorMsg, http.StatusBadRequest)
-			return
-		}
-		queryParams.PriceMax = maxPrice
+	contentType, ok := a.negotiate(w, r)
+	if !ok {
+		return
 	}
-
-	if r.URL.Query().Has("ids") {
-		IDs := r.URL.Query().Get("ids")
-		if IDs == "" {
-			errorMsg := "Empty ids parameter"
-			http.Error(w, errorMsg, http.StatusBadRequest)
-			return
-		}
-		IDsArr := strings.Split(IDs, ",")
-		for _, ID := range IDsArr {
-			if _, err := strconv.Atoi(ID); err != nil {
-				errorMsg := "Invalid id exists in ids parameter"
-				a.logger.Error(errorMsg, zap.Error(err))
-				http.Error(w, errorMsg, http.StatusBadRequest)
-				return
-			}
-		}
-		queryParams.IDs = IDsArr
+	fields, includeUser, ok := a.readProjection(w, r)
+	if !ok {
+		return
 	}
 
-	if r.URL.Query().Has("near") {
-		near := r.URL.Query().Get("near")
-		if near == "" {
-			errorMsg := "Empty near parameter"
-			http.Error(w, errorMsg, http.StatusBadRequest)
-			return
-		}
-		nearPoint := strings.Split(near, ",")
-		if len(nearPoint) != 2 {
-			errorMsg := "Near parameter expects comma separated pair of float numbers"
-			http.Error(w, errorMsg, http.StatusBadRequest)
-			return
-		}
-		latPoint, err := strconv.ParseFloat(nearPoint[0], 64)
-		if err != nil {
-			errorMsg := "Invalid latitude value in near parameter"
-			a.logger.Error(errorMsg, zap.Error(err))
-			http.Error(w, errorMsg, http.StatusBadRequest)
-			return
-		}
-		lngPoint, err := strconv.ParseFloat(nearPoint[1], 64)
-		if err != nil {
-			errorMsg := "Invalid longitude value in near parameter"
-			a.logger.Error(errorMsg, zap.Error(err))
-			http.Error(w, errorMsg, http.StatusBadRequest)
-			return
-		}
-		queryParams.Near = *utils.CalculateNearBox(utils.Point{
-			Lat: latPoint,
-			Lng: lngPoint,
-		}, 100)
+	queryParams, paramErr := parseRentalParams(r.URL.Query())
+	if paramErr != nil {
+		a.logger.Info(paramErr.msg, zap.Error(paramErr.err))
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidParameter, paramErr.param, paramErr.msg)
+		return
 	}
-
-	if r.URL.Query().Has("limit") {
-		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
-		if err != nil {
-			errorMsg := "Invalid value for limit parameter"
-			a.logger.Error(errorMsg, zap.Error(err))
-			http.Error(w, errorMsg, http.StatusBadRequest)
-			return
-		}
-		queryParams.Limit = limit
+	queryParams.IncludeUser = includeUser
+	rates, ok := a.readRates(w, r, queryParams.Currency)
+	if !ok {
+		return
 	}
 
-	if r.URL.Query().Has("offset") {
-		offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
-		if err != nil {
-			errorMsg := "Invalid value for offset parameter"
-			a.logger.Error(errorMsg, zap.Error(err))
-			http.Error(w, errorMsg, http.StatusBadRequest)
-			return
-		}
-		queryParams.Offset = offset
+	rentals, err := a.rentalSvc.GetRentals(r.Context(), queryParams)
+	if err != nil {
+		errorMsg := "Error getting rentals"
+		a.logger.Error(errorMsg, zap.Error(err))
+		a.writeServiceError(w, r, err, errorMsg)
+		return
 	}
-
-	if r.URL.Query().Has("sort") {
-		sortBy := r.URL.Query().Get("sort")
-		if sortBy == "" {
-			errorMsg := "Empty sort parameter"
-			http.Error(w, errorMsg, http.StatusBadRequest)
-			return
-		}
-		if _, exists := apiv1.SortsMap[sortBy]; !exists {
-			errorMsg := "Sort by given column is not allowed"
-			http.Error(w, errorMsg, http.StatusBadRequest)
-			return
-		}
-		queryParams.Sort = apiv1.SortsMap[sortBy]
+	lastModified := rentalsLastModified(rentals)
+	if rates != nil {
+		rentals = convertRentals(rentals, rates, queryParams.Currency)
+		lastModified = time.Time{}
 	}
 
-	rentals, err := a.rentalSvc.GetRentals(queryParams)
+	out, err := encodeRentals(contentType, rentals, fields)
 	if err != nil {
-		errorMsg := "Error getting rentals"
+		errorMsg := "Error parsing rentals"
 		a.logger.Error(errorMsg, zap.Error(err))
-		http.Error(w, errorMsg, http.StatusNotFound)
+		a.writeProblem(w, r, http.StatusInternalServerError, apiv1.ErrCodeInternal, "", errorMsg)
+		return
+	}
+
+	a.writeCacheable(w, r, contentType, out, lastModified)
+}
+
+func (a *APIServer) getCacheStats(w http.ResponseWriter, r *http.Request) {
+	stats := a.rentalSvc.CacheStats()
+	if stats == nil {
+		a.writeProblem(w, r, http.StatusNotFound, apiv1.ErrCodeNotFound, "", "Rental cache is disabled")
 		return
 	}
 
-	out, err := json.Marshal(rentals)
+	out, err := json.Marshal(stats)
 	if err != nil {
-		errorMsg := "Error parsing rentals"
+		errorMsg := "Error parsing cache stats"
 		a.logger.Error(errorMsg, zap.Error(err))
-		http.Error(w, errorMsg, http.StatusInternalServerError)
+		a.writeProblem(w, r, http.StatusInternalServerError, apiv1.ErrCodeInternal, "", errorMsg)
 		return
 	}
 
@@ -224,3 +445,47 @@ func (a *APIServer) getRentals(w http.ResponseWriter, r *http.Request) {
 		a.logger.Error("Error writing API response", zap.Error(err))
 	}
 }
+
+// negotiate picks the response content type from the Accept header, answering 406 when none is supported.
+func (a *APIServer) negotiate(w http.ResponseWriter, r *http.Request) (string, bool) {
+	contentType, ok := negotiateContentType(r.Header.Get("Accept"))
+	if !ok {
+		errorMsg := "Supported representations are application/json, text/csv and application/msgpack"
+		a.writeProblem(w, r, http.StatusNotAcceptable, apiv1.ErrCodeNotAcceptable, "", errorMsg)
+		return "", false
+	}
+	return contentType, true
+}
+
+// readProjection reads the fields and include parameters. Without include the owner is returned,
+// unless fields are given and none of them belongs to the owner.
+func (a *APIServer) readProjection(w http.ResponseWriter, r *http.Request) ([]rentalField, bool, bool) {
+	var fields []rentalField
+	if r.URL.Query().Has("fields") {
+		var err error
+		fields, err = parseFields(r.URL.Query().Get("fields"))
+		if err != nil {
+			errorMsg := "Invalid field in fields parameter"
+			a.logger.Info(errorMsg, zap.Error(err))
+			a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidParameter, "fields", errorMsg)
+			return nil, false, false
+		}
+	}
+
+	includeUser := fields == nil || selectsUser(fields)
+	if r.URL.Query().Has("include") {
+		includeUser = false
+		for _, include := range strings.Split(r.URL.Query().Get("include"), ",") {
+			switch include {
+			case "user":
+				includeUser = true
+			case "":
+			default:
+				errorMsg := "Include parameter only supports user"
+				a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidParameter, "include", errorMsg)
+				return nil, false, false
+			}
+		}
+	}
+	return fields, includeUser, true
+}
@@ -0,0 +1,59 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/mkermilska/rentals-challenge/pkg/auth"
+	"github.com/mkermilska/rentals-challenge/pkg/cache"
+	"github.com/mkermilska/rentals-challenge/pkg/service"
+)
+
+func TestAPIServer_DebugCache(t *testing.T) {
+	tests := map[string]struct {
+		apiKey         string
+		expectedStatus int
+	}{
+		"Anonymous": {
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"Not an administrator": {
+			apiKey:         "key-2",
+			expectedStatus: http.StatusForbidden,
+		},
+		"Administrator": {
+			apiKey:         "key-1",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	fake := newFakeServices()
+	fake.cacheStats = &service.CacheStats{Rental: cache.Stats{Hits: 3, Misses: 1}}
+	server := New(Options{
+		Authenticator: auth.NewAPIKeyAuthenticator(map[string]int{"key-1": 1, "key-2": 2}),
+		AdminUserIDs:  []int{1},
+	}, fake, zap.NewNop())
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/debug/cache", nil)
+			if test.apiKey != "" {
+				req.Header.Set(auth.APIKeyHeader, test.apiKey)
+			}
+			w := httptest.NewRecorder()
+			server.Handler().ServeHTTP(w, req)
+
+			require.Equal(t, test.expectedStatus, w.Code, w.Body.String())
+			if test.expectedStatus == http.StatusOK {
+				var stats service.CacheStats
+				require.Nil(t, json.Unmarshal(w.Body.Bytes(), &stats), "Error decoding cache stats")
+				assert.Equal(t, uint64(3), stats.Rental.Hits)
+			}
+		})
+	}
+}
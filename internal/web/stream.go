@@ -0,0 +1,137 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+)
+
+const (
+	// defaultStreamHeartbeat is the heartbeat interval of the rentals stream when none is configured.
+	defaultStreamHeartbeat = 15 * time.Second
+	// streamChangesBuffer is the number of change notifications a stream may fall behind.
+	streamChangesBuffer = 16
+)
+
+// streamRentals sends the rental events matching the rentals list filters as Server-Sent Events.
+// A change notification, a pending gap and every heartbeat trigger a read of the events after the
+// last one sent, so the stream resumes from Last-Event-ID without missing events.
+func (a *APIServer) streamRentals(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		errorMsg := "Streaming is not supported"
+		a.logger.Error(errorMsg)
+		a.writeProblem(w, r, http.StatusInternalServerError, apiv1.ErrCodeInternal, "", errorMsg)
+		return
+	}
+	queryParams, paramErr := parseRentalParams(r.URL.Query())
+	if paramErr != nil {
+		a.logger.Info(paramErr.msg, zap.Error(paramErr.err))
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidParameter, paramErr.param, paramErr.msg)
+		return
+	}
+	if queryParams.Currency != "" {
+		errorMsg := "The rentals stream sends prices in the currency of each rental"
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidParameter, "currency", errorMsg)
+		return
+	}
+
+	ctx := r.Context()
+	var lastEventID int64
+	// browsers resend the header on reconnects, last_event_id allows resuming a fresh connection
+	lastEventIDParam, param := r.Header.Get("Last-Event-ID"), "Last-Event-ID"
+	if lastEventIDParam == "" {
+		lastEventIDParam, param = r.URL.Query().Get("last_event_id"), "last_event_id"
+	}
+	if lastEventIDParam != "" {
+		var err error
+		lastEventID, err = strconv.ParseInt(lastEventIDParam, 10, 64)
+		if err != nil || lastEventID < 0 {
+			errorMsg := "Last event ID must be a positive number"
+			a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidParameter, param, errorMsg)
+			return
+		}
+	} else {
+		var err error
+		lastEventID, err = a.eventSvc.LastRentalEventID(ctx)
+		if err != nil {
+			errorMsg := "Error getting rental events"
+			a.logger.Error(errorMsg, zap.Error(err))
+			a.writeServiceError(w, r, err, errorMsg)
+			return
+		}
+	}
+
+	changes, unsubscribe := a.eventSvc.SubscribeChanges(streamChangesBuffer)
+	defer func() {
+		unsubscribe()
+	}()
+	heartbeat := time.NewTicker(a.streamHeartbeat)
+	defer heartbeat.Stop()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		pending, err := a.sendRentalEvents(w, r, queryParams, &lastEventID)
+		if err != nil {
+			a.logger.Info("Closing rentals stream", zap.Int64("lastEventID", lastEventID), zap.Error(err))
+			return
+		}
+		flusher.Flush()
+		var retry <-chan time.Time
+		if pending {
+			retry = time.After(time.Second)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-changes:
+			if !ok {
+				// dropped for falling behind, nothing is lost as the events are read from lastEventID
+				changes, unsubscribe = a.eventSvc.SubscribeChanges(streamChangesBuffer)
+			}
+		case <-retry:
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// sendRentalEvents writes the events after lastEventID and moves it past them. It reports whether
+// events are pending.
+func (a *APIServer) sendRentalEvents(w http.ResponseWriter, r *http.Request, queryParams database.RentalParams,
+	lastEventID *int64) (bool, error) {
+	for {
+		page, err := a.eventSvc.GetRentalEvents(r.Context(), queryParams, *lastEventID)
+		if err != nil {
+			return false, err
+		}
+		for _, event := range page.Events {
+			data, err := json.Marshal(event)
+			if err != nil {
+				return false, err
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+				return false, err
+			}
+		}
+		*lastEventID = page.Next
+		if !page.Full {
+			return page.Pending, nil
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+)
+
+// readEvents reads the stream until count events were received.
+func readEvents(t *testing.T, scanner *bufio.Scanner, count int) []apiv1.RentalEvent {
+	var events []apiv1.RentalEvent
+	var id, eventType string
+	for len(events) < count && scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			eventType = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			var event apiv1.RentalEvent
+			require.Nil(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event), "Error decoding event")
+			assert.Equal(t, strconv.FormatInt(event.ID, 10), id)
+			assert.Equal(t, eventType, event.Type)
+			events = append(events, event)
+		}
+	}
+	require.Len(t, events, count, "Stream ended early")
+	return events
+}
+
+func TestAPIServer_StreamRentals(t *testing.T) {
+	tests := map[string]struct {
+		target         string
+		lastEventID    string
+		expectedStatus int
+		expectedIDs    []int64
+	}{
+		"Resume after Last-Event-ID": {
+			target:         "/v1/rentals/stream",
+			lastEventID:    "1",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int64{2, 3, 4},
+		},
+		"Resume with filters": {
+			target:         "/v1/rentals/stream?price_max=250&last_event_id=0",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int64{1, 2, 4},
+		},
+		"Only changes to come": {
+			target:         "/v1/rentals/stream",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int64{4},
+		},
+		"Invalid Last-Event-ID": {
+			target:         "/v1/rentals/stream",
+			lastEventID:    "last",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"Invalid filter": {
+			target:         "/v1/rentals/stream?price_min=cheap",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fake := newFakeServices()
+			server := httptest.NewServer(New(Options{RentalEvents: fake}, fake, zap.NewNop()).Handler())
+			defer server.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+test.target, nil)
+			require.Nil(t, err, "Error creating request")
+			if test.lastEventID != "" {
+				req.Header.Set("Last-Event-ID", test.lastEventID)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.Nil(t, err, "Error opening stream")
+			defer resp.Body.Close()
+			require.Equal(t, test.expectedStatus, resp.StatusCode)
+			if test.expectedStatus != http.StatusOK {
+				return
+			}
+			assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+			scanner := bufio.NewScanner(resp.Body)
+			events := readEvents(t, scanner, len(test.expectedIDs)-1)
+			fake.addEvent(fakeRentalEvent(4, 150))
+			events = append(events, readEvents(t, scanner, 1)...)
+			var IDs []int64
+			for _, event := range events {
+				IDs = append(IDs, event.ID)
+			}
+			assert.Equal(t, test.expectedIDs, IDs)
+		})
+	}
+}
+
+func TestAPIServer_StreamRentalsHeartbeat(t *testing.T) {
+	fake := newFakeServices()
+	server := httptest.NewServer(New(Options{RentalEvents: fake, StreamHeartbeat: 10 * time.Millisecond},
+		fake, zap.NewNop()).Handler())
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/v1/rentals/stream", nil)
+	require.Nil(t, err, "Error creating request")
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err, "Error opening stream")
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	require.True(t, scanner.Scan(), "Stream ended early")
+	assert.Equal(t, ": heartbeat", scanner.Text())
+}
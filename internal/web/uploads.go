@@ -0,0 +1,113 @@
+package web
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/auth"
+	"github.com/mkermilska/rentals-challenge/pkg/service"
+	"github.com/mkermilska/rentals-challenge/pkg/storage"
+)
+
+const defaultMaxUploadBytes = 10 << 20
+
+// uploadRentalImage reads a multipart/form-data body with the file in the image field and the
+// optional caption and position fields of RentalImageInput.
+func (a *APIServer) uploadRentalImage(w http.ResponseWriter, r *http.Request) {
+	identity, _ := auth.FromContext(r.Context())
+	rentalID, ok := a.readRentalID(w, r)
+	if !ok {
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, a.maxUploadBytes)
+	if err := r.ParseMultipartForm(a.maxUploadBytes); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			errorMsg := "Image upload exceeds " + strconv.FormatInt(a.maxUploadBytes, 10) + " bytes"
+			a.logger.Info(errorMsg, zap.Int("rentalID", rentalID))
+			a.writeProblem(w, r, http.StatusRequestEntityTooLarge, apiv1.ErrCodeInvalidBody, "image", errorMsg)
+			return
+		}
+		errorMsg := "Invalid multipart form in request body"
+		a.logger.Info(errorMsg, zap.Error(err))
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, "", errorMsg)
+		return
+	}
+	defer func() {
+		_ = r.MultipartForm.RemoveAll()
+	}()
+
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		errorMsg := "Missing image file in request body"
+		a.logger.Info(errorMsg, zap.Error(err))
+		a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, "image", errorMsg)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		errorMsg := "Error reading uploaded image"
+		a.logger.Error(errorMsg, zap.Error(err))
+		a.writeProblem(w, r, http.StatusInternalServerError, apiv1.ErrCodeInternal, "", errorMsg)
+		return
+	}
+	var position *int
+	if value := r.FormValue("position"); value != "" {
+		p, err := strconv.Atoi(value)
+		if err != nil || p < 0 {
+			errorMsg := "Position must be a number not below 0"
+			a.logger.Info(errorMsg, zap.String("position", value))
+			a.writeProblem(w, r, http.StatusBadRequest, apiv1.ErrCodeInvalidBody, "position", errorMsg)
+			return
+		}
+		position = &p
+	}
+
+	image, err := a.uploadSvc.UploadRentalImage(r.Context(), identity.UserID, rentalID, data, r.FormValue("caption"), position)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidImage) {
+			errorMsg := "Invalid image: " + reason(err, service.ErrInvalidImage)
+			a.logger.Info(errorMsg, zap.Int("rentalID", rentalID))
+			a.writeProblem(w, r, http.StatusUnsupportedMediaType, apiv1.ErrCodeInvalidBody, "image", errorMsg)
+			return
+		}
+		errorMsg := "Error uploading rental image"
+		a.logger.Error(errorMsg, zap.Int("rentalID", rentalID), zap.Error(err))
+		a.writeServiceError(w, r, err, errorMsg)
+		return
+	}
+	a.writeJSON(w, r, http.StatusCreated, image)
+}
+
+// getMedia serves a stored object. Keys of uploads are never reused, so objects are cached for a year.
+func (a *APIServer) getMedia(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "*")
+	object, err := a.media.Open(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+			errorMsg := "Media not found"
+			a.logger.Debug(errorMsg, zap.String("key", key))
+			a.writeProblem(w, r, http.StatusNotFound, apiv1.ErrCodeNotFound, "", errorMsg)
+			return
+		}
+		errorMsg := "Error opening media"
+		a.logger.Error(errorMsg, zap.String("key", key), zap.Error(err))
+		a.writeProblem(w, r, http.StatusInternalServerError, apiv1.ErrCodeInternal, "", errorMsg)
+		return
+	}
+	defer object.Close()
+
+	if object.ContentType != "" {
+		w.Header().Set("Content-Type", object.ContentType)
+	}
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, "", object.Modified, object)
+}
@@ -0,0 +1,173 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/auth"
+	"github.com/mkermilska/rentals-challenge/pkg/storage"
+)
+
+func TestAPIServer_UploadRentalImage(t *testing.T) {
+	tests := map[string]struct {
+		target           string
+		fields           map[string]string
+		image            string
+		apiKey           string
+		expectedStatus   int
+		expectedParam    string
+		expectedPosition int
+	}{
+		"Upload image": {
+			target:           "/v1/rentals/1/images:upload",
+			fields:           map[string]string{"caption": "Front", "position": "0"},
+			image:            "GIF89a",
+			apiKey:           "key-1",
+			expectedStatus:   http.StatusCreated,
+			expectedPosition: 0,
+		},
+		"Upload image at the end": {
+			target:           "/v1/rentals/1/images:upload",
+			image:            "GIF89a",
+			apiKey:           "key-1",
+			expectedStatus:   http.StatusCreated,
+			expectedPosition: 2,
+		},
+		"Upload without authentication": {
+			target:         "/v1/rentals/1/images:upload",
+			image:          "GIF89a",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"Upload to rental of another user": {
+			target:         "/v1/rentals/1/images:upload",
+			image:          "GIF89a",
+			apiKey:         "key-2",
+			expectedStatus: http.StatusForbidden,
+		},
+		"Upload to missing rental": {
+			target:         "/v1/rentals/30/images:upload",
+			image:          "GIF89a",
+			apiKey:         "key-1",
+			expectedStatus: http.StatusNotFound,
+		},
+		"Upload without image": {
+			target:         "/v1/rentals/1/images:upload",
+			fields:         map[string]string{"caption": "Front"},
+			apiKey:         "key-1",
+			expectedStatus: http.StatusBadRequest,
+			expectedParam:  "image",
+		},
+		"Upload with invalid position": {
+			target:         "/v1/rentals/1/images:upload",
+			fields:         map[string]string{"position": "-1"},
+			image:          "GIF89a",
+			apiKey:         "key-1",
+			expectedStatus: http.StatusBadRequest,
+			expectedParam:  "position",
+		},
+		"Upload of a text file": {
+			target:         "/v1/rentals/1/images:upload",
+			image:          "hello",
+			apiKey:         "key-1",
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedParam:  "image",
+		},
+		"Upload of a too large image": {
+			target:         "/v1/rentals/1/images:upload",
+			image:          "GIF89a" + strings.Repeat("x", 2048),
+			apiKey:         "key-1",
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedParam:  "image",
+		},
+	}
+
+	fake := newFakeServices()
+	server := New(Options{
+		Authenticator:  auth.NewAPIKeyAuthenticator(map[string]int{"key-1": 1, "key-2": 2}),
+		Uploads:        fake,
+		MaxUploadBytes: 1024,
+	}, fake, zap.NewNop())
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			for name, value := range test.fields {
+				require.Nil(t, form.WriteField(name, value))
+			}
+			if test.image != "" {
+				part, err := form.CreateFormFile("image", "front.gif")
+				require.Nil(t, err)
+				_, err = part.Write([]byte(test.image))
+				require.Nil(t, err)
+			}
+			require.Nil(t, form.Close())
+			req := httptest.NewRequest(http.MethodPost, test.target, &body)
+			req.Header.Set("Content-Type", form.FormDataContentType())
+			if test.apiKey != "" {
+				req.Header.Set("X-API-Key", test.apiKey)
+			}
+			w := httptest.NewRecorder()
+			server.Handler().ServeHTTP(w, req)
+
+			require.Equal(t, test.expectedStatus, w.Code, w.Body.String())
+			if test.expectedParam != "" {
+				var problem apiv1.Problem
+				require.Nil(t, json.Unmarshal(w.Body.Bytes(), &problem), "Error decoding problem")
+				assert.Equal(t, test.expectedParam, problem.Param)
+			}
+			if test.expectedStatus == http.StatusCreated {
+				var image apiv1.RentalImage
+				require.Nil(t, json.Unmarshal(w.Body.Bytes(), &image), "Error decoding image")
+				assert.Equal(t, test.expectedPosition, image.Position)
+				assert.Equal(t, test.fields["caption"], image.Caption)
+				require.Len(t, image.Variants, 1)
+				assert.Equal(t, "w160", image.Variants[0].Name)
+			}
+		})
+	}
+}
+
+func TestAPIServer_GetMedia(t *testing.T) {
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.Nil(t, err, "Error creating store")
+	data := bytes.Repeat([]byte{0xff, 0xd8, 0xff}, 1000)
+	require.Nil(t, store.Put(context.Background(), "rentals/1/abc/w160.jpg", bytes.NewReader(data), int64(len(data)), "image/jpeg"))
+
+	tests := map[string]struct {
+		target         string
+		expectedStatus int
+	}{
+		"Get media":                  {target: "/media/rentals/1/abc/w160.jpg", expectedStatus: http.StatusOK},
+		"Get missing media":          {target: "/media/rentals/1/abc/w480.jpg", expectedStatus: http.StatusNotFound},
+		"Get media outside of store": {target: "/media/rentals/../../w160.jpg", expectedStatus: http.StatusNotFound},
+	}
+
+	server := New(Options{Media: store, CompressMinSize: 10}, newFakeServices(), zap.NewNop())
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.target, nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			w := httptest.NewRecorder()
+			server.Handler().ServeHTTP(w, req)
+
+			require.Equal(t, test.expectedStatus, w.Code, w.Body.String())
+			if test.expectedStatus == http.StatusOK {
+				assert.Equal(t, "image/jpeg", w.Header().Get("Content-Type"))
+				assert.Empty(t, w.Header().Get("Content-Encoding"), "Images are not compressed")
+				assert.Contains(t, w.Header().Get("Cache-Control"), "immutable")
+				assert.Equal(t, data, w.Body.Bytes())
+			}
+		})
+	}
+}
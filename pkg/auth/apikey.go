@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyAuthenticator authenticates requests carrying one of the statically configured API keys,
+// either in the X-API-Key header or as "Authorization: ApiKey <key>".
+type APIKeyAuthenticator struct {
+	keys map[string]int
+}
+
+func NewAPIKeyAuthenticator(keys map[string]int) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{
+		keys: keys,
+	}
+}
+
+// ParseAPIKeys parses "key:userID" pairs as given on the command line.
+func ParseAPIKeys(pairs []string) (map[string]int, error) {
+	keys := make(map[string]int, len(pairs))
+	for _, pair := range pairs {
+		key, userID, found := strings.Cut(pair, ":")
+		if !found || key == "" {
+			return nil, fmt.Errorf("api key %q is not in key:userID format", pair)
+		}
+		id, err := strconv.Atoi(userID)
+		if err != nil {
+			return nil, fmt.Errorf("api key %q has invalid user id: %w", key, err)
+		}
+		keys[key] = id
+	}
+	return keys, nil
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	key := APIKey(r)
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	// compare against every key so the response time does not leak which prefix matched
+	userID, found := 0, false
+	for k, id := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			userID, found = id, true
+		}
+	}
+	if !found {
+		return nil, ErrInvalidCredentials
+	}
+	return &Identity{UserID: userID, Method: MethodAPIKey}, nil
+}
+
+// APIKey returns the API key sent with the request, if any.
+func APIKey(r *http.Request) string {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return key
+	}
+	scheme, credentials, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if strings.EqualFold(scheme, "ApiKey") {
+		return strings.TrimSpace(credentials)
+	}
+	return ""
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChain_Authenticate(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err, "Error generating rsa key")
+	otherRSAKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err, "Error generating rsa key")
+
+	keySet, err := ParseJWKS([]byte(fmt.Sprintf(`{"keys":[
+		{"kty":"oct","kid":"hs","k":"%s"},
+		{"kty":"RSA","kid":"rs","n":"%s","e":"%s"}]}`,
+		base64.RawURLEncoding.EncodeToString(secret),
+		base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()))))
+	require.Nil(t, err, "Error parsing jwks")
+
+	authenticator := Chain{
+		NewAPIKeyAuthenticator(map[string]int{"secret-key": 3}),
+		NewJWTAuthenticator(keySet, JWTOptions{Issuer: "rentals"}),
+	}
+
+	sign := func(method jwt.SigningMethod, kid string, key interface{}, claims jwt.RegisteredClaims) string {
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		require.Nil(t, err, "Error signing token")
+		return signed
+	}
+	validClaims := jwt.RegisteredClaims{
+		Subject:   "2",
+		Issuer:    "rentals",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+	expiredClaims := validClaims
+	expiredClaims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+
+	tests := map[string]struct {
+		header         string
+		value          string
+		expectedUserID int
+		expectedError  error
+	}{
+		"No credentials": {
+			expectedError: ErrNoCredentials,
+		},
+		"API key header": {
+			header:         APIKeyHeader,
+			value:          "secret-key",
+			expectedUserID: 3,
+		},
+		"API key authorization": {
+			header:         "Authorization",
+			value:          "ApiKey secret-key",
+			expectedUserID: 3,
+		},
+		"Unknown API key": {
+			header:        APIKeyHeader,
+			value:         "other-key",
+			expectedError: ErrInvalidCredentials,
+		},
+		"HS256 token": {
+			header:         "Authorization",
+			value:          "Bearer " + sign(jwt.SigningMethodHS256, "hs", secret, validClaims),
+			expectedUserID: 2,
+		},
+		"RS256 token": {
+			header:         "Authorization",
+			value:          "Bearer " + sign(jwt.SigningMethodRS256, "rs", rsaKey, validClaims),
+			expectedUserID: 2,
+		},
+		"RS256 token signed by unknown key": {
+			header:        "Authorization",
+			value:         "Bearer " + sign(jwt.SigningMethodRS256, "rs", otherRSAKey, validClaims),
+			expectedError: ErrInvalidCredentials,
+		},
+		"Expired token": {
+			header:        "Authorization",
+			value:         "Bearer " + sign(jwt.SigningMethodHS256, "hs", secret, expiredClaims),
+			expectedError: ErrInvalidCredentials,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/v1/rentals", nil)
+			if test.header != "" {
+				r.Header.Set(test.header, test.value)
+			}
+			identity, err := authenticator.Authenticate(r)
+			if test.expectedError != nil {
+				assert.ErrorIs(t, err, test.expectedError)
+				return
+			}
+			require.Nil(t, err, "Error authenticating request")
+			assert.Equal(t, test.expectedUserID, identity.UserID)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// KeySet holds the verification keys loaded from a JWKS document (RFC 7517).
+// Only "RSA" keys (RS256) and "oct" keys (HS256) are supported.
+type KeySet struct {
+	rsaKeys  map[string]*rsa.PublicKey
+	hmacKeys map[string][]byte
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	K   string `json:"k"`
+}
+
+// LoadJWKS reads a JWKS document from a local file.
+func LoadJWKS(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading jwks file: %w", err)
+	}
+	return ParseJWKS(data)
+}
+
+func ParseJWKS(data []byte) (*KeySet, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing jwks: %w", err)
+	}
+
+	ks := &KeySet{
+		rsaKeys:  make(map[string]*rsa.PublicKey),
+		hmacKeys: make(map[string][]byte),
+	}
+	for _, key := range doc.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		switch key.Kty {
+		case "RSA":
+			pub, err := parseRSAKey(key)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing rsa key %q: %w", key.Kid, err)
+			}
+			ks.rsaKeys[key.Kid] = pub
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(key.K)
+			if err != nil || len(secret) == 0 {
+				return nil, fmt.Errorf("error parsing oct key %q: invalid k", key.Kid)
+			}
+			ks.hmacKeys[key.Kid] = secret
+		default:
+			return nil, fmt.Errorf("unsupported key type %q for key %q", key.Kty, key.Kid)
+		}
+	}
+	if len(ks.rsaKeys) == 0 && len(ks.hmacKeys) == 0 {
+		return nil, fmt.Errorf("jwks contains no signing keys")
+	}
+	return ks, nil
+}
+
+func parseRSAKey(key jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(key.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(key.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() < 3 {
+		return nil, fmt.Errorf("invalid exponent")
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(exponent.Int64()),
+	}, nil
+}
+
+// RSAKey returns the RSA key with the given kid. An empty kid matches when the set holds exactly one RSA key.
+func (ks *KeySet) RSAKey(kid string) (*rsa.PublicKey, bool) {
+	return lookup(ks.rsaKeys, kid)
+}
+
+// HMACKey returns the shared secret with the given kid. An empty kid matches when the set holds exactly one secret.
+func (ks *KeySet) HMACKey(kid string) ([]byte, bool) {
+	return lookup(ks.hmacKeys, kid)
+}
+
+func lookup[K any](keys map[string]K, kid string) (K, bool) {
+	if key, ok := keys[kid]; ok {
+		return key, true
+	}
+	var zero K
+	if kid != "" || len(keys) != 1 {
+		return zero, false
+	}
+	for _, key := range keys {
+		return key, true
+	}
+	return zero, false
+}
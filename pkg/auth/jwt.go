@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type JWTOptions struct {
+	Issuer   string
+	Audience string
+}
+
+// JWTAuthenticator verifies HS256 and RS256 bearer tokens against a KeySet.
+// The subject claim must hold the users.id of the caller.
+type JWTAuthenticator struct {
+	keys   *KeySet
+	parser *jwt.Parser
+}
+
+func NewJWTAuthenticator(keys *KeySet, opts JWTOptions) *JWTAuthenticator {
+	parserOpts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if opts.Issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(opts.Issuer))
+	}
+	if opts.Audience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(opts.Audience))
+	}
+
+	return &JWTAuthenticator{
+		keys:   keys,
+		parser: jwt.NewParser(parserOpts...),
+	}
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return nil, ErrNoCredentials
+	}
+
+	claims := jwt.RegisteredClaims{}
+	_, err := a.parser.ParseWithClaims(strings.TrimSpace(token), &claims, a.keyFunc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: subject is not a user id", ErrInvalidCredentials)
+	}
+	return &Identity{UserID: userID, Method: MethodJWT}, nil
+}
+
+func (a *JWTAuthenticator) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		if key, ok := a.keys.HMACKey(kid); ok {
+			return key, nil
+		}
+	case jwt.SigningMethodRS256.Alg():
+		if key, ok := a.keys.RSAKey(kid); ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("no %s key found for kid %q", token.Method.Alg(), kid)
+}
@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewLRU[int, string](2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set(1, "one")
+	c.Set(2, "two")
+	value, ok := c.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, "one", value)
+
+	c.Set(3, "three")
+	_, ok = c.Get(2)
+	assert.False(t, ok, "Least recently used entry is evicted")
+	_, ok = c.Get(1)
+	assert.True(t, ok)
+
+	c.Delete(1)
+	_, ok = c.Get(1)
+	assert.False(t, ok, "Deleted entry is gone")
+
+	now = now.Add(2 * time.Minute)
+	_, ok = c.Get(3)
+	assert.False(t, ok, "Expired entry is gone")
+
+	c.Set(4, "four")
+	c.Purge()
+	_, ok = c.Get(4)
+	assert.False(t, ok, "Purged entry is gone")
+
+	assert.Equal(t, Stats{Hits: 2, Misses: 4, Evictions: 1, Size: 0}, c.Stats())
+}
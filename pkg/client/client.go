@@ -0,0 +1,249 @@
+// Package client is a Go client of the rentals API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"
+
+	defaultMinBackoff = 100 * time.Millisecond
+	defaultMaxBackoff = 5 * time.Second
+)
+
+type Options struct {
+	// HTTPClient sends the requests, http.DefaultClient is used when nil.
+	HTTPClient *http.Client
+	// APIKey or BearerToken authenticate the write requests.
+	APIKey      string
+	BearerToken string
+	// MaxRetries is how often a request is repeated after a network error, 429, 502, 503 or 504.
+	// Creating a rental is only repeated after 429. Zero disables retries.
+	MaxRetries int
+	// MinBackoff is the wait before the first retry, doubled on every further retry up to MaxBackoff.
+	// A Retry-After header of the response takes precedence.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	opts       Options
+}
+
+// New creates a client of the API served at baseURL, e.g. http://localhost:59191.
+func New(baseURL string, opts Options) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme and host are required", baseURL)
+	}
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = defaultMinBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = defaultMaxBackoff
+	}
+	return &Client{baseURL: u, httpClient: httpClient, opts: opts}, nil
+}
+
+func (c *Client) GetRental(ctx context.Context, rentalID int) (*apiv1.Rental, error) {
+	var rental apiv1.Rental
+	err := c.do(ctx, http.MethodGet, rentalPath(rentalID), nil, nil, &rental)
+	if err != nil {
+		return nil, err
+	}
+	return &rental, nil
+}
+
+// ListRentals returns one page of rentals, use Rentals to iterate over all of them.
+func (c *Client) ListRentals(ctx context.Context, filter *Filter) ([]apiv1.Rental, error) {
+	var rentals []apiv1.Rental
+	err := c.do(ctx, http.MethodGet, "/v1/rentals", filter.Values(), nil, &rentals)
+	if err != nil {
+		return nil, err
+	}
+	return rentals, nil
+}
+
+// BatchGetRentals returns one result per id in the given order, with apiv1.ErrCodeNotFound as error
+// of the ids without a rental.
+func (c *Client) BatchGetRentals(ctx context.Context, rentalIDs ...int) ([]apiv1.BatchGetResult, error) {
+	var resp apiv1.BatchGetResponse
+	err := c.do(ctx, http.MethodPost, "/v1/rentals:batchGet", nil, apiv1.BatchGetRequest{IDs: rentalIDs}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+func (c *Client) CreateRental(ctx context.Context, input apiv1.RentalInput) (*apiv1.Rental, error) {
+	var rental apiv1.Rental
+	err := c.do(ctx, http.MethodPost, "/v1/rentals", nil, input, &rental)
+	if err != nil {
+		return nil, err
+	}
+	return &rental, nil
+}
+
+func (c *Client) UpdateRental(ctx context.Context, rentalID int, input apiv1.RentalInput) (*apiv1.Rental, error) {
+	var rental apiv1.Rental
+	err := c.do(ctx, http.MethodPut, rentalPath(rentalID), nil, input, &rental)
+	if err != nil {
+		return nil, err
+	}
+	return &rental, nil
+}
+
+func (c *Client) DeleteRental(ctx context.Context, rentalID int) error {
+	return c.do(ctx, http.MethodDelete, rentalPath(rentalID), nil, nil, nil)
+}
+
+func rentalPath(rentalID int) string {
+	return "/v1/rentals/" + strconv.Itoa(rentalID)
+}
+
+// do sends the request, retrying it as configured, and decodes the JSON response into out.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out any) error {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("error encoding request: %w", err)
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, path, query, body)
+		retryable := method != http.MethodPost
+		var retryAfter time.Duration
+		if err == nil {
+			if resp.StatusCode < http.StatusBadRequest {
+				return decodeResponse(resp, out)
+			}
+			err = decodeProblem(resp)
+			retryable = resp.StatusCode == http.StatusTooManyRequests ||
+				(retryable && retryableStatus(resp.StatusCode))
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		} else if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if !retryable || attempt >= c.opts.MaxRetries {
+			return err
+		}
+		wait := c.backoff(attempt)
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body []byte) (*http.Response, error) {
+	u := c.baseURL.JoinPath(path)
+	u.RawQuery = query.Encode()
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.opts.APIKey != "" {
+		req.Header.Set(apiKeyHeader, c.opts.APIKey)
+	}
+	if c.opts.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.opts.BearerToken)
+	}
+	return c.httpClient.Do(req)
+}
+
+func decodeResponse(resp *http.Response, out any) error {
+	defer resp.Body.Close()
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	return nil
+}
+
+// decodeProblem reads an error response, falling back to the status when the body is no Problem document.
+func decodeProblem(resp *http.Response) *APIError {
+	defer resp.Body.Close()
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	if err := json.NewDecoder(resp.Body).Decode(&apiErr.Problem); err != nil || apiErr.Problem.Code == "" {
+		apiErr.Problem = apiv1.Problem{
+			Title:  http.StatusText(resp.StatusCode),
+			Status: resp.StatusCode,
+			Code:   problemCode(resp.StatusCode),
+		}
+	}
+	return apiErr
+}
+
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff doubles MinBackoff on every attempt up to MaxBackoff, waiting a random time between half and all of it.
+func (c *Client) backoff(attempt int) time.Duration {
+	wait := c.opts.MaxBackoff
+	if attempt < 32 && c.opts.MinBackoff<<attempt < wait {
+		wait = c.opts.MinBackoff << attempt
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
@@ -0,0 +1,311 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+)
+
+func TestNew(t *testing.T) {
+	_, err := New("localhost:59191", Options{})
+	assert.NotNil(t, err, "Base URL without scheme accepted")
+	_, err = New("http://localhost:59191", Options{})
+	assert.Nil(t, err, "Error creating client")
+}
+
+func TestClient_GetRental(t *testing.T) {
+	httpServer := newTestServer()
+	defer httpServer.Close()
+	c, err := New(httpServer.URL, Options{})
+	require.Nil(t, err, "Error creating client")
+
+	rental, err := c.GetRental(context.Background(), 2)
+	require.Nil(t, err, "Error getting rental")
+	assert.Equal(t, "Rental 2", rental.Name)
+	assert.Equal(t, 2000, rental.Price.Day)
+	assert.Equal(t, 2, rental.User.ID)
+
+	_, err = c.GetRental(context.Background(), 30)
+	assert.True(t, errors.Is(err, ErrNotFound), "Expected not found error, got %v", err)
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr), "Expected APIError, got %T", err)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, apiv1.ErrCodeNotFound, apiErr.Problem.Code)
+	assert.Equal(t, "Rental not found", apiErr.Problem.Detail)
+	assert.NotEmpty(t, apiErr.Problem.RequestID)
+}
+
+func TestClient_ListRentals(t *testing.T) {
+	httpServer := newTestServer()
+	defer httpServer.Close()
+	c, err := New(httpServer.URL, Options{})
+	require.Nil(t, err, "Error creating client")
+
+	tests := map[string]struct {
+		filter        *Filter
+		expectedIDs   []int
+		expectedError error
+	}{
+		"All rentals": {
+			filter:      nil,
+			expectedIDs: []int{1, 2, 3, 4, 5},
+		},
+		"Rentals in price range": {
+			filter:      NewFilter().PriceMin(2000).PriceMax(4000),
+			expectedIDs: []int{3},
+		},
+		"Rentals in price range with bounds": {
+			filter:      NewFilter().PriceMin(1999).PriceMax(4001),
+			expectedIDs: []int{2, 3, 4},
+		},
+		"Rentals by ids": {
+			filter:      NewFilter().IDs(1, 5),
+			expectedIDs: []int{1, 5},
+		},
+		"Rentals page": {
+			filter:      NewFilter().Limit(2).Offset(1).Sort("price"),
+			expectedIDs: []int{2, 3},
+		},
+		"Rentals near": {
+			filter:      NewFilter().Near(33.64, -117.93),
+			expectedIDs: []int{1, 2, 3, 4, 5},
+		},
+		"Invalid sort": {
+			filter:        NewFilter().Sort("size"),
+			expectedError: ErrInvalidRequest,
+		},
+		"Currency without exchange rates": {
+			filter:        NewFilter().Currency("EUR"),
+			expectedError: ErrInvalidRequest,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rentals, err := c.ListRentals(context.Background(), test.filter)
+			if test.expectedError != nil {
+				assert.True(t, errors.Is(err, test.expectedError), "Expected %v, got %v", test.expectedError, err)
+				return
+			}
+			require.Nil(t, err, "Error listing rentals")
+			assert.Equal(t, test.expectedIDs, rentalIDs(rentals))
+		})
+	}
+}
+
+func TestClient_BatchGetRentals(t *testing.T) {
+	httpServer := newTestServer()
+	defer httpServer.Close()
+	c, err := New(httpServer.URL, Options{})
+	require.Nil(t, err, "Error creating client")
+
+	results, err := c.BatchGetRentals(context.Background(), 3, 30, 1)
+	require.Nil(t, err, "Error getting rentals")
+	require.Len(t, results, 3)
+	assert.Equal(t, "Rental 3", results[0].Rental.Name)
+	assert.Equal(t, 30, results[1].ID)
+	assert.Nil(t, results[1].Rental)
+	assert.Equal(t, apiv1.ErrCodeNotFound, results[1].Error)
+	assert.Equal(t, 1, results[2].Rental.ID)
+
+	_, err = c.BatchGetRentals(context.Background())
+	assert.True(t, errors.Is(err, ErrInvalidRequest), "Expected invalid request error, got %v", err)
+}
+
+func TestClient_ListRentalsProjection(t *testing.T) {
+	httpServer := newTestServer()
+	defer httpServer.Close()
+	c, err := New(httpServer.URL, Options{})
+	require.Nil(t, err, "Error creating client")
+
+	rentals, err := c.ListRentals(context.Background(), NewFilter().Fields("id", "price").IDs(3))
+	require.Nil(t, err, "Error listing rentals")
+	require.Len(t, rentals, 1)
+	assert.Equal(t, apiv1.Rental{ID: 3, Price: apiv1.Price{Day: 3000}}, rentals[0])
+
+	rentals, err = c.ListRentals(context.Background(), NewFilter().IncludeUser(false))
+	require.Nil(t, err, "Error listing rentals")
+	assert.Nil(t, rentals[0].User)
+}
+
+func TestClient_Rentals(t *testing.T) {
+	httpServer := newTestServer()
+	defer httpServer.Close()
+	c, err := New(httpServer.URL, Options{})
+	require.Nil(t, err, "Error creating client")
+
+	tests := map[string]struct {
+		filter      *Filter
+		expectedIDs []int
+	}{
+		"Default page size": {
+			filter:      nil,
+			expectedIDs: []int{1, 2, 3, 4, 5},
+		},
+		"Pages of two": {
+			filter:      NewFilter().Limit(2),
+			expectedIDs: []int{1, 2, 3, 4, 5},
+		},
+		"Pages of one from an offset": {
+			filter:      NewFilter().Limit(1).Offset(3),
+			expectedIDs: []int{4, 5},
+		},
+		"Full last page": {
+			filter:      NewFilter().Limit(5),
+			expectedIDs: []int{1, 2, 3, 4, 5},
+		},
+		"Empty list": {
+			filter:      NewFilter().PriceMin(10000),
+			expectedIDs: []int{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ids := []int{}
+			it := c.Rentals(context.Background(), test.filter)
+			for it.Next() {
+				ids = append(ids, it.Rental().ID)
+			}
+			require.Nil(t, it.Err(), "Error iterating rentals")
+			assert.Equal(t, test.expectedIDs, ids)
+		})
+	}
+}
+
+func TestClient_WriteRentals(t *testing.T) {
+	httpServer := newTestServer()
+	defer httpServer.Close()
+	anonymous, err := New(httpServer.URL, Options{})
+	require.Nil(t, err, "Error creating client")
+	c, err := New(httpServer.URL, Options{APIKey: "key-1"})
+	require.Nil(t, err, "Error creating client")
+	input := apiv1.RentalInput{Name: "Camper", Type: "camper-van", Price: apiv1.Price{Day: 9900}}
+
+	_, err = anonymous.CreateRental(context.Background(), input)
+	assert.True(t, errors.Is(err, ErrUnauthorized), "Expected unauthorized error, got %v", err)
+
+	rental, err := c.CreateRental(context.Background(), input)
+	require.Nil(t, err, "Error creating rental")
+	assert.Equal(t, 6, rental.ID)
+	assert.Equal(t, 1, rental.User.ID)
+
+	input.Name = "Renamed"
+	rental, err = c.UpdateRental(context.Background(), 1, input)
+	require.Nil(t, err, "Error updating rental")
+	assert.Equal(t, "Renamed", rental.Name)
+
+	_, err = c.UpdateRental(context.Background(), 2, input)
+	assert.True(t, errors.Is(err, ErrForbidden), "Expected forbidden error, got %v", err)
+
+	err = c.DeleteRental(context.Background(), 1)
+	require.Nil(t, err, "Error deleting rental")
+	_, err = c.GetRental(context.Background(), 1)
+	assert.True(t, errors.Is(err, ErrNotFound), "Expected not found error, got %v", err)
+}
+
+func TestClient_Retry(t *testing.T) {
+	tests := map[string]struct {
+		status           int
+		failures         int32
+		maxRetries       int
+		post             bool
+		expectedAttempts int32
+		expectedError    error
+	}{
+		"Retried until success": {
+			status:           http.StatusServiceUnavailable,
+			failures:         2,
+			maxRetries:       3,
+			expectedAttempts: 3,
+		},
+		"Retries exhausted": {
+			status:           http.StatusBadGateway,
+			failures:         5,
+			maxRetries:       2,
+			expectedAttempts: 3,
+			expectedError:    ErrInternal,
+		},
+		"Retries disabled": {
+			status:           http.StatusServiceUnavailable,
+			failures:         1,
+			expectedAttempts: 1,
+			expectedError:    ErrInternal,
+		},
+		"Internal errors are not retried": {
+			status:           http.StatusInternalServerError,
+			failures:         1,
+			maxRetries:       3,
+			expectedAttempts: 1,
+			expectedError:    ErrInternal,
+		},
+		"Create not retried after unavailable": {
+			status:           http.StatusServiceUnavailable,
+			failures:         1,
+			maxRetries:       3,
+			post:             true,
+			expectedAttempts: 1,
+			expectedError:    ErrInternal,
+		},
+		"Create retried after rate limit": {
+			status:           http.StatusTooManyRequests,
+			failures:         1,
+			maxRetries:       3,
+			post:             true,
+			expectedAttempts: 2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var attempts int32
+			handler := newTestHandler()
+			flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&attempts, 1) <= test.failures {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(test.status)
+					return
+				}
+				handler.ServeHTTP(w, r)
+			}))
+			defer flaky.Close()
+
+			c, err := New(flaky.URL, Options{
+				APIKey:     "key-1",
+				MaxRetries: test.maxRetries,
+				MinBackoff: time.Millisecond,
+				MaxBackoff: 5 * time.Millisecond,
+			})
+			require.Nil(t, err, "Error creating client")
+
+			if test.post {
+				_, err = c.CreateRental(context.Background(), apiv1.RentalInput{Name: "Camper", Type: "camper-van"})
+			} else {
+				_, err = c.GetRental(context.Background(), 1)
+			}
+			if test.expectedError != nil {
+				assert.True(t, errors.Is(err, test.expectedError), "Expected %v, got %v", test.expectedError, err)
+			} else {
+				assert.Nil(t, err, "Unexpected error")
+			}
+			assert.Equal(t, test.expectedAttempts, atomic.LoadInt32(&attempts))
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert.Equal(t, 3*time.Second, parseRetryAfter("3"))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(""))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon"))
+	inFuture := parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	assert.True(t, inFuture > 50*time.Second && inFuture <= time.Minute, "Unexpected wait %v", inFuture)
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+)
+
+// Errors matched with errors.Is against the errors returned by the Client, one per Problem code.
+var (
+	ErrInvalidRequest = errors.New("invalid request")
+	ErrUnauthorized   = errors.New("unauthorized")
+	ErrForbidden      = errors.New("forbidden")
+	ErrNotFound       = errors.New("not found")
+	ErrNotAcceptable  = errors.New("not acceptable")
+	ErrConflict       = errors.New("conflict")
+	ErrRateLimited    = errors.New("rate limited")
+	ErrTimeout        = errors.New("timeout")
+	ErrInternal       = errors.New("internal error")
+)
+
+var codeErrors = map[string]error{
+	apiv1.ErrCodeInvalidParameter: ErrInvalidRequest,
+	apiv1.ErrCodeInvalidBody:      ErrInvalidRequest,
+	apiv1.ErrCodeInvalidQuery:     ErrInvalidRequest,
+	apiv1.ErrCodeUnauthorized:     ErrUnauthorized,
+	apiv1.ErrCodeForbidden:        ErrForbidden,
+	apiv1.ErrCodeNotFound:         ErrNotFound,
+	apiv1.ErrCodeNotAcceptable:    ErrNotAcceptable,
+	apiv1.ErrCodeConflict:         ErrConflict,
+	apiv1.ErrCodeRateLimited:      ErrRateLimited,
+	apiv1.ErrCodeTimeout:          ErrTimeout,
+	apiv1.ErrCodeInternal:         ErrInternal,
+}
+
+// APIError is an error response of the API. Use errors.As to read the Problem document.
+type APIError struct {
+	StatusCode int
+	Problem    apiv1.Problem
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("rentals API: %d %s", e.StatusCode, e.Problem.Code)
+	if e.Problem.Detail != "" {
+		msg += ": " + e.Problem.Detail
+	}
+	return msg
+}
+
+// Unwrap returns the error of the Problem code, so errors.Is(err, ErrNotFound) works.
+func (e *APIError) Unwrap() error {
+	return codeErrors[e.Problem.Code]
+}
+
+// problemCode guesses the code of error responses without a Problem document, e.g. from a proxy.
+func problemCode(status int) string {
+	switch {
+	case status == http.StatusUnauthorized:
+		return apiv1.ErrCodeUnauthorized
+	case status == http.StatusForbidden:
+		return apiv1.ErrCodeForbidden
+	case status == http.StatusNotFound:
+		return apiv1.ErrCodeNotFound
+	case status == http.StatusNotAcceptable:
+		return apiv1.ErrCodeNotAcceptable
+	case status == http.StatusConflict:
+		return apiv1.ErrCodeConflict
+	case status == http.StatusTooManyRequests:
+		return apiv1.ErrCodeRateLimited
+	case status == http.StatusGatewayTimeout:
+		return apiv1.ErrCodeTimeout
+	case status >= http.StatusInternalServerError:
+		return apiv1.ErrCodeInternal
+	default:
+		return apiv1.ErrCodeInvalidParameter
+	}
+}
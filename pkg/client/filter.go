@@ -0,0 +1,112 @@
+package client
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Filter builds the query of a rentals list, mirroring database.RentalParams.
+// The zero value lists all rentals, methods can be chained:
+//
+//	client.NewFilter().PriceMax(20000).Near(33.64, -117.93).Sort("price")
+type Filter struct {
+	query url.Values
+}
+
+func NewFilter() *Filter {
+	return &Filter{query: url.Values{}}
+}
+
+func (f *Filter) set(name, value string) *Filter {
+	if f.query == nil {
+		f.query = url.Values{}
+	}
+	f.query.Set(name, value)
+	return f
+}
+
+// PriceMin keeps rentals with a day price greater than price, in cents. The bound is exclusive,
+// pass price-1 to include it.
+func (f *Filter) PriceMin(price int) *Filter {
+	return f.set("price_min", strconv.Itoa(price))
+}
+
+// PriceMax keeps rentals with a day price less than price, in cents. The bound is exclusive, pass
+// price+1 to include it.
+func (f *Filter) PriceMax(price int) *Filter {
+	return f.set("price_max", strconv.Itoa(price))
+}
+
+// PriceUnit sets the charge PriceMin and PriceMax apply to, one of the keys of apiv1.PriceUnitsMap.
+func (f *Filter) PriceUnit(unit string) *Filter {
+	return f.set("price_unit", unit)
+}
+
+// Currency returns prices in the currency with the ISO 4217 code, and interprets PriceMin, PriceMax
+// and the price sort in it.
+func (f *Filter) Currency(code string) *Filter {
+	return f.set("currency", code)
+}
+
+func (f *Filter) IDs(ids ...int) *Filter {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = strconv.Itoa(id)
+	}
+	return f.set("ids", strings.Join(values, ","))
+}
+
+// Near keeps rentals within 100 miles of the point.
+func (f *Filter) Near(lat, lng float64) *Filter {
+	return f.set("near", strconv.FormatFloat(lat, 'f', -1, 64)+","+strconv.FormatFloat(lng, 'f', -1, 64))
+}
+
+// Amenities keeps rentals offering every one of the amenity codes listed by GET /v1/amenities.
+func (f *Filter) Amenities(codes ...string) *Filter {
+	return f.set("amenities", strings.Join(codes, ","))
+}
+
+// RatingMin keeps rentals with an average rating of at least rating, from 1 to 5.
+func (f *Filter) RatingMin(rating float64) *Filter {
+	return f.set("rating_min", strconv.FormatFloat(rating, 'f', -1, 64))
+}
+
+// Sort orders rentals by one of the keys of apiv1.SortsMap.
+func (f *Filter) Sort(by string) *Filter {
+	return f.set("sort", by)
+}
+
+// Limit caps the number of rentals. The iterator of Client.Rentals uses it as page size.
+func (f *Filter) Limit(limit int) *Filter {
+	return f.set("limit", strconv.Itoa(limit))
+}
+
+func (f *Filter) Offset(offset int) *Filter {
+	return f.set("offset", strconv.Itoa(offset))
+}
+
+// Fields returns only the given fields, the others are left empty in the decoded rentals.
+func (f *Filter) Fields(fields ...string) *Filter {
+	return f.set("fields", strings.Join(fields, ","))
+}
+
+// IncludeUser sets whether the owner of each rental is returned.
+func (f *Filter) IncludeUser(include bool) *Filter {
+	if include {
+		return f.set("include", "user")
+	}
+	return f.set("include", "")
+}
+
+// Values returns a copy of the query parameters.
+func (f *Filter) Values() url.Values {
+	values := url.Values{}
+	if f == nil {
+		return values
+	}
+	for name, v := range f.query {
+		values[name] = append([]string(nil), v...)
+	}
+	return values
+}
@@ -0,0 +1,141 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/internal/web"
+	"github.com/mkermilska/rentals-challenge/pkg/auth"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+	"github.com/mkermilska/rentals-challenge/pkg/service"
+)
+
+// fakeRentalService keeps rentals 1 to 5 in memory, rental N costs N*1000 per day and is owned by user N.
+type fakeRentalService struct {
+	rentals map[int]apiv1.Rental
+}
+
+func newFakeRentalService() *fakeRentalService {
+	svc := &fakeRentalService{rentals: map[int]apiv1.Rental{}}
+	for id := 1; id <= 5; id++ {
+		svc.rentals[id] = apiv1.Rental{
+			ID:    id,
+			Name:  "Rental " + strconv.Itoa(id),
+			Type:  "camper-van",
+			Price: apiv1.Price{Day: id * 1000},
+			User:  &apiv1.User{ID: id, FirstName: "John", LastName: "Smith"},
+		}
+	}
+	return svc
+}
+
+func (s *fakeRentalService) GetRentalByID(_ context.Context, rentalID int) (*apiv1.Rental, error) {
+	rental, ok := s.rentals[rentalID]
+	if !ok {
+		return nil, database.ErrNotFound
+	}
+	return &rental, nil
+}
+
+func (s *fakeRentalService) GetRentals(_ context.Context, params database.RentalParams) ([]apiv1.Rental, error) {
+	rentals := []apiv1.Rental{}
+	for _, rental := range s.rentals {
+		if params.PriceMin > 0 && rental.Price.Day <= params.PriceMin ||
+			params.PriceMax > 0 && rental.Price.Day >= params.PriceMax {
+			continue
+		}
+		if params.IDs != nil && !contains(params.IDs, rental.ID) {
+			continue
+		}
+		if !params.IncludeUser {
+			rental.User = nil
+		}
+		rentals = append(rentals, rental)
+	}
+	sort.Slice(rentals, func(i, j int) bool { return rentals[i].ID < rentals[j].ID })
+
+	if params.Offset >= len(rentals) {
+		return []apiv1.Rental{}, nil
+	}
+	rentals = rentals[params.Offset:]
+	if params.Limit > 0 && params.Limit < len(rentals) {
+		rentals = rentals[:params.Limit]
+	}
+	return rentals, nil
+}
+
+func contains(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *fakeRentalService) CreateRental(_ context.Context, ownerID int, input apiv1.RentalInput) (*apiv1.Rental, error) {
+	rental := apiv1.Rental{
+		ID:    len(s.rentals) + 1,
+		Name:  input.Name,
+		Type:  input.Type,
+		Price: input.Price,
+		User:  &apiv1.User{ID: ownerID},
+	}
+	s.rentals[rental.ID] = rental
+	return &rental, nil
+}
+
+func (s *fakeRentalService) UpdateRental(ctx context.Context, ownerID, rentalID int, input apiv1.RentalInput) (*apiv1.Rental, error) {
+	rental, err := s.GetRentalByID(ctx, rentalID)
+	if err != nil {
+		return nil, err
+	}
+	if rental.User.ID != ownerID {
+		return nil, service.ErrForbidden
+	}
+	rental.Name, rental.Type, rental.Price = input.Name, input.Type, input.Price
+	s.rentals[rentalID] = *rental
+	return rental, nil
+}
+
+func (s *fakeRentalService) DeleteRental(ctx context.Context, ownerID, rentalID int) error {
+	rental, err := s.GetRentalByID(ctx, rentalID)
+	if err != nil {
+		return err
+	}
+	if rental.User.ID != ownerID {
+		return service.ErrForbidden
+	}
+	delete(s.rentals, rentalID)
+	return nil
+}
+
+func (s *fakeRentalService) CacheStats() *service.CacheStats {
+	return nil
+}
+
+// newTestHandler serves the API over the in-memory rentals, the API key "key-1" belongs to user 1.
+func newTestHandler() http.Handler {
+	server := web.New(web.Options{
+		Authenticator: auth.NewAPIKeyAuthenticator(map[string]int{"key-1": 1}),
+	}, newFakeRentalService(), zap.NewNop())
+	return server.Handler()
+}
+
+func newTestServer() *httptest.Server {
+	return httptest.NewServer(newTestHandler())
+}
+
+func rentalIDs(rentals []apiv1.Rental) []int {
+	ids := []int{}
+	for _, rental := range rentals {
+		ids = append(ids, rental.ID)
+	}
+	return ids
+}
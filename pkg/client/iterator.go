@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"strconv"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+)
+
+// DefaultPageSize is the page size of Rentals when the filter has no limit.
+const DefaultPageSize = 100
+
+// RentalIterator pages through a rentals list with limit and offset:
+//
+//	it := c.Rentals(ctx, filter)
+//	for it.Next() {
+//		rental := it.Rental()
+//	}
+//	err := it.Err()
+type RentalIterator struct {
+	ctx    context.Context
+	client *Client
+	filter *Filter
+	limit  int
+	offset int
+
+	page []apiv1.Rental
+	pos  int
+	done bool
+	err  error
+}
+
+// Rentals iterates over all rentals matching the filter, starting at its offset.
+// The limit of the filter is the page size.
+func (c *Client) Rentals(ctx context.Context, filter *Filter) *RentalIterator {
+	query := filter.Values()
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = DefaultPageSize
+	}
+	offset, _ := strconv.Atoi(query.Get("offset"))
+	return &RentalIterator{
+		ctx:    ctx,
+		client: c,
+		filter: &Filter{query: query},
+		limit:  limit,
+		offset: offset,
+	}
+}
+
+// Next advances to the next rental, loading the next page when needed.
+// It returns false at the end of the list or after an error.
+func (it *RentalIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.pos+1 < len(it.page) {
+		it.pos++
+		return true
+	}
+	if it.done {
+		return false
+	}
+
+	it.filter.Limit(it.limit).Offset(it.offset)
+	page, err := it.client.ListRentals(it.ctx, it.filter)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.page, it.pos = page, 0
+	it.offset += len(page)
+	it.done = len(page) < it.limit
+	return len(page) > 0
+}
+
+// Rental returns the current rental, valid after Next returned true.
+func (it *RentalIterator) Rental() apiv1.Rental {
+	return it.page[it.pos]
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *RentalIterator) Err() error {
+	return it.err
+}
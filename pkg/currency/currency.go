@@ -0,0 +1,156 @@
+// Package currency converts prices between currencies with exchange rates to a base currency.
+package currency
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"strconv"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+)
+
+// Base is the currency every exchange rate is relative to.
+const Base = apiv1.DefaultCurrency
+
+var (
+	// ErrUnsupported is returned for currencies without an exchange rate.
+	ErrUnsupported = errors.New("unsupported currency")
+	// ErrInvalidRate is returned by Validate.
+	ErrInvalidRate = errors.New("invalid exchange rate")
+)
+
+// ValidCode reports whether code looks like an ISO 4217 code, three upper case letters.
+func ValidCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Rates holds the amount of each currency worth one unit of Base.
+type Rates map[string]float64
+
+func NewRates(exchangeRates []apiv1.ExchangeRate) Rates {
+	rates := Rates{Base: 1}
+	for _, exchangeRate := range exchangeRates {
+		rates[exchangeRate.Currency] = exchangeRate.Rate
+	}
+	return rates
+}
+
+// Has reports whether amounts can be converted from and to code.
+func (r Rates) Has(code string) bool {
+	_, ok := r[code]
+	return ok
+}
+
+// Convert converts an amount in cents with exact decimal arithmetic, rounding half away from zero
+// like round(numeric) in Postgres, so converted prices agree with the price filters of the database.
+func (r Rates) Convert(amount int, from, to string) (int, error) {
+	fromRate, ok := r[from]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnsupported, from)
+	}
+	toRate, ok := r[to]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnsupported, to)
+	}
+	if from == to {
+		return amount, nil
+	}
+	converted := new(big.Rat).SetInt64(int64(amount))
+	converted.Mul(converted, decimal(toRate))
+	converted.Quo(converted, decimal(fromRate))
+	return roundHalfAway(converted), nil
+}
+
+// decimal returns the shortest decimal that reads back as rate, the numeric stored in the database.
+func decimal(rate float64) *big.Rat {
+	value, _ := new(big.Rat).SetString(strconv.FormatFloat(rate, 'f', -1, 64))
+	return value
+}
+
+func roundHalfAway(value *big.Rat) int {
+	quotient, remainder := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+	// the remainder has the sign of value, the denominator is positive
+	if remainder.Abs(remainder).Lsh(remainder, 1).Cmp(value.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(value.Sign())))
+	}
+	return int(quotient.Int64())
+}
+
+// ConvertPrice returns price in the to currency. A price without a currency is in Base, a price in a
+// currency without a rate is returned unchanged.
+func (r Rates) ConvertPrice(price apiv1.Price, to string) apiv1.Price {
+	from := price.Currency
+	if from == "" {
+		from = Base
+	}
+	if !r.Has(from) || !r.Has(to) {
+		return price
+	}
+	convert := func(amount int) int {
+		converted, _ := r.Convert(amount, from, to)
+		return converted
+	}
+	convertOptional := func(amount *int) *int {
+		if amount == nil {
+			return nil
+		}
+		converted := convert(*amount)
+		return &converted
+	}
+	return apiv1.Price{
+		Day:             convert(price.Day),
+		Week:            convertOptional(price.Week),
+		Month:           convertOptional(price.Month),
+		SecurityDeposit: convertOptional(price.SecurityDeposit),
+		CleaningFee:     convertOptional(price.CleaningFee),
+		PerMile:         convertOptional(price.PerMile),
+		Currency:        to,
+	}
+}
+
+// Validate checks the codes and that every rate is positive. The rate of Base, when given, must be 1.
+func Validate(exchangeRates []apiv1.ExchangeRate) error {
+	seen := make(map[string]bool, len(exchangeRates))
+	for _, exchangeRate := range exchangeRates {
+		switch {
+		case !ValidCode(exchangeRate.Currency):
+			return fmt.Errorf("%w: %q is not a three letter currency code", ErrInvalidRate, exchangeRate.Currency)
+		case seen[exchangeRate.Currency]:
+			return fmt.Errorf("%w: %s is listed twice", ErrInvalidRate, exchangeRate.Currency)
+		case exchangeRate.Rate <= 0 || math.IsInf(exchangeRate.Rate, 0):
+			return fmt.Errorf("%w: the rate of %s must be positive", ErrInvalidRate, exchangeRate.Currency)
+		case exchangeRate.Currency == Base && exchangeRate.Rate != 1:
+			return fmt.Errorf("%w: the rate of the base currency %s must be 1", ErrInvalidRate, Base)
+		}
+		seen[exchangeRate.Currency] = true
+	}
+	return nil
+}
+
+// LoadFile reads exchange rates from a JSON file holding an array of {"currency", "rate"} objects.
+func LoadFile(path string) ([]apiv1.ExchangeRate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading exchange rates file: %w", err)
+	}
+	var exchangeRates []apiv1.ExchangeRate
+	if err := json.Unmarshal(data, &exchangeRates); err != nil {
+		return nil, fmt.Errorf("error parsing exchange rates file: %w", err)
+	}
+	if err := Validate(exchangeRates); err != nil {
+		return nil, err
+	}
+	return exchangeRates, nil
+}
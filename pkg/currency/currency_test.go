@@ -0,0 +1,126 @@
+package currency
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+)
+
+func testRates() Rates {
+	return NewRates([]apiv1.ExchangeRate{{Currency: "CAD", Rate: 1.35}, {Currency: "EUR", Rate: 0.92}, {Currency: "CHF", Rate: 0.7}})
+}
+
+func TestRates_Convert(t *testing.T) {
+	tests := map[string]struct {
+		amount         int
+		from           string
+		to             string
+		expectedAmount int
+		expectedError  error
+	}{
+		"Same currency": {
+			amount: 10000, from: "EUR", to: "EUR", expectedAmount: 10000,
+		},
+		"From the base currency": {
+			amount: 10000, from: "USD", to: "CAD", expectedAmount: 13500,
+		},
+		"To the base currency": {
+			amount: 13500, from: "CAD", to: "USD", expectedAmount: 10000,
+		},
+		"Between two currencies": {
+			amount: 10000, from: "CAD", to: "EUR", expectedAmount: 6815,
+		},
+		"Rounds half away from zero": {
+			amount: 10, from: "USD", to: "CAD", expectedAmount: 14,
+		},
+		"Rounds the exact half cent up": {
+			// 45 * 0.7 is 31.499999999999996 in floating point
+			amount: 45, from: "USD", to: "CHF", expectedAmount: 32,
+		},
+		"Rounds the exact half cent away from zero": {
+			amount: -45, from: "USD", to: "CHF", expectedAmount: -32,
+		},
+		"Unsupported target": {
+			amount: 10000, from: "USD", to: "GBP", expectedError: ErrUnsupported,
+		},
+		"Unsupported source": {
+			amount: 10000, from: "GBP", to: "USD", expectedError: ErrUnsupported,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			amount, err := testRates().Convert(test.amount, test.from, test.to)
+			if test.expectedError != nil {
+				assert.ErrorIs(t, err, test.expectedError)
+				return
+			}
+			require.Nil(t, err, "Error converting amount")
+			assert.Equal(t, test.expectedAmount, amount)
+		})
+	}
+}
+
+func TestRates_ConvertPrice(t *testing.T) {
+	rates := testRates()
+	assert.Equal(t, apiv1.Price{Day: 9200, Currency: "EUR"}, rates.ConvertPrice(apiv1.Price{Day: 10000, Currency: "USD"}, "EUR"))
+	assert.Equal(t, apiv1.Price{Day: 13500, Currency: "CAD"}, rates.ConvertPrice(apiv1.Price{Day: 10000}, "CAD"))
+	assert.Equal(t, apiv1.Price{Day: 10000, Currency: "GBP"}, rates.ConvertPrice(apiv1.Price{Day: 10000, Currency: "GBP"}, "EUR"),
+		"Prices in a currency without a rate are not converted")
+
+	week, deposit, convertedWeek, convertedDeposit := 60000, 50000, 55200, 46000
+	assert.Equal(t, apiv1.Price{Day: 9200, Week: &convertedWeek, SecurityDeposit: &convertedDeposit, Currency: "EUR"},
+		rates.ConvertPrice(apiv1.Price{Day: 10000, Week: &week, SecurityDeposit: &deposit, Currency: "USD"}, "EUR"),
+		"Charges are converted, missing ones stay missing")
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		rates         []apiv1.ExchangeRate
+		expectedError error
+	}{
+		"Valid rates": {
+			rates: []apiv1.ExchangeRate{{Currency: "USD", Rate: 1}, {Currency: "CAD", Rate: 1.35}},
+		},
+		"Lower case code": {
+			rates:         []apiv1.ExchangeRate{{Currency: "cad", Rate: 1.35}},
+			expectedError: ErrInvalidRate,
+		},
+		"Duplicate code": {
+			rates:         []apiv1.ExchangeRate{{Currency: "CAD", Rate: 1.35}, {Currency: "CAD", Rate: 1.36}},
+			expectedError: ErrInvalidRate,
+		},
+		"Zero rate": {
+			rates:         []apiv1.ExchangeRate{{Currency: "EUR"}},
+			expectedError: ErrInvalidRate,
+		},
+		"Base rate other than 1": {
+			rates:         []apiv1.ExchangeRate{{Currency: "USD", Rate: 1.1}},
+			expectedError: ErrInvalidRate,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, Validate(test.rates), test.expectedError)
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	require.Nil(t, os.WriteFile(path, []byte(`[{"currency": "CAD", "rate": 1.35}, {"currency": "EUR", "rate": 0.92}]`), 0o600))
+
+	rates, err := LoadFile(path)
+	require.Nil(t, err, "Error loading exchange rates")
+	assert.Equal(t, []apiv1.ExchangeRate{{Currency: "CAD", Rate: 1.35}, {Currency: "EUR", Rate: 0.92}}, rates)
+
+	require.Nil(t, os.WriteFile(path, []byte(`[{"currency": "CAD", "rate": -1}]`), 0o600))
+	_, err = LoadFile(path)
+	assert.ErrorIs(t, err, ErrInvalidRate)
+}
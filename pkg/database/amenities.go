@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// Amenity is an entry of the catalogue of amenities rentals can offer.
+type Amenity struct {
+	Code     string `db:"code"`
+	Name     string `db:"name"`
+	Category string `db:"category"`
+}
+
+// FindAmenities returns the catalogue ordered by code.
+func (rr *RentalsRepository) FindAmenities(ctx context.Context) ([]Amenity, error) {
+	rr.logger.Debug("Getting amenities")
+	amenities := make([]Amenity, 0)
+	err := rr.db.SelectContext(ctx, &amenities, `SELECT * FROM amenities ORDER BY code`)
+	if err != nil {
+		return nil, errors.Wrap(translateError(err), "error getting amenities")
+	}
+	return amenities, nil
+}
+
+// findAmenities returns the amenity codes of the rentals by rental id, ordered by code.
+func findAmenities(ctx context.Context, q sqlx.QueryerContext, rentalIDs ...int) (map[int][]string, error) {
+	rows := make([]struct {
+		RentalID int    `db:"rental_id"`
+		Code     string `db:"amenity_code"`
+	}, 0)
+	err := sqlx.SelectContext(ctx, q, &rows,
+		`SELECT rental_id, amenity_code FROM rental_amenities WHERE rental_id = ANY ($1)
+		ORDER BY rental_id, amenity_code`, pq.Array(rentalIDs))
+	if err != nil {
+		return nil, errors.Wrap(translateError(err), "error getting rental amenities")
+	}
+	amenities := make(map[int][]string, len(rentalIDs))
+	for _, row := range rows {
+		amenities[row.RentalID] = append(amenities[row.RentalID], row.Code)
+	}
+	return amenities, nil
+}
+
+// loadAmenities sets the amenities of every rental with one query.
+func loadAmenities(ctx context.Context, q sqlx.QueryerContext, rentals []Rental) error {
+	if len(rentals) == 0 {
+		return nil
+	}
+	rentalIDs := make([]int, len(rentals))
+	for i, rental := range rentals {
+		rentalIDs[i] = rental.ID
+	}
+	amenities, err := findAmenities(ctx, q, rentalIDs...)
+	if err != nil {
+		return err
+	}
+	for i := range rentals {
+		rentals[i].Amenities = amenities[rentals[i].ID]
+	}
+	return nil
+}
+
+// setAmenities replaces the amenities of the rental with codes. Nil codes keep the amenities as
+// they are, an empty slice removes all of them. Codes missing from the catalogue give ErrConflict.
+func setAmenities(ctx context.Context, tx *sqlx.Tx, rentalID int, codes []string) error {
+	if codes == nil {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx,
+		`DELETE FROM rental_amenities WHERE rental_id = $1 AND NOT (amenity_code = ANY ($2))`,
+		rentalID, pq.Array(codes))
+	if err != nil {
+		return errors.Wrap(translateError(err), fmt.Sprintf("error deleting amenities of rental %d", rentalID))
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO rental_amenities (rental_id, amenity_code)
+		SELECT $1, unnest($2::text[])
+		ON CONFLICT DO NOTHING`, rentalID, pq.Array(codes))
+	if err != nil {
+		return errors.Wrap(translateError(err), fmt.Sprintf("error inserting amenities of rental %d", rentalID))
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRentalsRepository_FindRentals_Amenities(t *testing.T) {
+	requireDB(t)
+	rentalsRepository := NewRentalsRepository(db, zap.NewNop())
+	tests := map[string]struct {
+		amenities   []string
+		expectedIDs []int
+	}{
+		"One amenity": {
+			amenities:   []string{"ac"},
+			expectedIDs: []int{2, 4, 9},
+		},
+		"All of several amenities": {
+			amenities:   []string{"ac", "pet_friendly"},
+			expectedIDs: []int{2},
+		},
+		"Repeated amenity": {
+			amenities:   []string{"kitchen", "kitchen"},
+			expectedIDs: []int{1, 3, 10},
+		},
+		"Unknown amenity": {
+			amenities:   []string{"ac", "hot_tub"},
+			expectedIDs: []int{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rentals, err := rentalsRepository.FindRentals(context.Background(),
+				RentalParams{Amenities: test.amenities, Sort: "id"})
+			require.Nil(t, err, "Error getting rentals")
+			rentalIDs := make([]int, 0, len(rentals))
+			for _, rental := range rentals {
+				rentalIDs = append(rentalIDs, rental.ID)
+				assert.Subset(t, rental.Amenities, test.amenities)
+			}
+			assert.Equal(t, test.expectedIDs, rentalIDs)
+		})
+	}
+}
+
+func TestRentalsRepository_SetAmenities(t *testing.T) {
+	requireDB(t)
+	ctx := context.Background()
+	rentalsRepository := NewRentalsRepository(db, zap.NewNop())
+	amenities := func(rentalID int) []string {
+		rental, err := rentalsRepository.FindRentalByID(ctx, rentalID)
+		require.Nil(t, err, "Error getting rental")
+		return rental.Amenities
+	}
+
+	catalogue, err := rentalsRepository.FindAmenities(ctx)
+	require.Nil(t, err, "Error getting amenities")
+	assert.Len(t, catalogue, 16)
+
+	rental := &Rental{UserID: 3, Name: "Equipped Camper", Type: "camper-van", Amenities: []string{"wifi", "ac"}}
+	rentalID, err := rentalsRepository.InsertRental(ctx, rental)
+	require.Nil(t, err, "Error inserting rental")
+	assert.Equal(t, []string{"ac", "wifi"}, amenities(rentalID))
+
+	rental = &Rental{ID: rentalID, UserID: 3, Name: "Equipped Camper", Type: "camper-van"}
+	require.Nil(t, rentalsRepository.UpdateRental(ctx, rental), "Error updating rental")
+	assert.Equal(t, []string{"ac", "wifi"}, amenities(rentalID), "Nil amenities keep the amenities")
+
+	rental.Amenities = []string{"wifi", "shower"}
+	require.Nil(t, rentalsRepository.UpdateRental(ctx, rental), "Error updating amenities")
+	assert.Equal(t, []string{"shower", "wifi"}, amenities(rentalID))
+
+	rental.Amenities = []string{"hot_tub"}
+	assert.ErrorIs(t, rentalsRepository.UpdateRental(ctx, rental), ErrConflict, "Amenity missing from the catalogue")
+	assert.Equal(t, []string{"shower", "wifi"}, amenities(rentalID), "A failed update keeps the amenities")
+
+	rental.Amenities = []string{}
+	require.Nil(t, rentalsRepository.UpdateRental(ctx, rental), "Error clearing amenities")
+	assert.Empty(t, amenities(rentalID))
+
+	require.Nil(t, rentalsRepository.DeleteRental(ctx, rentalID, 3))
+}
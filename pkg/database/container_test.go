@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"flag"
 	"log"
 	"os"
 	"path/filepath"
@@ -22,23 +23,33 @@ const (
 	testDBPass = "root"
 )
 
-var db *sqlx.DB
+var (
+	db     *sqlx.DB
+	dbOpts StartUpOptions
+)
 
+// TestMain starts a Postgres container for the repository tests. With -short no container is
+// started and only the tests that do not call requireDB run.
 func TestMain(m *testing.M) {
+	flag.Parse()
+	if testing.Short() {
+		os.Exit(m.Run())
+	}
 	ctx := context.Background()
 	c, host, port := RunDBContainer(ctx)
 	defer func() {
 		_ = c.Terminate(ctx)
 	}()
 
-	var err error
-	db, err = StartDBStore(StartUpOptions{
+	dbOpts = StartUpOptions{
 		DBHost:     host,
 		DBPort:     port,
 		DBName:     testDBName,
 		DBUsername: testDBUser,
 		DBPassword: testDBPass,
-	})
+	}
+	var err error
+	db, err = StartDBStore(dbOpts)
 	if err != nil {
 		log.Fatalf("Failed to start the database: %s", err)
 	}
@@ -46,6 +57,14 @@ func TestMain(m *testing.M) {
 	os.Exit(code)
 }
 
+// requireDB skips tests that need the database container in -short mode.
+func requireDB(t *testing.T) {
+	t.Helper()
+	if db == nil {
+		t.Skip("the database container is not started in -short mode")
+	}
+}
+
 func RunDBContainer(ctx context.Context) (dbC testcontainers.Container, host string, port int) {
 	basePort, err := nat.NewPort("tcp", "5432")
 	if err != nil {
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// ExchangeRate is the amount of Currency worth one USD.
+type ExchangeRate struct {
+	Currency string    `db:"currency"`
+	Rate     float64   `db:"rate"`
+	Updated  time.Time `db:"updated"`
+}
+
+type CurrencyRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+func NewCurrencyRepository(db *sqlx.DB, logger *zap.Logger) *CurrencyRepository {
+	return &CurrencyRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// FindExchangeRates returns every exchange rate ordered by currency.
+func (cr *CurrencyRepository) FindExchangeRates(ctx context.Context) ([]ExchangeRate, error) {
+	cr.logger.Debug("Getting exchange rates")
+	rates := make([]ExchangeRate, 0)
+	err := cr.db.SelectContext(ctx, &rates, `SELECT * FROM exchange_rates ORDER BY currency`)
+	if err != nil {
+		return nil, errors.Wrap(translateError(err), "error getting exchange rates")
+	}
+	return rates, nil
+}
+
+// UpsertExchangeRates stores the rates in one transaction. Currencies missing from rates keep theirs.
+func (cr *CurrencyRepository) UpsertExchangeRates(ctx context.Context, rates []ExchangeRate) error {
+	cr.logger.Debug("Storing exchange rates", zap.Int("rates", len(rates)))
+	return inTx(ctx, cr.db, func(tx *sqlx.Tx) error {
+		for _, rate := range rates {
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO exchange_rates (currency, rate, updated) VALUES ($1, $2, now())
+				ON CONFLICT (currency) DO UPDATE SET rate = $2, updated = now()`, rate.Currency, rate.Rate)
+			if err != nil {
+				return errors.Wrap(translateError(err), "error storing exchange rate of "+rate.Currency)
+			}
+		}
+		return nil
+	})
+}
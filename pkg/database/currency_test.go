@@ -0,0 +1,30 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCurrencyRepository(t *testing.T) {
+	requireDB(t)
+	ctx := context.Background()
+	currencyRepository := NewCurrencyRepository(db, zap.NewNop())
+
+	rates, err := currencyRepository.FindExchangeRates(ctx)
+	require.Nil(t, err, "Error getting exchange rates")
+	require.Len(t, rates, 3)
+	assert.Equal(t, "CAD", rates[0].Currency)
+	assert.Equal(t, 1.35, rates[0].Rate)
+
+	err = currencyRepository.UpsertExchangeRates(ctx, []ExchangeRate{{Currency: "CAD", Rate: 1.4}, {Currency: "GBP", Rate: 0.79}})
+	require.Nil(t, err, "Error storing exchange rates")
+	rates, err = currencyRepository.FindExchangeRates(ctx)
+	require.Nil(t, err, "Error getting exchange rates")
+	require.Len(t, rates, 4)
+	assert.Equal(t, 1.4, rates[0].Rate)
+	assert.Equal(t, "GBP", rates[2].Currency)
+}
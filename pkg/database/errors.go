@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Sentinel errors returned by the repositories. Callers should match them with errors.Is,
+// the original driver error stays in the chain and can be inspected with errors.As.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrInvalidQuery = errors.New("invalid query")
+	ErrTimeout      = errors.New("timeout")
+)
+
+// Postgres SQLSTATE codes and classes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgExclusionViolation  = "23P01"
+	pgQueryCanceled       = "57014"
+	pgClassDataException  = "22"
+	pgClassSyntaxError    = "42"
+)
+
+// translateError classifies a driver error by joining it with the matching sentinel error.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		class := ""
+		if len(pgErr.Code) >= 2 {
+			class = pgErr.Code[:2]
+		}
+		switch {
+		case pgErr.Code == pgUniqueViolation, pgErr.Code == pgForeignKeyViolation, pgErr.Code == pgExclusionViolation:
+			return fmt.Errorf("%w: %w", ErrConflict, err)
+		case pgErr.Code == pgQueryCanceled:
+			return fmt.Errorf("%w: %w", ErrTimeout, err)
+		case class == pgClassDataException, class == pgClassSyntaxError:
+			return fmt.Errorf("%w: %w", ErrInvalidQuery, err)
+		}
+	}
+	return err
+}
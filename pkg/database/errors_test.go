@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslateError(t *testing.T) {
+	otherErr := errors.New("connection refused")
+	tests := map[string]struct {
+		err           error
+		expectedError error
+	}{
+		"No error": {
+			err: nil,
+		},
+		"No rows": {
+			err:           sql.ErrNoRows,
+			expectedError: ErrNotFound,
+		},
+		"Wrapped no rows": {
+			err:           fmt.Errorf("scanning rental: %w", sql.ErrNoRows),
+			expectedError: ErrNotFound,
+		},
+		"Unique violation": {
+			err:           &pgconn.PgError{Code: "23505"},
+			expectedError: ErrConflict,
+		},
+		"Foreign key violation": {
+			err:           &pgconn.PgError{Code: "23503"},
+			expectedError: ErrConflict,
+		},
+		"Exclusion violation": {
+			err:           &pgconn.PgError{Code: "23P01"},
+			expectedError: ErrConflict,
+		},
+		"Query canceled": {
+			err:           &pgconn.PgError{Code: "57014"},
+			expectedError: ErrTimeout,
+		},
+		"Data exception": {
+			err:           &pgconn.PgError{Code: "22P02"},
+			expectedError: ErrInvalidQuery,
+		},
+		"Syntax error": {
+			err:           &pgconn.PgError{Code: "42601"},
+			expectedError: ErrInvalidQuery,
+		},
+		"Short code": {
+			err:           &pgconn.PgError{Code: "2"},
+			expectedError: otherErr,
+		},
+		"Empty code": {
+			err:           &pgconn.PgError{},
+			expectedError: otherErr,
+		},
+		"Other Postgres error": {
+			err:           &pgconn.PgError{Code: "53300"},
+			expectedError: otherErr,
+		},
+		"Context deadline": {
+			err:           fmt.Errorf("querying rentals: %w", context.DeadlineExceeded),
+			expectedError: ErrTimeout,
+		},
+		"Network timeout": {
+			err:           &net.OpError{Op: "read", Net: "tcp", Err: &timeoutError{}},
+			expectedError: ErrTimeout,
+		},
+		"Network error without timeout": {
+			err:           &net.OpError{Op: "dial", Net: "tcp", Err: otherErr},
+			expectedError: otherErr,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := translateError(test.err)
+			if test.err == nil {
+				assert.Nil(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, test.err, "The driver error stays in the chain")
+			for _, sentinel := range []error{ErrNotFound, ErrConflict, ErrInvalidQuery, ErrTimeout} {
+				if sentinel == test.expectedError {
+					assert.ErrorIs(t, err, sentinel)
+				} else {
+					assert.NotErrorIs(t, err, sentinel)
+				}
+			}
+		})
+	}
+}
+
+// timeoutError is a net.Error of a timed out read, like the ones of deadlines set on connections.
+type timeoutError struct{}
+
+func (e *timeoutError) Error() string   { return "i/o timeout" }
+func (e *timeoutError) Timeout() bool   { return true }
+func (e *timeoutError) Temporary() bool { return true }
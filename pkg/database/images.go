@@ -0,0 +1,297 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+)
+
+// RentalImage is an image of the gallery of a rental. Positions of a rental run from 0 without gaps,
+// rentals.primary_image_url is kept as the url of position 0.
+type RentalImage struct {
+	ID       int    `db:"id"`
+	RentalID int    `db:"rental_id"`
+	URL      string `db:"url"`
+	Position int    `db:"position"`
+	Caption  string `db:"caption"`
+	Width    *int   `db:"width"`
+	Height   *int   `db:"height"`
+	// BlobKey is the key of the uploaded original in the blob store, nil for images linked by url.
+	BlobKey  *string       `db:"blob_key"`
+	Variants ImageVariants `db:"variants"`
+	Created  time.Time     `db:"created"`
+}
+
+// ImageVariant is a smaller rendering of an uploaded image, stored in the blob store under Key.
+type ImageVariant struct {
+	Name   string `json:"name"`
+	Key    string `json:"key"`
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// ImageVariants is stored as a jsonb array.
+type ImageVariants []ImageVariant
+
+func (v ImageVariants) Value() (driver.Value, error) {
+	if v == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(v)
+}
+
+func (v *ImageVariants) Scan(src any) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, v)
+	case string:
+		return json.Unmarshal([]byte(data), v)
+	case nil:
+		*v = nil
+		return nil
+	}
+	return fmt.Errorf("unsupported image variants of type %T", src)
+}
+
+// BlobKeys returns the keys of the original and the variants of an uploaded image.
+func (image RentalImage) BlobKeys() []string {
+	if image.BlobKey == nil {
+		return nil
+	}
+	keys := []string{*image.BlobKey}
+	for _, variant := range image.Variants {
+		keys = append(keys, variant.Key)
+	}
+	return keys
+}
+
+// InsertRentalImage adds image to the gallery of image.RentalID, provided the rental is owned by
+// userID. The image goes to position, or to the end when position is nil or past it. The
+// rental.updated outbox event is stored in the same transaction.
+func (rr *RentalsRepository) InsertRentalImage(ctx context.Context, userID int, image *RentalImage, position *int) (*RentalImage, error) {
+	rr.logger.Debug("Inserting rental image", zap.Int("rentalID", image.RentalID))
+	var inserted RentalImage
+	err := inTx(ctx, rr.db, func(tx *sqlx.Tx) error {
+		previous, err := lockRental(ctx, tx, image.RentalID, userID)
+		if err != nil {
+			return err
+		}
+		var count int
+		err = tx.GetContext(ctx, &count, `SELECT count(*) FROM rental_images WHERE rental_id = $1`, image.RentalID)
+		if err != nil {
+			return errors.Wrap(translateError(err), fmt.Sprintf("error counting images of rental %d", image.RentalID))
+		}
+		at := count
+		if position != nil && *position < count {
+			at = max(*position, 0)
+		}
+		_, err = tx.ExecContext(ctx,
+			`UPDATE rental_images SET position = position + 1 WHERE rental_id = $1 AND position >= $2`,
+			image.RentalID, at)
+		if err != nil {
+			return errors.Wrap(translateError(err), fmt.Sprintf("error moving images of rental %d", image.RentalID))
+		}
+		err = tx.QueryRowxContext(ctx,
+			`INSERT INTO rental_images (rental_id, url, position, caption, width, height, blob_key, variants)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING *`,
+			image.RentalID, image.URL, at, image.Caption, image.Width, image.Height, image.BlobKey, image.Variants,
+		).StructScan(&inserted)
+		if err != nil {
+			return errors.Wrap(translateError(err), fmt.Sprintf("error inserting image of rental %d", image.RentalID))
+		}
+		return touchRental(ctx, tx, previous)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &inserted, nil
+}
+
+// ReorderRentalImages moves the images of the rental to the position of their id in imageIDs,
+// provided the rental is owned by userID. ErrConflict is returned when imageIDs does not list
+// every image of the rental exactly once.
+func (rr *RentalsRepository) ReorderRentalImages(ctx context.Context, userID, rentalID int, imageIDs []int) error {
+	rr.logger.Debug("Reordering rental images", zap.Int("rentalID", rentalID), zap.Ints("imageIDs", imageIDs))
+	return inTx(ctx, rr.db, func(tx *sqlx.Tx) error {
+		previous, err := lockRental(ctx, tx, rentalID, userID)
+		if err != nil {
+			return err
+		}
+		var count int
+		err = tx.GetContext(ctx, &count, `SELECT count(*) FROM rental_images WHERE rental_id = $1`, rentalID)
+		if err != nil {
+			return errors.Wrap(translateError(err), fmt.Sprintf("error counting images of rental %d", rentalID))
+		}
+		res, err := tx.ExecContext(ctx,
+			`UPDATE rental_images i SET position = o.position - 1
+			FROM unnest($2::integer[]) WITH ORDINALITY AS o(id, position)
+			WHERE i.id = o.id AND i.rental_id = $1`,
+			rentalID, pq.Array(imageIDs))
+		if err != nil {
+			return errors.Wrap(translateError(err), fmt.Sprintf("error reordering images of rental %d", rentalID))
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return errors.Wrap(err, "error reading affected rows")
+		}
+		if int(affected) != count || len(imageIDs) != count {
+			return errors.Wrap(ErrConflict, fmt.Sprintf("the order does not list the %d images of rental %d", count, rentalID))
+		}
+		return touchRental(ctx, tx, previous)
+	})
+}
+
+// DeleteRentalImage removes the image from the gallery of the rental, provided the rental is owned
+// by userID, and moves the images after it one position forward. The deleted image is returned,
+// so its blobs can be removed.
+func (rr *RentalsRepository) DeleteRentalImage(ctx context.Context, userID, rentalID, imageID int) (*RentalImage, error) {
+	rr.logger.Debug("Deleting rental image", zap.Int("rentalID", rentalID), zap.Int("imageID", imageID))
+	var deleted RentalImage
+	err := inTx(ctx, rr.db, func(tx *sqlx.Tx) error {
+		previous, err := lockRental(ctx, tx, rentalID, userID)
+		if err != nil {
+			return err
+		}
+		err = tx.QueryRowxContext(ctx,
+			`DELETE FROM rental_images WHERE id = $1 AND rental_id = $2 RETURNING *`, imageID, rentalID).StructScan(&deleted)
+		if err != nil {
+			err = translateError(err)
+			if errors.Is(err, ErrNotFound) {
+				return errors.Wrap(err, fmt.Sprintf("not found image %d of rental %d", imageID, rentalID))
+			}
+			return errors.Wrap(err, fmt.Sprintf("error deleting image %d of rental %d", imageID, rentalID))
+		}
+		_, err = tx.ExecContext(ctx,
+			`UPDATE rental_images SET position = position - 1 WHERE rental_id = $1 AND position > $2`,
+			rentalID, deleted.Position)
+		if err != nil {
+			return errors.Wrap(translateError(err), fmt.Sprintf("error moving images of rental %d", rentalID))
+		}
+		return touchRental(ctx, tx, previous)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &deleted, nil
+}
+
+// findImages returns the galleries of the rentals by rental id, ordered by position.
+func findImages(ctx context.Context, q sqlx.QueryerContext, rentalIDs ...int) (map[int][]RentalImage, error) {
+	images := make([]RentalImage, 0)
+	err := sqlx.SelectContext(ctx, q, &images,
+		`SELECT * FROM rental_images WHERE rental_id = ANY ($1) ORDER BY rental_id, position`, pq.Array(rentalIDs))
+	if err != nil {
+		return nil, errors.Wrap(translateError(err), "error getting rental images")
+	}
+	galleries := make(map[int][]RentalImage, len(rentalIDs))
+	for _, image := range images {
+		galleries[image.RentalID] = append(galleries[image.RentalID], image)
+	}
+	return galleries, nil
+}
+
+// loadImages sets the gallery of every rental with one query.
+func loadImages(ctx context.Context, q sqlx.QueryerContext, rentals []Rental) error {
+	if len(rentals) == 0 {
+		return nil
+	}
+	rentalIDs := make([]int, len(rentals))
+	for i, rental := range rentals {
+		rentalIDs[i] = rental.ID
+	}
+	galleries, err := findImages(ctx, q, rentalIDs...)
+	if err != nil {
+		return err
+	}
+	for i := range rentals {
+		rentals[i].Images = galleries[rentals[i].ID]
+	}
+	return nil
+}
+
+// setPrimaryImage makes url the first image of the rental: it replaces the url of the first image,
+// whose size and uploaded blobs are then dropped, or becomes the only image of a rental without any.
+func setPrimaryImage(ctx context.Context, tx *sqlx.Tx, rentalID int, url string) error {
+	if url == "" {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx,
+		`UPDATE rental_images SET url = $2, width = NULL, height = NULL, blob_key = NULL, variants = '[]'
+		WHERE rental_id = $1 AND position = 0 AND url <> $2`, rentalID, url)
+	if err != nil {
+		return errors.Wrap(translateError(err), fmt.Sprintf("error updating primary image of rental %d", rentalID))
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO rental_images (rental_id, url, position)
+		SELECT $1, $2, 0 WHERE NOT EXISTS (SELECT 1 FROM rental_images WHERE rental_id = $1)`, rentalID, url)
+	if err != nil {
+		return errors.Wrap(translateError(err), fmt.Sprintf("error inserting primary image of rental %d", rentalID))
+	}
+	return nil
+}
+
+// lockRental locks the rental for the rest of tx, so its changes are serialized, and returns it
+// as it is before them, with its images and amenities. ErrNotFound is returned when the rental is
+// not owned by userID, a userID of 0 matches any owner.
+func lockRental(ctx context.Context, tx *sqlx.Tx, rentalID, userID int) (*Rental, error) {
+	var rental Rental
+	err := tx.GetContext(ctx, &rental,
+		`SELECT * FROM rentals WHERE id = $1 AND ($2 = 0 OR user_id = $2) FOR UPDATE`, rentalID, userID)
+	if err != nil {
+		err = translateError(err)
+		if errors.Is(err, ErrNotFound) {
+			return nil, errors.Wrap(err, fmt.Sprintf("not found rentals with id %d", rentalID))
+		}
+		return nil, errors.Wrap(err, fmt.Sprintf("error locking rental with id %d", rentalID))
+	}
+	if err := loadRelations(ctx, tx, &rental); err != nil {
+		return nil, err
+	}
+	return &rental, nil
+}
+
+// loadRelations sets the images and amenities of the rental.
+func loadRelations(ctx context.Context, tx *sqlx.Tx, rental *Rental) error {
+	galleries, err := findImages(ctx, tx, rental.ID)
+	if err != nil {
+		return err
+	}
+	rental.Images = galleries[rental.ID]
+	amenities, err := findAmenities(ctx, tx, rental.ID)
+	if err != nil {
+		return err
+	}
+	rental.Amenities = amenities[rental.ID]
+	return nil
+}
+
+// touchRental marks the rental updated after a change of its gallery or reviews. It points
+// primary_image_url to the first image and stores the rental.updated outbox event, holding the
+// rental with its images and amenities and, as it was before the change, previous.
+func touchRental(ctx context.Context, tx *sqlx.Tx, previous *Rental) error {
+	var updated Rental
+	err := tx.QueryRowxContext(ctx,
+		`UPDATE rentals SET primary_image_url = COALESCE(
+			(SELECT url FROM rental_images WHERE rental_id = $1 ORDER BY position LIMIT 1), ''),
+		updated = now()
+		WHERE id = $1
+		RETURNING *`, previous.ID).StructScan(&updated)
+	if err != nil {
+		return errors.Wrap(translateError(err), fmt.Sprintf("error updating rental with id %d", previous.ID))
+	}
+	if err := loadRelations(ctx, tx, &updated); err != nil {
+		return err
+	}
+	return insertOutboxEvent(ctx, tx, apiv1.EventRentalUpdated, &updated, previous)
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRentalsRepository_Images(t *testing.T) {
+	requireDB(t)
+	ctx := context.Background()
+	rentalsRepository := NewRentalsRepository(db, zap.NewNop())
+	urls := func(rentalID int) []string {
+		rental, err := rentalsRepository.FindRentalByID(ctx, rentalID)
+		require.Nil(t, err, "Error getting rental")
+		urls := make([]string, len(rental.Images))
+		for i, image := range rental.Images {
+			assert.Equal(t, i, image.Position)
+			urls[i] = image.URL
+		}
+		if len(urls) > 0 {
+			assert.Equal(t, urls[0], rental.PrimaryImageURL, "The primary image is the first image")
+		}
+		return urls
+	}
+
+	rentalID, err := rentalsRepository.InsertRental(ctx, &Rental{UserID: 3, Name: "Gallery Camper", Type: "camper-van",
+		PrimaryImageURL: "https://example.com/front.jpg"})
+	require.Nil(t, err, "Error inserting rental")
+	assert.Equal(t, []string{"https://example.com/front.jpg"}, urls(rentalID))
+
+	width := 1600
+	back, err := rentalsRepository.InsertRentalImage(ctx, 3,
+		&RentalImage{RentalID: rentalID, URL: "https://example.com/back.jpg", Caption: "Back", Width: &width}, nil)
+	require.Nil(t, err, "Error adding image")
+	assert.Equal(t, 1, back.Position)
+	assert.Equal(t, &width, back.Width)
+	first := 0
+	side, err := rentalsRepository.InsertRentalImage(ctx, 3,
+		&RentalImage{RentalID: rentalID, URL: "https://example.com/side.jpg"}, &first)
+	require.Nil(t, err, "Error adding image at the front")
+	assert.Equal(t, []string{"https://example.com/side.jpg", "https://example.com/front.jpg", "https://example.com/back.jpg"},
+		urls(rentalID))
+	_, err = rentalsRepository.InsertRentalImage(ctx, 4, &RentalImage{RentalID: rentalID, URL: "https://example.com/x.jpg"}, nil)
+	assert.ErrorIs(t, err, ErrNotFound, "Image added by another user")
+
+	rental, err := rentalsRepository.FindRentalByID(ctx, rentalID)
+	require.Nil(t, err, "Error getting rental")
+	front := rental.Images[1]
+	err = rentalsRepository.ReorderRentalImages(ctx, 3, rentalID, []int{back.ID, front.ID, side.ID})
+	require.Nil(t, err, "Error reordering images")
+	assert.Equal(t, []string{"https://example.com/back.jpg", "https://example.com/front.jpg", "https://example.com/side.jpg"},
+		urls(rentalID))
+	err = rentalsRepository.ReorderRentalImages(ctx, 3, rentalID, []int{back.ID, front.ID})
+	assert.ErrorIs(t, err, ErrConflict, "Order missing an image")
+
+	deleted, err := rentalsRepository.DeleteRentalImage(ctx, 3, rentalID, back.ID)
+	require.Nil(t, err, "Error deleting image")
+	assert.Equal(t, back.ID, deleted.ID)
+	assert.Equal(t, []string{"https://example.com/front.jpg", "https://example.com/side.jpg"}, urls(rentalID))
+	_, err = rentalsRepository.DeleteRentalImage(ctx, 3, rentalID, back.ID)
+	assert.ErrorIs(t, err, ErrNotFound, "Image deleted twice")
+
+	key := "rentals/1/abc/original.jpg"
+	uploaded, err := rentalsRepository.InsertRentalImage(ctx, 3, &RentalImage{RentalID: rentalID,
+		URL: "http://localhost:59191/media/" + key, BlobKey: &key,
+		Variants: ImageVariants{{Name: "w160", Key: "rentals/1/abc/w160.jpg", Width: 160, Height: 120}}}, nil)
+	require.Nil(t, err, "Error adding uploaded image")
+	assert.Equal(t, []string{key, "rentals/1/abc/w160.jpg"}, uploaded.BlobKeys())
+	deleted, err = rentalsRepository.DeleteRentalImage(ctx, 3, rentalID, uploaded.ID)
+	require.Nil(t, err, "Error deleting uploaded image")
+	assert.Equal(t, uploaded.Variants, deleted.Variants)
+
+	err = rentalsRepository.UpdateRental(ctx, &Rental{ID: rentalID, UserID: 3, Name: "Gallery Camper", Type: "camper-van"})
+	require.Nil(t, err, "Error updating rental")
+	assert.Equal(t, []string{"https://example.com/front.jpg", "https://example.com/side.jpg"}, urls(rentalID),
+		"An update without primary image keeps the gallery")
+	err = rentalsRepository.UpdateRental(ctx, &Rental{ID: rentalID, UserID: 3, Name: "Gallery Camper", Type: "camper-van",
+		PrimaryImageURL: "https://example.com/new.jpg"})
+	require.Nil(t, err, "Error updating rental")
+	assert.Equal(t, []string{"https://example.com/new.jpg", "https://example.com/side.jpg"}, urls(rentalID))
+
+	rentals, err := rentalsRepository.FindRentals(ctx, RentalParams{IDs: []int{1, rentalID}, Sort: "id"})
+	require.Nil(t, err, "Error getting rentals")
+	require.Len(t, rentals, 2)
+	assert.Len(t, rentals[0].Images, 1, "Seeded rentals have their primary image")
+	assert.Len(t, rentals[1].Images, 2)
+
+	require.Nil(t, rentalsRepository.DeleteRental(ctx, rentalID, 3))
+}
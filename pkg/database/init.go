@@ -15,9 +15,13 @@ type StartUpOptions struct {
 	DBPassword string
 }
 
+func (opts StartUpOptions) connString() string {
+	return fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=disable",
+		opts.DBUsername, opts.DBPassword, opts.DBHost, opts.DBPort, opts.DBName)
+}
+
 func StartDBStore(opts StartUpOptions) (*sqlx.DB, error) {
-	db, err := sqlx.Open("pgx", fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=disable",
-		opts.DBUsername, opts.DBPassword, opts.DBHost, opts.DBPort, opts.DBName))
+	db, err := sqlx.Open("pgx", opts.connString())
 	if err != nil {
 		return nil, err
 	}
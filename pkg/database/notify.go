@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// RentalChangesChannel is the channel the rentals_notify_change trigger notifies.
+const RentalChangesChannel = "rental_changes"
+
+// Operations of a RentalChange.
+const (
+	ChangeInsert = "insert"
+	ChangeUpdate = "update"
+	ChangeDelete = "delete"
+	// ChangeResync follows a reconnect of the listener, when notifications may have been missed
+	// meanwhile, and a change of the exchange rates: any rental may have changed.
+	ChangeResync = "resync"
+)
+
+// RentalChange is the payload of a notification on RentalChangesChannel.
+type RentalChange struct {
+	Op string `json:"op"`
+	ID int    `json:"id"`
+}
+
+// Delays between the reconnects of the listener.
+const (
+	listenMinBackoff = time.Second
+	listenMaxBackoff = 30 * time.Second
+)
+
+// Listener receives the rental changes over a dedicated connection, as LISTEN does not work
+// through the pooled connections of sqlx.DB.
+type Listener struct {
+	connString string
+	logger     *zap.Logger
+}
+
+func NewListener(opts StartUpOptions, logger *zap.Logger) *Listener {
+	return &Listener{connString: opts.connString(), logger: logger}
+}
+
+// Listen calls handle for every rental change until ctx is done. A lost connection is reopened
+// with backoff and followed by a ChangeResync.
+func (l *Listener) Listen(ctx context.Context, handle func(RentalChange)) {
+	backoff := listenMinBackoff
+	connected := false
+	for {
+		err := l.listen(ctx, func() {
+			if connected {
+				handle(RentalChange{Op: ChangeResync})
+			}
+			connected = true
+			backoff = listenMinBackoff
+		}, handle)
+		if ctx.Err() != nil {
+			return
+		}
+		l.logger.Error("Error listening for rental changes", zap.Duration("retryIn", backoff), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, listenMaxBackoff)
+	}
+}
+
+func (l *Listener) listen(ctx context.Context, onListen func(), handle func(RentalChange)) error {
+	conn, err := pgx.Connect(ctx, l.connString)
+	if err != nil {
+		return errors.Wrap(err, "error connecting")
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+RentalChangesChannel); err != nil {
+		return errors.Wrap(err, "error listening")
+	}
+	l.logger.Info("Listening for rental changes", zap.String("channel", RentalChangesChannel))
+	onListen()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return errors.Wrap(err, "error waiting for notification")
+		}
+		var change RentalChange
+		if err := json.Unmarshal([]byte(notification.Payload), &change); err != nil {
+			l.logger.Warn("Invalid rental change notification", zap.String("payload", notification.Payload), zap.Error(err))
+			continue
+		}
+		handle(change)
+	}
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestListener_Listen(t *testing.T) {
+	requireDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan RentalChange, 100)
+	go NewListener(dbOpts, zap.NewNop()).Listen(ctx, func(change RentalChange) {
+		changes <- change
+	})
+	// ping until the listener is connected, the changes before would be missed
+	require.Eventually(t, func() bool {
+		_, err := db.Exec("SELECT pg_notify($1, '{\"op\":\"ping\"}')", RentalChangesChannel)
+		require.Nil(t, err, "Error notifying")
+		select {
+		case <-changes:
+			return true
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+
+	rentalsRepository := NewRentalsRepository(db, zap.NewNop())
+	rentalID, err := rentalsRepository.InsertRental(ctx, &Rental{UserID: 5, Name: "Notified Camper", Type: "camper-van"})
+	require.Nil(t, err, "Error inserting rental")
+	require.Nil(t, rentalsRepository.UpdateRental(ctx, &Rental{ID: rentalID, UserID: 5, Name: "Renamed Camper", Type: "camper-van"}))
+	require.Nil(t, rentalsRepository.DeleteRental(ctx, rentalID, 5))
+
+	var received []RentalChange
+	for len(received) < 3 {
+		select {
+		case change := <-changes:
+			if change.Op != "ping" {
+				received = append(received, change)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Received %d of 3 notifications", len(received))
+		}
+	}
+	assert.Equal(t, []RentalChange{
+		{Op: ChangeInsert, ID: rentalID},
+		{Op: ChangeUpdate, ID: rentalID},
+		{Op: ChangeDelete, ID: rentalID},
+	}, received)
+}
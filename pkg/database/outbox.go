@@ -0,0 +1,183 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/types"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// ErrDeadLetter marks errors of events that can never be published, like undecodable payloads.
+// PublishEvents sets such events aside as failed instead of retrying them.
+var ErrDeadLetter = errors.New("dead letter")
+
+// OutboxEvent is a rental change waiting in the outbox. Payload holds the Rental as JSON, as it was
+// after the change, or before it for deletions. Previous holds the Rental before the change of
+// rental.updated events.
+type OutboxEvent struct {
+	ID        int64              `db:"id"`
+	EventType string             `db:"event_type"`
+	RentalID  int                `db:"rental_id"`
+	Payload   types.JSONText     `db:"payload"`
+	Previous  types.NullJSONText `db:"previous"`
+	Created   time.Time          `db:"created"`
+	Published *time.Time         `db:"published"`
+	Attempts  int                `db:"attempts"`
+	LastError *string            `db:"last_error"`
+	Failed    *time.Time         `db:"failed"`
+}
+
+// Rental decodes the payload of the event.
+func (e OutboxEvent) Rental() (Rental, error) {
+	var rental Rental
+	if err := json.Unmarshal(e.Payload, &rental); err != nil {
+		return Rental{}, errors.Wrapf(err, "error decoding outbox event %d", e.ID)
+	}
+	return rental, nil
+}
+
+// PreviousRental decodes the rental before the change, nil for events without it.
+func (e OutboxEvent) PreviousRental() (*Rental, error) {
+	if !e.Previous.Valid {
+		return nil, nil
+	}
+	var rental Rental
+	if err := json.Unmarshal(e.Previous.JSONText, &rental); err != nil {
+		return nil, errors.Wrapf(err, "error decoding previous rental of outbox event %d", e.ID)
+	}
+	return &rental, nil
+}
+
+// insertOutboxEvent records a change of rental within tx, so the event is stored if and only if
+// the change is committed. previous is the rental before an update, nil for other changes.
+func insertOutboxEvent(ctx context.Context, tx *sqlx.Tx, eventType string, rental, previous *Rental) error {
+	payload, err := json.Marshal(rental)
+	if err != nil {
+		return errors.Wrap(err, "error encoding outbox event")
+	}
+	var previousPayload types.NullJSONText
+	if previous != nil {
+		previousPayload.JSONText, err = json.Marshal(previous)
+		if err != nil {
+			return errors.Wrap(err, "error encoding outbox event")
+		}
+		previousPayload.Valid = true
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO outbox (event_type, rental_id, payload, previous) VALUES ($1, $2, $3, $4)`,
+		eventType, rental.ID, payload, previousPayload)
+	if err != nil {
+		return errors.Wrap(translateError(err), "error inserting outbox event")
+	}
+	return nil
+}
+
+type OutboxRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+func NewOutboxRepository(db *sqlx.DB, logger *zap.Logger) *OutboxRepository {
+	return &OutboxRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// PublishEvents passes up to limit pending events, oldest first, to publish and marks the ones it
+// accepted as published. It stops at the first error of publish, so events keep their order, and
+// counts the failed attempt. Events that failed maxAttempts times, or whose error is ErrDeadLetter,
+// are marked as failed and skipped instead, so they do not hold back the events after them. It
+// returns the number of events published or failed. The events are locked while they are
+// published, concurrent relays skip them.
+func (or *OutboxRepository) PublishEvents(ctx context.Context, limit, maxAttempts int,
+	publish func(OutboxEvent) error) (int, error) {
+	tx, err := or.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(translateError(err), "error starting transaction")
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	events := make([]OutboxEvent, 0, limit)
+	err = tx.SelectContext(ctx, &events,
+		`SELECT * FROM outbox WHERE published IS NULL AND failed IS NULL ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED`, limit)
+	if err != nil {
+		return 0, errors.Wrap(translateError(err), "error getting outbox events")
+	}
+
+	publishedIDs := make([]int64, 0, len(events))
+	failed := 0
+	var publishErr error
+	for _, event := range events {
+		if publishErr = publish(event); publishErr == nil {
+			publishedIDs = append(publishedIDs, event.ID)
+			continue
+		}
+		deadLetter := errors.Is(publishErr, ErrDeadLetter) || event.Attempts+1 >= maxAttempts
+		_, err = tx.ExecContext(ctx,
+			`UPDATE outbox SET attempts = attempts + 1, last_error = $2, failed = CASE WHEN $3::boolean THEN now() END
+			WHERE id = $1`, event.ID, publishErr.Error(), deadLetter)
+		if err != nil {
+			return 0, errors.Wrap(translateError(err), "error recording failed outbox event")
+		}
+		if !deadLetter {
+			publishErr = errors.Wrapf(publishErr, "error publishing outbox event %d", event.ID)
+			break
+		}
+		or.logger.Error("Outbox event set aside as failed", zap.Int64("eventID", event.ID),
+			zap.Int("attempts", event.Attempts+1), zap.Error(publishErr))
+		publishErr = nil
+		failed++
+	}
+	if len(publishedIDs) > 0 {
+		_, err = tx.ExecContext(ctx, `UPDATE outbox SET published = now() WHERE id = ANY ($1)`, pq.Array(publishedIDs))
+		if err != nil {
+			return 0, errors.Wrap(translateError(err), "error marking outbox events as published")
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, errors.Wrap(translateError(err), "error committing published outbox events")
+	}
+	return len(publishedIDs) + failed, publishErr
+}
+
+// FindEventsAfter returns up to limit events with an id greater than afterID, published or not,
+// oldest first.
+func (or *OutboxRepository) FindEventsAfter(ctx context.Context, afterID int64, limit int) ([]OutboxEvent, error) {
+	events := make([]OutboxEvent, 0)
+	err := or.db.SelectContext(ctx, &events, `SELECT * FROM outbox WHERE id > $1 ORDER BY id LIMIT $2`, afterID, limit)
+	if err != nil {
+		return nil, errors.Wrap(translateError(err), "error getting outbox events")
+	}
+	return events, nil
+}
+
+// LastEventID returns the id of the newest event, 0 when the outbox is empty.
+func (or *OutboxRepository) LastEventID(ctx context.Context) (int64, error) {
+	var lastID int64
+	err := or.db.GetContext(ctx, &lastID, `SELECT COALESCE(MAX(id), 0) FROM outbox`)
+	if err != nil {
+		return 0, errors.Wrap(translateError(err), "error getting last outbox event")
+	}
+	return lastID, nil
+}
+
+// DeletePublishedEvents removes the events published before the given time.
+func (or *OutboxRepository) DeletePublishedEvents(ctx context.Context, before time.Time) (int64, error) {
+	res, err := or.db.ExecContext(ctx, `DELETE FROM outbox WHERE published < $1`, before)
+	if err != nil {
+		return 0, errors.Wrap(translateError(err), "error deleting published outbox events")
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "error reading affected rows")
+	}
+	return deleted, nil
+}
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+)
+
+func TestOutboxRepository_RentalChanges(t *testing.T) {
+	requireDB(t)
+	ctx := context.Background()
+	rentalsRepository := NewRentalsRepository(db, zap.NewNop())
+	outboxRepository := NewOutboxRepository(db, zap.NewNop())
+	// publish what earlier tests left in the outbox
+	_, err := outboxRepository.PublishEvents(ctx, 1000, 1, func(OutboxEvent) error { return nil })
+	require.Nil(t, err, "Error draining the outbox")
+
+	rentalID, err := rentalsRepository.InsertRental(ctx, &Rental{UserID: 5, Name: "Outbox Camper", Type: "camper-van"})
+	require.Nil(t, err, "Error inserting rental")
+	err = rentalsRepository.UpdateRental(ctx, &Rental{ID: rentalID, UserID: 5, Name: "Renamed Camper", Type: "camper-van"})
+	require.Nil(t, err, "Error updating rental")
+	err = rentalsRepository.UpdateRental(ctx, &Rental{ID: rentalID, UserID: 4, Name: "Not the owner", Type: "camper-van"})
+	assert.ErrorIs(t, err, ErrNotFound)
+	require.Nil(t, rentalsRepository.DeleteRental(ctx, rentalID, 5))
+
+	failing := errors.New("unavailable")
+	published, err := outboxRepository.PublishEvents(ctx, 10, 3, func(event OutboxEvent) error {
+		if event.EventType == apiv1.EventRentalUpdated {
+			return failing
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, failing)
+	assert.Equal(t, 1, published, "Events after a failed one are not published")
+
+	var events []OutboxEvent
+	published, err = outboxRepository.PublishEvents(ctx, 10, 3, func(event OutboxEvent) error {
+		events = append(events, event)
+		return nil
+	})
+	require.Nil(t, err, "Error publishing events")
+	assert.Equal(t, 2, published)
+	require.Len(t, events, 2)
+	assert.Equal(t, apiv1.EventRentalUpdated, events[0].EventType)
+	assert.Equal(t, 1, events[0].Attempts, "The failed attempt is counted")
+	assert.Equal(t, apiv1.EventRentalDeleted, events[1].EventType)
+	var rental Rental
+	require.Nil(t, json.Unmarshal(events[1].Payload, &rental), "Error decoding payload")
+	assert.Equal(t, rentalID, rental.ID)
+	assert.Equal(t, "Renamed Camper", rental.Name)
+
+	// a dead letter is set aside and the events after it are published
+	_, err = rentalsRepository.InsertRental(ctx, &Rental{UserID: 5, Name: "Dead Letter Camper", Type: "camper-van"})
+	require.Nil(t, err, "Error inserting rental")
+	_, err = rentalsRepository.InsertRental(ctx, &Rental{UserID: 5, Name: "Next Camper", Type: "camper-van"})
+	require.Nil(t, err, "Error inserting rental")
+	var names []string
+	published, err = outboxRepository.PublishEvents(ctx, 10, 3, func(event OutboxEvent) error {
+		rental, _ := event.Rental()
+		if rental.Name == "Dead Letter Camper" {
+			return fmt.Errorf("%w: corrupt", ErrDeadLetter)
+		}
+		names = append(names, rental.Name)
+		return nil
+	})
+	require.Nil(t, err, "Error publishing events")
+	assert.Equal(t, 2, published)
+	assert.Equal(t, []string{"Next Camper"}, names)
+	var failed OutboxEvent
+	require.Nil(t, db.GetContext(ctx, &failed, `SELECT * FROM outbox WHERE failed IS NOT NULL ORDER BY id DESC LIMIT 1`))
+	assert.Nil(t, failed.Published)
+	require.NotNil(t, failed.LastError)
+	assert.Contains(t, *failed.LastError, "corrupt")
+
+	deleted, err := outboxRepository.DeletePublishedEvents(ctx, time.Now().Add(time.Minute))
+	require.Nil(t, err, "Error deleting published events")
+	assert.GreaterOrEqual(t, deleted, int64(3))
+}
+
+func TestOutboxRepository_FindEventsAfter(t *testing.T) {
+	requireDB(t)
+	ctx := context.Background()
+	rentalsRepository := NewRentalsRepository(db, zap.NewNop())
+	outboxRepository := NewOutboxRepository(db, zap.NewNop())
+	lastID, err := outboxRepository.LastEventID(ctx)
+	require.Nil(t, err, "Error getting last event id")
+
+	rental := &Rental{UserID: 5, Name: "Streamed Camper", Type: "camper-van", PricePerDay: 9900}
+	rentalID, err := rentalsRepository.InsertRental(ctx, rental)
+	require.Nil(t, err, "Error inserting rental")
+	rental.ID = rentalID
+	rental.PricePerDay = 19900
+	require.Nil(t, rentalsRepository.UpdateRental(ctx, rental))
+	require.Nil(t, rentalsRepository.DeleteRental(ctx, rentalID, 5))
+
+	events, err := outboxRepository.FindEventsAfter(ctx, lastID, 10)
+	require.Nil(t, err, "Error finding events")
+	require.Len(t, events, 3)
+	assert.Equal(t, apiv1.EventRentalCreated, events[0].EventType)
+	assert.Equal(t, apiv1.EventRentalUpdated, events[1].EventType)
+	assert.Equal(t, apiv1.EventRentalDeleted, events[2].EventType)
+	previous, err := events[0].PreviousRental()
+	require.Nil(t, err, "Error decoding previous rental")
+	assert.Nil(t, previous, "Only updates keep the previous state")
+	previous, err = events[1].PreviousRental()
+	require.Nil(t, err, "Error decoding previous rental")
+	require.NotNil(t, previous)
+	assert.Equal(t, 9900, previous.PricePerDay)
+	updated, err := events[1].Rental()
+	require.Nil(t, err, "Error decoding rental")
+	assert.Equal(t, 19900, updated.PricePerDay)
+
+	deleted, err := events[2].Rental()
+	require.Nil(t, err, "Error decoding rental")
+	assert.False(t, RentalParams{PriceMax: 10000}.Matches(deleted))
+	created, err := events[0].Rental()
+	require.Nil(t, err, "Error decoding rental")
+	assert.True(t, RentalParams{PriceMax: 10000}.Matches(created))
+	assert.False(t, RentalParams{PriceMin: 9900}.Matches(created))
+	assert.False(t, RentalParams{PriceMax: 10000, PriceUnit: "price_per_week"}.Matches(created), "Rental without a weekly price")
+
+	events, err = outboxRepository.FindEventsAfter(ctx, events[0].ID, 10)
+	require.Nil(t, err, "Error finding events")
+	assert.Len(t, events, 2)
+}
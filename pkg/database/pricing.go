@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// RentalPricing holds the pricing rules of a rental on top of its price per day.
+type RentalPricing struct {
+	RentalID                int            `db:"rental_id"`
+	WeekendSurchargePercent int            `db:"weekend_surcharge_percent"`
+	WeeklyDiscountPercent   int            `db:"weekly_discount_percent"`
+	MonthlyDiscountPercent  int            `db:"monthly_discount_percent"`
+	MinNights               int            `db:"min_nights"`
+	Updated                 time.Time      `db:"updated"`
+	Seasons                 []RentalSeason `db:"-"`
+}
+
+type RentalSeason struct {
+	ID          int       `db:"id"`
+	RentalID    int       `db:"rental_id"`
+	Name        string    `db:"name"`
+	StartDate   time.Time `db:"start_date"`
+	EndDate     time.Time `db:"end_date"`
+	PricePerDay int       `db:"price_per_day"`
+	MinNights   int       `db:"min_nights"`
+}
+
+type PricingRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+func NewPricingRepository(db *sqlx.DB, logger *zap.Logger) *PricingRepository {
+	return &PricingRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// FindPricing returns the pricing rules of the rental with its seasons ordered by start date.
+// ErrNotFound is returned when no rules were set for the rental.
+func (pr *PricingRepository) FindPricing(ctx context.Context, rentalID int) (*RentalPricing, error) {
+	pr.logger.Debug("Getting rental pricing", zap.Int("rentalID", rentalID))
+	pricing := RentalPricing{}
+	err := pr.db.GetContext(ctx, &pricing, `SELECT * FROM rental_pricing WHERE rental_id = $1`, rentalID)
+	if err != nil {
+		return nil, errors.Wrap(translateError(err), fmt.Sprintf("error getting pricing of rental %d", rentalID))
+	}
+	pricing.Seasons = make([]RentalSeason, 0)
+	err = pr.db.SelectContext(ctx, &pricing.Seasons,
+		`SELECT * FROM rental_seasons WHERE rental_id = $1 ORDER BY start_date`, rentalID)
+	if err != nil {
+		return nil, errors.Wrap(translateError(err), fmt.Sprintf("error getting seasons of rental %d", rentalID))
+	}
+	return &pricing, nil
+}
+
+// ReplacePricing stores the rules and seasons of pricing.RentalID, replacing the previous ones.
+func (pr *PricingRepository) ReplacePricing(ctx context.Context, pricing *RentalPricing) error {
+	pr.logger.Debug("Replacing rental pricing", zap.Int("rentalID", pricing.RentalID))
+	return inTx(ctx, pr.db, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO rental_pricing (rental_id, weekend_surcharge_percent, weekly_discount_percent,
+			monthly_discount_percent, min_nights, updated)
+			VALUES ($1, $2, $3, $4, $5, now())
+			ON CONFLICT (rental_id) DO UPDATE SET weekend_surcharge_percent = $2, weekly_discount_percent = $3,
+			monthly_discount_percent = $4, min_nights = $5, updated = now()`,
+			pricing.RentalID, pricing.WeekendSurchargePercent, pricing.WeeklyDiscountPercent,
+			pricing.MonthlyDiscountPercent, pricing.MinNights)
+		if err != nil {
+			return errors.Wrap(translateError(err), fmt.Sprintf("error storing pricing of rental %d", pricing.RentalID))
+		}
+		_, err = tx.ExecContext(ctx, `DELETE FROM rental_seasons WHERE rental_id = $1`, pricing.RentalID)
+		if err != nil {
+			return errors.Wrap(translateError(err), fmt.Sprintf("error deleting seasons of rental %d", pricing.RentalID))
+		}
+		for _, season := range pricing.Seasons {
+			_, err = tx.ExecContext(ctx,
+				`INSERT INTO rental_seasons (rental_id, name, start_date, end_date, price_per_day, min_nights)
+				VALUES ($1, $2, $3, $4, $5, $6)`,
+				pricing.RentalID, season.Name, season.StartDate, season.EndDate, season.PricePerDay, season.MinNights)
+			if err != nil {
+				return errors.Wrap(translateError(err), fmt.Sprintf("error inserting season %q", season.Name))
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestPricingRepository(t *testing.T) {
+	requireDB(t)
+	ctx := context.Background()
+	pricingRepository := NewPricingRepository(db, zap.NewNop())
+
+	_, err := pricingRepository.FindPricing(ctx, 7)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	summer := RentalSeason{
+		Name:        "Summer",
+		StartDate:   time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:     time.Date(2024, 8, 31, 0, 0, 0, 0, time.UTC),
+		PricePerDay: 15000,
+		MinNights:   3,
+	}
+	err = pricingRepository.ReplacePricing(ctx, &RentalPricing{
+		RentalID: 7, WeekendSurchargePercent: 20, WeeklyDiscountPercent: 10, MinNights: 2,
+		Seasons: []RentalSeason{summer, {Name: "Easter", StartDate: summer.StartDate.AddDate(0, -3, 0),
+			EndDate: summer.StartDate.AddDate(0, -3, 7), PricePerDay: 12000}},
+	})
+	require.Nil(t, err, "Error storing pricing")
+	pricing, err := pricingRepository.FindPricing(ctx, 7)
+	require.Nil(t, err, "Error getting pricing")
+	assert.Equal(t, 20, pricing.WeekendSurchargePercent)
+	assert.Equal(t, 2, pricing.MinNights)
+	require.Len(t, pricing.Seasons, 2)
+	assert.Equal(t, "Easter", pricing.Seasons[0].Name)
+	assert.True(t, summer.EndDate.Equal(pricing.Seasons[1].EndDate))
+
+	err = pricingRepository.ReplacePricing(ctx, &RentalPricing{RentalID: 7, MonthlyDiscountPercent: 25, MinNights: 1})
+	require.Nil(t, err, "Error replacing pricing")
+	pricing, err = pricingRepository.FindPricing(ctx, 7)
+	require.Nil(t, err, "Error getting pricing")
+	assert.Equal(t, 0, pricing.WeekendSurchargePercent)
+	assert.Equal(t, 25, pricing.MonthlyDiscountPercent)
+	assert.Empty(t, pricing.Seasons)
+
+	err = pricingRepository.ReplacePricing(ctx, &RentalPricing{RentalID: 3000, MinNights: 1})
+	assert.ErrorIs(t, err, ErrConflict, "Pricing of a missing rental")
+}
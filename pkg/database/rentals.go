@@ -2,8 +2,10 @@ package database
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -23,6 +25,11 @@ type Rental struct {
 	Description     string     `db:"description"`
 	Sleeps          int        `db:"sleeps"`
 	PricePerDay     int        `db:"price_per_day"`
+	PricePerWeek    *int       `db:"price_per_week"`
+	PricePerMonth   *int       `db:"price_per_month"`
+	SecurityDeposit *int       `db:"security_deposit"`
+	CleaningFee     *int       `db:"cleaning_fee"`
+	PricePerMile    *int       `db:"price_per_mile"`
 	HomeCity        string     `db:"home_city"`
 	HomeState       string     `db:"home_state"`
 	HomeZip         string     `db:"home_zip"`
@@ -36,17 +43,104 @@ type Rental struct {
 	Lat             float64    `db:"lat"`
 	Lng             float64    `db:"lng"`
 	PrimaryImageURL string     `db:"primary_image_url"`
+	Currency        string     `db:"currency"`
 	User            apiv1.User `db:"user"`
+	// Images is the gallery ordered by position, loaded by the repository with the rental.
+	Images []RentalImage `db:"-"`
+	// Amenities are the codes of the amenities of the rental ordered by code, also loaded with the
+	// rental. Writing nil Amenities keeps the stored ones.
+	Amenities []string `db:"-"`
+	// RatingAvg is the average star rating of the reviews, nil until the first review.
+	RatingAvg   *float64 `db:"rating_avg"`
+	RatingCount int      `db:"rating_count"`
 }
 
 type RentalParams struct {
 	PriceMin int
 	PriceMax int
-	Limit    int
-	Offset   int
-	IDs      []string
-	Near     utils.NearBox //[lat,lng]
-	Sort     string
+	// PriceUnit is the price column PriceMin and PriceMax apply to, price_per_day when empty.
+	// Rentals without the charge do not match.
+	PriceUnit string
+	Limit     int
+	Offset    int
+	IDs       []int
+	// UserIDs keeps the rentals owned by any of the users.
+	UserIDs []int
+	Near    utils.NearBox //[lat,lng]
+	Sort    string
+	// IncludeUser joins the owner of each rental, Rental.User stays empty otherwise.
+	IncludeUser bool
+	// Currency converts the price of every rental with the exchange rates before PriceMin, PriceMax
+	// and the price sort apply. Prices are compared in the currency of each rental when empty.
+	Currency string
+	// Amenities keeps the rentals offering every one of the amenity codes.
+	Amenities []string
+	// RatingMin keeps the rentals with an average rating of at least RatingMin, rentals without
+	// reviews do not match.
+	RatingMin float64
+}
+
+// Matches reports whether rental passes the filters of params, as FindRentals applies them.
+// Sort and pagination are ignored, and so is Currency, prices are compared as stored.
+func (p RentalParams) Matches(rental Rental) bool {
+	if p.PriceMin != 0 || p.PriceMax != 0 {
+		price := rental.price(p.priceColumn())
+		if price == nil || p.PriceMin != 0 && *price <= p.PriceMin || p.PriceMax != 0 && *price >= p.PriceMax {
+			return false
+		}
+	}
+	if len(p.IDs) > 0 && !slices.Contains(p.IDs, rental.ID) {
+		return false
+	}
+	if len(p.UserIDs) > 0 && !slices.Contains(p.UserIDs, rental.UserID) {
+		return false
+	}
+	if p.Near.MinLat != 0 && p.Near.MaxLat != 0 {
+		if rental.Lat < p.Near.MinLat || rental.Lat > p.Near.MaxLat || rental.Lng < p.Near.MinLng || rental.Lng > p.Near.MaxLng {
+			return false
+		}
+	}
+	for _, code := range p.Amenities {
+		if !slices.Contains(rental.Amenities, code) {
+			return false
+		}
+	}
+	if p.RatingMin != 0 && (rental.RatingAvg == nil || *rental.RatingAvg < p.RatingMin) {
+		return false
+	}
+	return true
+}
+
+// priceColumns are the columns holding prices, they are converted to the requested currency.
+var priceColumns = []string{"price_per_day", "price_per_week", "price_per_month", "security_deposit", "cleaning_fee", "price_per_mile"}
+
+// ratingColumn sorts rentals by descending average rating.
+const ratingColumn = "rating_avg"
+
+func (p RentalParams) priceColumn() string {
+	if p.PriceUnit == "" {
+		return "price_per_day"
+	}
+	return p.PriceUnit
+}
+
+// price returns the value of a price column, nil when the rental does not offer the charge.
+func (r Rental) price(column string) *int {
+	switch column {
+	case "price_per_day":
+		return &r.PricePerDay
+	case "price_per_week":
+		return r.PricePerWeek
+	case "price_per_month":
+		return r.PricePerMonth
+	case "security_deposit":
+		return r.SecurityDeposit
+	case "cleaning_fee":
+		return r.CleaningFee
+	case "price_per_mile":
+		return r.PricePerMile
+	}
+	return nil
 }
 
 type RentalsRepository struct {
@@ -61,10 +155,10 @@ func NewRentalsRepository(db *sqlx.DB, logger *zap.Logger) *RentalsRepository {
 	}
 }
 
-func (rr *RentalsRepository) FindRentalByID(rentalID int) (*Rental, error) {
+func (rr *RentalsRepository) FindRentalByID(ctx context.Context, rentalID int) (*Rental, error) {
 	rr.logger.Debug("Getting rental by ID", zap.Int("rentalID", rentalID))
 	rental := Rental{}
-	err := rr.db.Get(&rental,
+	err := rr.db.GetContext(ctx, &rental,
 		`SELECT r.*,
 		u.id as "user.id",
 		u.first_name as "user.first_name",
@@ -73,39 +167,71 @@ func (rr *RentalsRepository) FindRentalByID(rentalID int) (*Rental, error) {
 		JOIN users u ON r.user_id = u.id
 		WHERE r.id = $1`, rentalID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		err = translateError(err)
+		if errors.Is(err, ErrNotFound) {
 			return nil, errors.Wrap(err, fmt.Sprintf("not found rentals with id %d", rentalID))
-			//return nil, fmt.Errorf("not found rentals with ID %d: %w", rentalID, err)
 		}
 		return nil, errors.Wrap(err, fmt.Sprintf("error getting rental with id %d", rentalID))
 	}
+	galleries, err := findImages(ctx, rr.db, rentalID)
+	if err != nil {
+		return nil, err
+	}
+	rental.Images = galleries[rentalID]
+	amenities, err := findAmenities(ctx, rr.db, rentalID)
+	if err != nil {
+		return nil, err
+	}
+	rental.Amenities = amenities[rentalID]
 	return &rental, nil
 }
 
-func (rr *RentalsRepository) FindRentals(params RentalParams) ([]Rental, error) {
+func (rr *RentalsRepository) FindRentals(ctx context.Context, params RentalParams) ([]Rental, error) {
 	rr.logger.Debug("Getting rentals", zap.Any("rentalParams", params))
 	rentals := make([]Rental, 0)
 	args := make([]interface{}, 0)
 	argPosition := 1
 
 	var getRentalsQuery bytes.Buffer
-	getRentalsQuery.WriteString(
-		`SELECT r.*,
-		u.id as "user.id",
-		u.first_name as "user.first_name",
-		u.last_name as "user.last_name"
-		FROM rentals r
-		JOIN users u ON r.user_id = u.id
-		WHERE true = true `)
+	if params.IncludeUser {
+		getRentalsQuery.WriteString(
+			`SELECT r.*,
+			u.id as "user.id",
+			u.first_name as "user.first_name",
+			u.last_name as "user.last_name"
+			FROM rentals r
+			JOIN users u ON r.user_id = u.id
+			WHERE true = true `)
+	} else {
+		getRentalsQuery.WriteString(`SELECT r.* FROM rentals r WHERE true = true `)
+	}
+
+	// price reads a price column in the requested currency, rentals in a currency without a rate have none
+	currencyPosition := 0
+	price := func(column string) string {
+		if params.Currency == "" {
+			return "r." + column
+		}
+		if currencyPosition == 0 {
+			currencyPosition = argPosition
+			args = append(args, params.Currency)
+			argPosition++
+		}
+		return fmt.Sprintf(`round(r.%s *
+			(SELECT rate FROM exchange_rates WHERE currency = $%d) /
+			(SELECT rate FROM exchange_rates WHERE currency = r.currency))`, column, currencyPosition)
+	}
 
 	if params.PriceMin != 0 {
-		getRentalsQuery.WriteString(fmt.Sprintf(`AND r.price_per_day > $%d `, argPosition))
+		priceColumn := price(params.priceColumn())
+		getRentalsQuery.WriteString(fmt.Sprintf(`AND %s > $%d `, priceColumn, argPosition))
 		args = append(args, params.PriceMin)
 		argPosition++
 	}
 
 	if params.PriceMax != 0 {
-		getRentalsQuery.WriteString(fmt.Sprintf(`AND r.price_per_day < $%d `, argPosition))
+		priceColumn := price(params.priceColumn())
+		getRentalsQuery.WriteString(fmt.Sprintf(`AND %s < $%d `, priceColumn, argPosition))
 		args = append(args, params.PriceMax)
 		argPosition++
 	}
@@ -116,6 +242,12 @@ func (rr *RentalsRepository) FindRentals(params RentalParams) ([]Rental, error)
 		argPosition++
 	}
 
+	if len(params.UserIDs) > 0 {
+		getRentalsQuery.WriteString(fmt.Sprintf(`AND r.user_id = ANY ($%d) `, argPosition))
+		args = append(args, pq.Array(params.UserIDs))
+		argPosition++
+	}
+
 	if params.Near.MinLat != 0 && params.Near.MaxLat != 0 {
 		getRentalsQuery.WriteString(fmt.Sprintf(`AND (lat BETWEEN $%d AND $%d) AND (lng BETWEEN $%d AND $%d)`,
 			argPosition, argPosition+1, argPosition+2, argPosition+3))
@@ -123,7 +255,28 @@ func (rr *RentalsRepository) FindRentals(params RentalParams) ([]Rental, error)
 		argPosition += 4
 	}
 
-	if params.Sort != "" {
+	if len(params.Amenities) > 0 {
+		codes := slices.Clone(params.Amenities)
+		slices.Sort(codes)
+		codes = slices.Compact(codes)
+		getRentalsQuery.WriteString(fmt.Sprintf(`AND r.id IN (SELECT rental_id FROM rental_amenities
+			WHERE amenity_code = ANY ($%d) GROUP BY rental_id HAVING count(*) = $%d) `, argPosition, argPosition+1))
+		args = append(args, pq.Array(codes), len(codes))
+		argPosition += 2
+	}
+
+	if params.RatingMin != 0 {
+		getRentalsQuery.WriteString(fmt.Sprintf(`AND r.rating_avg >= $%d `, argPosition))
+		args = append(args, params.RatingMin)
+		argPosition++
+	}
+
+	if slices.Contains(priceColumns, params.Sort) {
+		getRentalsQuery.WriteString(fmt.Sprintf(`ORDER BY %s `, price(params.Sort)))
+	} else if params.Sort == ratingColumn {
+		// the best rated first, rentals without reviews last
+		getRentalsQuery.WriteString(`ORDER BY r.rating_avg DESC NULLS LAST, r.rating_count DESC, r.id `)
+	} else if params.Sort != "" {
 		getRentalsQuery.WriteString(fmt.Sprintf(`ORDER BY %s `, params.Sort))
 	}
 
@@ -141,12 +294,173 @@ func (rr *RentalsRepository) FindRentals(params RentalParams) ([]Rental, error)
 
 	rentalsQuery, rentalsArgs, err := sqlx.In(getRentalsQuery.String(), args...)
 	if err != nil {
-		return nil, errors.Wrap(err, "error parsing query parameters")
+		return nil, errors.Wrap(fmt.Errorf("%w: %w", ErrInvalidQuery, err), "error parsing query parameters")
 	}
-	err = rr.db.Select(&rentals, rr.db.Rebind(rentalsQuery), rentalsArgs...)
+	err = rr.db.SelectContext(ctx, &rentals, rr.db.Rebind(rentalsQuery), rentalsArgs...)
 	if err != nil {
-		return nil, errors.Wrap(err, "error getting rentals")
+		return nil, errors.Wrap(translateError(err), "error getting rentals")
+	}
+	if err := loadImages(ctx, rr.db, rentals); err != nil {
+		return nil, err
+	}
+	if err := loadAmenities(ctx, rr.db, rentals); err != nil {
+		return nil, err
 	}
 
 	return rentals, nil
 }
+
+// InsertRental stores the rental and its rental.created outbox event in one transaction.
+func (rr *RentalsRepository) InsertRental(ctx context.Context, rental *Rental) (int, error) {
+	rr.logger.Debug("Inserting rental", zap.Int("userID", rental.UserID))
+	var inserted Rental
+	err := inTx(ctx, rr.db, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowxContext(ctx,
+			`INSERT INTO rentals (user_id, name, type, description, sleeps, price_per_day,
+			price_per_week, price_per_month, security_deposit, cleaning_fee, price_per_mile,
+			home_city, home_state, home_zip, home_country,
+			vehicle_make, vehicle_model, vehicle_year, vehicle_length,
+			created, updated, lat, lng, primary_image_url, currency)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19,
+			now(), now(), $20, $21, $22, $23)
+			RETURNING *`,
+			rental.UserID, rental.Name, rental.Type, rental.Description, rental.Sleeps, rental.PricePerDay,
+			rental.PricePerWeek, rental.PricePerMonth, rental.SecurityDeposit, rental.CleaningFee, rental.PricePerMile,
+			rental.HomeCity, rental.HomeState, rental.HomeZip, rental.HomeCountry,
+			rental.VehicleMake, rental.VehicleModel, rental.VehicleYear, rental.VehicleLength,
+			rental.Lat, rental.Lng, rental.PrimaryImageURL, currencyOrDefault(rental.Currency)).StructScan(&inserted)
+		if err != nil {
+			return errors.Wrap(translateError(err), "error inserting rental")
+		}
+		if err := setPrimaryImage(ctx, tx, inserted.ID, inserted.PrimaryImageURL); err != nil {
+			return err
+		}
+		if err := setAmenities(ctx, tx, inserted.ID, rental.Amenities); err != nil {
+			return err
+		}
+		galleries, err := findImages(ctx, tx, inserted.ID)
+		if err != nil {
+			return err
+		}
+		inserted.Images = galleries[inserted.ID]
+		amenities, err := findAmenities(ctx, tx, inserted.ID)
+		if err != nil {
+			return err
+		}
+		inserted.Amenities = amenities[inserted.ID]
+		return insertOutboxEvent(ctx, tx, apiv1.EventRentalCreated, &inserted, nil)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return inserted.ID, nil
+}
+
+// UpdateRental overwrites the rental with rental.ID, provided it is still owned by rental.UserID.
+// The rental.updated outbox event is stored in the same transaction.
+func (rr *RentalsRepository) UpdateRental(ctx context.Context, rental *Rental) error {
+	rr.logger.Debug("Updating rental", zap.Int("rentalID", rental.ID))
+	return inTx(ctx, rr.db, func(tx *sqlx.Tx) error {
+		previous, err := lockRental(ctx, tx, rental.ID, rental.UserID)
+		if err != nil {
+			return err
+		}
+		var updated Rental
+		err = tx.QueryRowxContext(ctx,
+			`UPDATE rentals SET name = $3, type = $4, description = $5, sleeps = $6, price_per_day = $7,
+			price_per_week = $8, price_per_month = $9, security_deposit = $10, cleaning_fee = $11, price_per_mile = $12,
+			home_city = $13, home_state = $14, home_zip = $15, home_country = $16,
+			vehicle_make = $17, vehicle_model = $18, vehicle_year = $19, vehicle_length = $20,
+			lat = $21, lng = $22, currency = $24, updated = now(),
+			primary_image_url = CASE WHEN $23 <> '' THEN $23 ELSE COALESCE(
+				(SELECT url FROM rental_images WHERE rental_id = $1 ORDER BY position LIMIT 1), '') END
+			WHERE id = $1 AND user_id = $2
+			RETURNING *`,
+			rental.ID, rental.UserID, rental.Name, rental.Type, rental.Description, rental.Sleeps, rental.PricePerDay,
+			rental.PricePerWeek, rental.PricePerMonth, rental.SecurityDeposit, rental.CleaningFee, rental.PricePerMile,
+			rental.HomeCity, rental.HomeState, rental.HomeZip, rental.HomeCountry,
+			rental.VehicleMake, rental.VehicleModel, rental.VehicleYear, rental.VehicleLength,
+			rental.Lat, rental.Lng, rental.PrimaryImageURL, currencyOrDefault(rental.Currency)).StructScan(&updated)
+		if err != nil {
+			err = translateError(err)
+			if errors.Is(err, ErrNotFound) {
+				return errors.Wrap(err, fmt.Sprintf("not found rentals with id %d", rental.ID))
+			}
+			return errors.Wrap(err, fmt.Sprintf("error updating rental with id %d", rental.ID))
+		}
+		if err := setPrimaryImage(ctx, tx, updated.ID, updated.PrimaryImageURL); err != nil {
+			return err
+		}
+		if err := setAmenities(ctx, tx, updated.ID, rental.Amenities); err != nil {
+			return err
+		}
+		if err := loadRelations(ctx, tx, &updated); err != nil {
+			return err
+		}
+		return insertOutboxEvent(ctx, tx, apiv1.EventRentalUpdated, &updated, previous)
+	})
+}
+
+// DeleteRental removes the rental with rentalID, provided it is owned by userID. The rental.deleted
+// outbox event, with the rental as it was, is stored in the same transaction.
+func (rr *RentalsRepository) DeleteRental(ctx context.Context, rentalID, userID int) error {
+	rr.logger.Debug("Deleting rental", zap.Int("rentalID", rentalID))
+	return inTx(ctx, rr.db, func(tx *sqlx.Tx) error {
+		// the images and amenities are deleted along with the rental
+		galleries, err := findImages(ctx, tx, rentalID)
+		if err != nil {
+			return err
+		}
+		amenities, err := findAmenities(ctx, tx, rentalID)
+		if err != nil {
+			return err
+		}
+		var deleted Rental
+		err = tx.QueryRowxContext(ctx,
+			`DELETE FROM rentals WHERE id = $1 AND user_id = $2 RETURNING *`, rentalID, userID).StructScan(&deleted)
+		if err != nil {
+			err = translateError(err)
+			if errors.Is(err, ErrNotFound) {
+				return errors.Wrap(err, fmt.Sprintf("not found rentals with id %d", rentalID))
+			}
+			return errors.Wrap(err, fmt.Sprintf("error deleting rental with id %d", rentalID))
+		}
+		deleted.Images = galleries[rentalID]
+		deleted.Amenities = amenities[rentalID]
+		return insertOutboxEvent(ctx, tx, apiv1.EventRentalDeleted, &deleted, nil)
+	})
+}
+
+func currencyOrDefault(currency string) string {
+	if currency == "" {
+		return apiv1.DefaultCurrency
+	}
+	return currency
+}
+
+// inTx runs fn in a transaction, committed when fn succeeds.
+func inTx(ctx context.Context, db *sqlx.DB, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(translateError(err), "error starting transaction")
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(translateError(err), "error committing transaction")
+	}
+	return nil
+}
+
+func expectAffected(res sql.Result, notFoundMsg string) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "error reading affected rows")
+	}
+	if affected == 0 {
+		return errors.Wrap(ErrNotFound, notFoundMsg)
+	}
+	return nil
+}
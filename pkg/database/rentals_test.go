@@ -1,6 +1,8 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -9,6 +11,7 @@ import (
 )
 
 func TestRentalsRepository_FindRentals(t *testing.T) {
+	requireDB(t)
 	tests := map[string]struct {
 		params        RentalParams
 		expectedCount int
@@ -19,17 +22,50 @@ func TestRentalsRepository_FindRentals(t *testing.T) {
 		},
 		"Filter by ids": {
 			params: RentalParams{
-				IDs: []string{"1", "2"},
+				IDs: []int{1, 2},
 			},
 			expectedCount: 2,
 		},
+		"Filter by owners": {
+			params: RentalParams{
+				UserIDs: []int{1, 2},
+			},
+			expectedCount: 12,
+		},
+		"Find all rentals with owners": {
+			params: RentalParams{
+				IncludeUser: true,
+			},
+			expectedCount: 30,
+		},
+		"Filter by price in another currency": {
+			params: RentalParams{
+				PriceMin: 12000,
+				Currency: "EUR",
+			},
+			expectedCount: 15,
+		},
+		"Filter by weekly price": {
+			params: RentalParams{
+				PriceMax:  100000,
+				PriceUnit: "price_per_week",
+			},
+			expectedCount: 3,
+		},
+		"Filter by price in a currency without a rate": {
+			params: RentalParams{
+				PriceMin: 1,
+				Currency: "XYZ",
+			},
+			expectedCount: 0,
+		},
 		// more tests needs to be added
 	}
 
 	rentalsRepository := NewRentalsRepository(db, zap.NewNop())
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			rentals, err := rentalsRepository.FindRentals(test.params)
+			rentals, err := rentalsRepository.FindRentals(context.Background(), test.params)
 			require.Nil(t, err, "Error getting rentals")
 			assert.Len(t, rentals, test.expectedCount)
 		})
@@ -37,20 +73,21 @@ func TestRentalsRepository_FindRentals(t *testing.T) {
 }
 
 func TestRentalsRepository_FindRentalByID(t *testing.T) {
+	requireDB(t)
 	tests := map[string]struct {
 		ID             int
 		expectedExists bool
-		expectedError  bool
+		expectedError  error
 	}{
 		"Existing rental": {
 			ID:             30,
 			expectedExists: true,
-			expectedError:  false,
+			expectedError:  nil,
 		},
 		"Not found rental": {
 			ID:             3000,
 			expectedExists: false,
-			expectedError:  true,
+			expectedError:  ErrNotFound,
 		},
 	}
 
@@ -58,12 +95,12 @@ func TestRentalsRepository_FindRentalByID(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			rental, err := rentalsRepository.FindRentalByID(test.ID)
-			if err != nil && !test.expectedError {
+			rental, err := rentalsRepository.FindRentalByID(context.Background(), test.ID)
+			if err != nil && test.expectedError == nil {
 				t.Fatalf("Test case %s failed: %s", name, err)
 			}
-			if err == nil && test.expectedError {
-				t.Fatalf("Test case %s failed: %s", name, err)
+			if test.expectedError != nil && !errors.Is(err, test.expectedError) {
+				t.Fatalf("Test case %s failed: expected %s, got %v", name, test.expectedError, err)
 			}
 			if rental == nil && test.expectedExists {
 				t.Fatalf("Test case %s failed: %s", name, err)
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Booking is a stay booked by a user. StartDate and EndDate are dates at midnight UTC.
+type Booking struct {
+	ID        int        `db:"id"`
+	RentalID  int        `db:"rental_id"`
+	UserID    int        `db:"user_id"`
+	StartDate time.Time  `db:"start_date"`
+	EndDate   time.Time  `db:"end_date"`
+	Cancelled *time.Time `db:"cancelled"`
+	Created   time.Time  `db:"created"`
+}
+
+// Completed reports whether the stay ended by now and was not cancelled.
+func (b Booking) Completed(now time.Time) bool {
+	return b.Cancelled == nil && !b.EndDate.After(now)
+}
+
+// Review is the star rating, from 1 to 5, and text a user left for a completed booking.
+type Review struct {
+	ID        int       `db:"id"`
+	RentalID  int       `db:"rental_id"`
+	BookingID int       `db:"booking_id"`
+	UserID    int       `db:"user_id"`
+	Rating    int       `db:"rating"`
+	Text      string    `db:"text"`
+	Created   time.Time `db:"created"`
+}
+
+func (rr *RentalsRepository) FindBooking(ctx context.Context, bookingID int) (*Booking, error) {
+	rr.logger.Debug("Getting booking", zap.Int("bookingID", bookingID))
+	var booking Booking
+	err := rr.db.GetContext(ctx, &booking, `SELECT * FROM bookings WHERE id = $1`, bookingID)
+	if err != nil {
+		err = translateError(err)
+		if errors.Is(err, ErrNotFound) {
+			return nil, errors.Wrap(err, fmt.Sprintf("not found bookings with id %d", bookingID))
+		}
+		return nil, errors.Wrap(err, fmt.Sprintf("error getting booking with id %d", bookingID))
+	}
+	return &booking, nil
+}
+
+// FindReviews returns a page of the reviews of the rental, newest first.
+func (rr *RentalsRepository) FindReviews(ctx context.Context, rentalID, limit, offset int) ([]Review, error) {
+	rr.logger.Debug("Getting reviews", zap.Int("rentalID", rentalID))
+	reviews := make([]Review, 0)
+	err := rr.db.SelectContext(ctx, &reviews,
+		`SELECT * FROM reviews WHERE rental_id = $1 ORDER BY id DESC LIMIT $2 OFFSET $3`, rentalID, limit, offset)
+	if err != nil {
+		return nil, errors.Wrap(translateError(err), fmt.Sprintf("error getting reviews of rental %d", rentalID))
+	}
+	return reviews, nil
+}
+
+// InsertReview stores the review and updates the rating of its rental in one transaction. A second
+// review of the same booking gives ErrConflict.
+func (rr *RentalsRepository) InsertReview(ctx context.Context, review *Review) (*Review, error) {
+	rr.logger.Debug("Inserting review", zap.Int("rentalID", review.RentalID), zap.Int("bookingID", review.BookingID))
+	var inserted Review
+	err := inTx(ctx, rr.db, func(tx *sqlx.Tx) error {
+		// the rating is computed from all reviews, so concurrent reviews of the rental wait for each other
+		previous, err := lockRental(ctx, tx, review.RentalID, 0)
+		if err != nil {
+			return err
+		}
+		err = tx.QueryRowxContext(ctx,
+			`INSERT INTO reviews (rental_id, booking_id, user_id, rating, text)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING *`,
+			review.RentalID, review.BookingID, review.UserID, review.Rating, review.Text,
+		).StructScan(&inserted)
+		if err != nil {
+			return errors.Wrap(translateError(err), fmt.Sprintf("error inserting review of booking %d", review.BookingID))
+		}
+		_, err = tx.ExecContext(ctx,
+			`UPDATE rentals SET
+			rating_avg = (SELECT round(avg(rating), 2) FROM reviews WHERE rental_id = $1),
+			rating_count = (SELECT count(*) FROM reviews WHERE rental_id = $1)
+			WHERE id = $1`, review.RentalID)
+		if err != nil {
+			return errors.Wrap(translateError(err), fmt.Sprintf("error updating rating of rental %d", review.RentalID))
+		}
+		return touchRental(ctx, tx, previous)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &inserted, nil
+}
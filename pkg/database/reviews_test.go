@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRentalsRepository_Reviews(t *testing.T) {
+	requireDB(t)
+	ctx := context.Background()
+	rentalsRepository := NewRentalsRepository(db, zap.NewNop())
+	rating := func(rentalID int) (*float64, int) {
+		rental, err := rentalsRepository.FindRentalByID(ctx, rentalID)
+		require.Nil(t, err, "Error getting rental")
+		return rental.RatingAvg, rental.RatingCount
+	}
+
+	rentalID, err := rentalsRepository.InsertRental(ctx, &Rental{UserID: 3, Name: "Reviewed Camper", Type: "camper-van"})
+	require.Nil(t, err, "Error inserting rental")
+	defer func() {
+		require.Nil(t, rentalsRepository.DeleteRental(ctx, rentalID, 3), "Error deleting rental")
+	}()
+	avg, count := rating(rentalID)
+	assert.Nil(t, avg, "No rating without reviews")
+	assert.Equal(t, 0, count)
+
+	bookingIDs := make([]int, 3)
+	for i := range bookingIDs {
+		err := db.GetContext(ctx, &bookingIDs[i],
+			`INSERT INTO bookings (rental_id, user_id, start_date, end_date) VALUES ($1, $2, '2023-06-01', '2023-06-08')
+			RETURNING id`, rentalID, i+1)
+		require.Nil(t, err, "Error inserting booking")
+	}
+	booking, err := rentalsRepository.FindBooking(ctx, bookingIDs[0])
+	require.Nil(t, err, "Error getting booking")
+	assert.Equal(t, time.Date(2023, 6, 8, 0, 0, 0, 0, time.UTC), booking.EndDate.UTC())
+	assert.True(t, booking.Completed(time.Now()))
+	assert.False(t, booking.Completed(time.Date(2023, 6, 7, 0, 0, 0, 0, time.UTC)))
+	_, err = rentalsRepository.FindBooking(ctx, 30000)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	for i, stars := range []int{5, 4} {
+		review, err := rentalsRepository.InsertReview(ctx, &Review{RentalID: rentalID, BookingID: bookingIDs[i],
+			UserID: i + 1, Rating: stars, Text: "Nice"})
+		require.Nil(t, err, "Error inserting review")
+		assert.Equal(t, stars, review.Rating)
+	}
+	_, err = rentalsRepository.InsertReview(ctx, &Review{RentalID: rentalID, BookingID: bookingIDs[0], UserID: 1,
+		Rating: 1, Text: "Again"})
+	assert.ErrorIs(t, err, ErrConflict, "Second review of a booking")
+	_, err = rentalsRepository.InsertReview(ctx, &Review{RentalID: rentalID, BookingID: bookingIDs[2], UserID: 3,
+		Rating: 6, Text: "Too many stars"})
+	assert.NotNil(t, err, "Rating above 5")
+
+	avg, count = rating(rentalID)
+	require.NotNil(t, avg)
+	assert.Equal(t, 4.5, *avg)
+	assert.Equal(t, 2, count)
+	_, err = rentalsRepository.InsertReview(ctx, &Review{RentalID: rentalID, BookingID: bookingIDs[2], UserID: 3,
+		Rating: 3, Text: "Fine"})
+	require.Nil(t, err, "Error inserting review")
+	avg, count = rating(rentalID)
+	require.NotNil(t, avg)
+	assert.Equal(t, 4.0, *avg)
+	assert.Equal(t, 3, count)
+
+	reviews, err := rentalsRepository.FindReviews(ctx, rentalID, 2, 0)
+	require.Nil(t, err, "Error getting reviews")
+	require.Len(t, reviews, 2)
+	assert.Equal(t, []int{3, 4}, []int{reviews[0].Rating, reviews[1].Rating}, "Newest first")
+	reviews, err = rentalsRepository.FindReviews(ctx, rentalID, 2, 2)
+	require.Nil(t, err, "Error getting reviews")
+	require.Len(t, reviews, 1)
+	assert.Equal(t, 5, reviews[0].Rating)
+}
+
+func TestRentalsRepository_FindRentals_Rating(t *testing.T) {
+	requireDB(t)
+	rentalsRepository := NewRentalsRepository(db, zap.NewNop())
+	tests := map[string]struct {
+		params      RentalParams
+		expectedIDs []int
+	}{
+		"Sorted by rating": {
+			params:      RentalParams{Sort: "rating_avg", Limit: 4},
+			expectedIDs: []int{1, 2, 4, 3},
+		},
+		"Minimum rating": {
+			params:      RentalParams{RatingMin: 3, Sort: "id"},
+			expectedIDs: []int{1, 2},
+		},
+		"Minimum rating above all ratings": {
+			params:      RentalParams{RatingMin: 4.8},
+			expectedIDs: []int{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rentals, err := rentalsRepository.FindRentals(context.Background(), test.params)
+			require.Nil(t, err, "Error getting rentals")
+			rentalIDs := make([]int, 0, len(rentals))
+			for _, rental := range rentals {
+				rentalIDs = append(rentalIDs, rental.ID)
+				assert.True(t, test.params.Matches(rental), "Matches agrees with the query")
+			}
+			assert.Equal(t, test.expectedIDs, rentalIDs)
+		})
+	}
+}
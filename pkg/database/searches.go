@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/types"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type SavedSearch struct {
+	ID     int `db:"id"`
+	UserID int `db:"user_id"`
+	// Query is the query string the search was saved with, Params its parsed RentalParams as JSON.
+	Query       string         `db:"query"`
+	Params      types.JSONText `db:"params"`
+	CallbackURL string         `db:"callback_url"`
+	Secret      string         `db:"secret"`
+	// MatchedIDs are the rentals found by the last run, Checked is nil until the first run.
+	MatchedIDs pq.Int64Array `db:"matched_ids"`
+	Checked    *time.Time    `db:"checked"`
+	Created    time.Time     `db:"created"`
+}
+
+// WebhookDelivery is one attempt to deliver an event of a saved search.
+type WebhookDelivery struct {
+	ID            int       `db:"id"`
+	SavedSearchID int       `db:"saved_search_id"`
+	EventID       string    `db:"event_id"`
+	Attempt       int       `db:"attempt"`
+	StatusCode    int       `db:"status_code"`
+	Error         string    `db:"error"`
+	Created       time.Time `db:"created"`
+}
+
+type SearchesRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+func NewSearchesRepository(db *sqlx.DB, logger *zap.Logger) *SearchesRepository {
+	return &SearchesRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (sr *SearchesRepository) InsertSavedSearch(ctx context.Context, search *SavedSearch) (int, error) {
+	sr.logger.Debug("Inserting saved search", zap.Int("userID", search.UserID))
+	var searchID int
+	err := sr.db.QueryRowxContext(ctx,
+		`INSERT INTO saved_searches (user_id, query, params, callback_url, secret)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`,
+		search.UserID, search.Query, search.Params, search.CallbackURL, search.Secret).Scan(&searchID)
+	if err != nil {
+		return 0, errors.Wrap(translateError(err), "error inserting saved search")
+	}
+	return searchID, nil
+}
+
+func (sr *SearchesRepository) FindSavedSearchByID(ctx context.Context, searchID int) (*SavedSearch, error) {
+	sr.logger.Debug("Getting saved search by ID", zap.Int("searchID", searchID))
+	search := SavedSearch{}
+	err := sr.db.GetContext(ctx, &search, `SELECT * FROM saved_searches WHERE id = $1`, searchID)
+	if err != nil {
+		err = translateError(err)
+		if errors.Is(err, ErrNotFound) {
+			return nil, errors.Wrap(err, fmt.Sprintf("not found saved search with id %d", searchID))
+		}
+		return nil, errors.Wrap(err, fmt.Sprintf("error getting saved search with id %d", searchID))
+	}
+	return &search, nil
+}
+
+// FindSavedSearches returns the saved searches of userID, or of all users when userID is 0.
+func (sr *SearchesRepository) FindSavedSearches(ctx context.Context, userID int) ([]SavedSearch, error) {
+	sr.logger.Debug("Getting saved searches", zap.Int("userID", userID))
+	searches := make([]SavedSearch, 0)
+	err := sr.db.SelectContext(ctx, &searches,
+		`SELECT * FROM saved_searches WHERE $1 = 0 OR user_id = $1 ORDER BY id`, userID)
+	if err != nil {
+		return nil, errors.Wrap(translateError(err), "error getting saved searches")
+	}
+	return searches, nil
+}
+
+// DeleteSavedSearch removes the saved search with searchID and its delivery log, provided it is owned by userID.
+func (sr *SearchesRepository) DeleteSavedSearch(ctx context.Context, searchID, userID int) error {
+	sr.logger.Debug("Deleting saved search", zap.Int("searchID", searchID))
+	res, err := sr.db.ExecContext(ctx, `DELETE FROM saved_searches WHERE id = $1 AND user_id = $2`, searchID, userID)
+	if err != nil {
+		return errors.Wrap(translateError(err), fmt.Sprintf("error deleting saved search with id %d", searchID))
+	}
+	return expectAffected(res, fmt.Sprintf("not found saved search with id %d", searchID))
+}
+
+// UpdateSavedSearchMatches records the rentals found by a run of the saved search.
+func (sr *SearchesRepository) UpdateSavedSearchMatches(ctx context.Context, searchID int, rentalIDs []int) error {
+	sr.logger.Debug("Updating saved search matches", zap.Int("searchID", searchID))
+	res, err := sr.db.ExecContext(ctx,
+		`UPDATE saved_searches SET matched_ids = $2, checked = now() WHERE id = $1`, searchID, pq.Array(rentalIDs))
+	if err != nil {
+		return errors.Wrap(translateError(err), fmt.Sprintf("error updating saved search with id %d", searchID))
+	}
+	return expectAffected(res, fmt.Sprintf("not found saved search with id %d", searchID))
+}
+
+func (sr *SearchesRepository) InsertWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error {
+	_, err := sr.db.ExecContext(ctx,
+		`INSERT INTO webhook_deliveries (saved_search_id, event_id, attempt, status_code, error)
+		VALUES ($1, $2, $3, $4, $5)`,
+		delivery.SavedSearchID, delivery.EventID, delivery.Attempt, delivery.StatusCode, delivery.Error)
+	if err != nil {
+		return errors.Wrap(translateError(err), "error inserting webhook delivery")
+	}
+	return nil
+}
+
+// FindWebhookDeliveries returns the latest delivery attempts of the saved search, newest first.
+func (sr *SearchesRepository) FindWebhookDeliveries(ctx context.Context, searchID, limit int) ([]WebhookDelivery, error) {
+	sr.logger.Debug("Getting webhook deliveries", zap.Int("searchID", searchID))
+	deliveries := make([]WebhookDelivery, 0)
+	err := sr.db.SelectContext(ctx, &deliveries,
+		`SELECT * FROM webhook_deliveries WHERE saved_search_id = $1 ORDER BY id DESC LIMIT $2`, searchID, limit)
+	if err != nil {
+		return nil, errors.Wrap(translateError(err), "error getting webhook deliveries")
+	}
+	return deliveries, nil
+}
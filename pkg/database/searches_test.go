@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSearchesRepository(t *testing.T) {
+	requireDB(t)
+	ctx := context.Background()
+	searchesRepository := NewSearchesRepository(db, zap.NewNop())
+
+	searchID, err := searchesRepository.InsertSavedSearch(ctx, &SavedSearch{
+		UserID:      2,
+		Query:       "price_max=10000",
+		Params:      []byte(`{"PriceMax":10000}`),
+		CallbackURL: "http://localhost/hook",
+		Secret:      "secret",
+	})
+	require.Nil(t, err, "Error inserting saved search")
+
+	search, err := searchesRepository.FindSavedSearchByID(ctx, searchID)
+	require.Nil(t, err, "Error getting saved search")
+	assert.Equal(t, "price_max=10000", search.Query)
+	assert.JSONEq(t, `{"PriceMax":10000}`, string(search.Params))
+	assert.Empty(t, search.MatchedIDs)
+	assert.Nil(t, search.Checked)
+
+	require.Nil(t, searchesRepository.UpdateSavedSearchMatches(ctx, searchID, []int{4, 12}))
+	search, err = searchesRepository.FindSavedSearchByID(ctx, searchID)
+	require.Nil(t, err, "Error getting saved search")
+	assert.Equal(t, []int64{4, 12}, []int64(search.MatchedIDs))
+	assert.NotNil(t, search.Checked)
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		err = searchesRepository.InsertWebhookDelivery(ctx, &WebhookDelivery{
+			SavedSearchID: searchID, EventID: "evt", Attempt: attempt, StatusCode: 500,
+		})
+		require.Nil(t, err, "Error inserting webhook delivery")
+	}
+	deliveries, err := searchesRepository.FindWebhookDeliveries(ctx, searchID, 2)
+	require.Nil(t, err, "Error getting webhook deliveries")
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, 3, deliveries[0].Attempt)
+
+	searches, err := searchesRepository.FindSavedSearches(ctx, 2)
+	require.Nil(t, err, "Error getting saved searches")
+	assert.Len(t, searches, 1)
+	searches, err = searchesRepository.FindSavedSearches(ctx, 3)
+	require.Nil(t, err, "Error getting saved searches")
+	assert.Len(t, searches, 0)
+
+	err = searchesRepository.DeleteSavedSearch(ctx, searchID, 3)
+	assert.ErrorIs(t, err, ErrNotFound)
+	require.Nil(t, searchesRepository.DeleteSavedSearch(ctx, searchID, 2))
+	_, err = searchesRepository.FindSavedSearchByID(ctx, searchID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
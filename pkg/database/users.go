@@ -0,0 +1,36 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+)
+
+type UsersRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+func NewUsersRepository(db *sqlx.DB, logger *zap.Logger) *UsersRepository {
+	return &UsersRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// FindUsersByIDs returns the users with the given ids in one query, missing users are left out.
+func (ur *UsersRepository) FindUsersByIDs(ctx context.Context, userIDs []int) ([]apiv1.User, error) {
+	ur.logger.Debug("Getting users by IDs", zap.Ints("userIDs", userIDs))
+	users := make([]apiv1.User, 0, len(userIDs))
+	err := ur.db.SelectContext(ctx, &users,
+		`SELECT id, first_name, last_name FROM users WHERE id = ANY ($1) ORDER BY id`, pq.Array(userIDs))
+	if err != nil {
+		return nil, errors.Wrap(translateError(err), "error getting users")
+	}
+	return users, nil
+}
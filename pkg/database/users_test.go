@@ -0,0 +1,44 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestUsersRepository_FindUsersByIDs(t *testing.T) {
+	requireDB(t)
+	tests := map[string]struct {
+		IDs         []int
+		expectedIDs []int
+	}{
+		"Existing users": {
+			IDs:         []int{3, 1},
+			expectedIDs: []int{1, 3},
+		},
+		"Missing users are left out": {
+			IDs:         []int{2, 3000},
+			expectedIDs: []int{2},
+		},
+		"No users": {
+			IDs:         []int{},
+			expectedIDs: []int{},
+		},
+	}
+
+	usersRepository := NewUsersRepository(db, zap.NewNop())
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			users, err := usersRepository.FindUsersByIDs(context.Background(), test.IDs)
+			require.Nil(t, err, "Error getting users")
+			IDs := []int{}
+			for _, user := range users {
+				IDs = append(IDs, user.ID)
+			}
+			assert.Equal(t, test.expectedIDs, IDs)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+// Package events relays the rental changes stored in the outbox to an EventPublisher.
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/webhook"
+)
+
+// EventPublisher delivers rental events downstream. Publish returns once the event is accepted,
+// an error leaves the event in the outbox to be published again.
+type EventPublisher interface {
+	Publish(ctx context.Context, event apiv1.RentalEvent) error
+}
+
+// WriterPublisher writes every event as a line of JSON, e.g. to os.Stdout.
+type WriterPublisher struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterPublisher(w io.Writer) *WriterPublisher {
+	return &WriterPublisher{w: w}
+}
+
+func (p *WriterPublisher) Publish(_ context.Context, event apiv1.RentalEvent) error {
+	out, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error encoding event: %w", err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err = p.w.Write(append(out, '\n'))
+	return err
+}
+
+// HTTPPublisher posts every event as JSON to a URL. Events are signed like the saved search
+// webhooks when a secret is set.
+type HTTPPublisher struct {
+	url        string
+	secret     string
+	httpClient *http.Client
+}
+
+func NewHTTPPublisher(url, secret string, httpClient *http.Client) *HTTPPublisher {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &HTTPPublisher{url: url, secret: secret, httpClient: httpClient}
+}
+
+func (p *HTTPPublisher) Publish(ctx context.Context, event apiv1.RentalEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error encoding event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.EventHeader, event.Type)
+	req.Header.Set(webhook.EventIDHeader, strconv.FormatInt(event.ID, 10))
+	if p.secret != "" {
+		req.Header.Set(webhook.SignatureHeader, webhook.Sign(p.secret, time.Now(), body))
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("event endpoint answered %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// natsFlushTimeout bounds the wait for the server when ctx has no deadline.
+const natsFlushTimeout = 5 * time.Second
+
+// NATSPublisher publishes every event on "<prefix>.<event type>", e.g. rentals.rental.created.
+// The Nats-Msg-Id header carries the event id, so JetStream streams drop redelivered events.
+type NATSPublisher struct {
+	conn   *nats.Conn
+	prefix string
+}
+
+func NewNATSPublisher(conn *nats.Conn, prefix string) *NATSPublisher {
+	return &NATSPublisher{conn: conn, prefix: prefix}
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, event apiv1.RentalEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error encoding event: %w", err)
+	}
+	msg := nats.NewMsg(p.prefix + "." + event.Type)
+	msg.Header.Set(nats.MsgIdHdr, strconv.FormatInt(event.ID, 10))
+	msg.Data = body
+	if err := p.conn.PublishMsg(msg); err != nil {
+		return fmt.Errorf("error publishing event: %w", err)
+	}
+	// the flush round trip confirms the server received the event
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, natsFlushTimeout)
+		defer cancel()
+	}
+	if err := p.conn.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("error flushing event: %w", err)
+	}
+	return nil
+}
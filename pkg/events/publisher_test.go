@@ -0,0 +1,125 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/webhook"
+)
+
+func testEvent() apiv1.RentalEvent {
+	return apiv1.RentalEvent{
+		ID:       42,
+		Type:     apiv1.EventRentalUpdated,
+		RentalID: 3,
+		UserID:   1,
+		Rental:   apiv1.Rental{ID: 3, Name: "Rental", Price: apiv1.Price{Day: 9900}},
+	}
+}
+
+// runNATSServer starts an in-process NATS server on a random port.
+func runNATSServer(t *testing.T) *server.Server {
+	natsServer, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: server.RANDOM_PORT, NoLog: true, NoSigs: true})
+	require.Nil(t, err, "Error creating NATS server")
+	go natsServer.Start()
+	require.True(t, natsServer.ReadyForConnections(5*time.Second), "NATS server not ready")
+	t.Cleanup(natsServer.Shutdown)
+	return natsServer
+}
+
+func TestNATSPublisher(t *testing.T) {
+	natsServer := runNATSServer(t)
+	conn, err := nats.Connect(natsServer.ClientURL())
+	require.Nil(t, err, "Error connecting to NATS")
+	defer conn.Close()
+
+	sub, err := conn.SubscribeSync("rentals.>")
+	require.Nil(t, err, "Error subscribing")
+	require.Nil(t, conn.Flush())
+
+	publisher := NewNATSPublisher(conn, "rentals")
+	require.Nil(t, publisher.Publish(context.Background(), testEvent()))
+
+	msg, err := sub.NextMsg(time.Second)
+	require.Nil(t, err, "Error receiving event")
+	assert.Equal(t, "rentals.rental.updated", msg.Subject)
+	assert.Equal(t, "42", msg.Header.Get(nats.MsgIdHdr))
+	var event apiv1.RentalEvent
+	require.Nil(t, json.Unmarshal(msg.Data, &event), "Error decoding event")
+	assert.Equal(t, testEvent(), event)
+}
+
+func TestNATSPublisher_Closed(t *testing.T) {
+	natsServer := runNATSServer(t)
+	conn, err := nats.Connect(natsServer.ClientURL())
+	require.Nil(t, err, "Error connecting to NATS")
+	conn.Close()
+
+	err = NewNATSPublisher(conn, "rentals").Publish(context.Background(), testEvent())
+	assert.NotNil(t, err, "Expected an error publishing on a closed connection")
+}
+
+func TestHTTPPublisher(t *testing.T) {
+	tests := map[string]struct {
+		status        int
+		expectedError bool
+	}{
+		"Accepted event": {
+			status: http.StatusNoContent,
+		},
+		"Rejected event": {
+			status:        http.StatusServiceUnavailable,
+			expectedError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var received apiv1.RentalEvent
+			endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if err := webhook.Verify("secret", r.Header.Get(webhook.SignatureHeader), body, time.Minute); err != nil {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				assert.Equal(t, apiv1.EventRentalUpdated, r.Header.Get(webhook.EventHeader))
+				_ = json.Unmarshal(body, &received)
+				w.WriteHeader(test.status)
+			}))
+			defer endpoint.Close()
+
+			err := NewHTTPPublisher(endpoint.URL, "secret", nil).Publish(context.Background(), testEvent())
+			if test.expectedError {
+				assert.NotNil(t, err, "Expected an error")
+				return
+			}
+			require.Nil(t, err, "Error publishing event")
+			assert.Equal(t, testEvent(), received)
+		})
+	}
+}
+
+func TestWriterPublisher(t *testing.T) {
+	var out bytes.Buffer
+	publisher := NewWriterPublisher(&out)
+	require.Nil(t, publisher.Publish(context.Background(), testEvent()))
+	require.Nil(t, publisher.Publish(context.Background(), testEvent()))
+
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	var event apiv1.RentalEvent
+	require.Nil(t, json.Unmarshal(lines[1], &event), "Error decoding event")
+	assert.Equal(t, testEvent(), event)
+}
@@ -0,0 +1,122 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+	"github.com/mkermilska/rentals-challenge/pkg/mapper"
+)
+
+// Defaults of the zero Options fields.
+const (
+	defaultInterval    = time.Second
+	defaultBatchSize   = 100
+	defaultRetention   = 24 * time.Hour
+	defaultMaxAttempts = 10
+)
+
+type Options struct {
+	// Interval between two polls of the outbox while it is empty or the publisher fails.
+	Interval time.Duration
+	// BatchSize is the number of events published per transaction.
+	BatchSize int
+	// Retention is how long published events are kept in the outbox.
+	Retention time.Duration
+	// MaxAttempts is the number of failed publishes after which an event is set aside as failed.
+	MaxAttempts int
+}
+
+// OutboxStore is what the relay needs from database.OutboxRepository.
+type OutboxStore interface {
+	PublishEvents(ctx context.Context, limit, maxAttempts int, publish func(database.OutboxEvent) error) (int, error)
+	DeletePublishedEvents(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Relay publishes the outbox events in order. A full batch is followed by the next one right away,
+// so a backlog drains without waiting for the interval. Events that can not be decoded, or that the
+// publisher rejected MaxAttempts times, are set aside as failed so they do not block the others.
+type Relay struct {
+	interval    time.Duration
+	batchSize   int
+	retention   time.Duration
+	maxAttempts int
+	store       OutboxStore
+	publisher   EventPublisher
+	logger      *zap.Logger
+}
+
+func NewRelay(opts Options, store OutboxStore, publisher EventPublisher, logger *zap.Logger) *Relay {
+	r := &Relay{
+		interval:    opts.Interval,
+		batchSize:   opts.BatchSize,
+		retention:   opts.Retention,
+		maxAttempts: opts.MaxAttempts,
+		store:       store,
+		publisher:   publisher,
+		logger:      logger,
+	}
+	if r.interval <= 0 {
+		r.interval = defaultInterval
+	}
+	if r.batchSize <= 0 {
+		r.batchSize = defaultBatchSize
+	}
+	if r.retention <= 0 {
+		r.retention = defaultRetention
+	}
+	if r.maxAttempts <= 0 {
+		r.maxAttempts = defaultMaxAttempts
+	}
+	return r
+}
+
+// Run publishes the outbox events until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	r.logger.Info("Starting outbox relay", zap.Duration("interval", r.interval))
+	lastCleanup := time.Time{}
+	for {
+		published, err := r.publishBatch(ctx)
+		if err != nil {
+			r.logger.Error("Error publishing outbox events", zap.Int("published", published), zap.Error(err))
+		}
+		if time.Since(lastCleanup) > r.retention/10 {
+			r.cleanup(ctx)
+			lastCleanup = time.Now()
+		}
+		if err == nil && published == r.batchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.interval):
+		}
+	}
+}
+
+func (r *Relay) publishBatch(ctx context.Context) (int, error) {
+	return r.store.PublishEvents(ctx, r.batchSize, r.maxAttempts, func(outboxEvent database.OutboxEvent) error {
+		rental, err := outboxEvent.Rental()
+		if err != nil {
+			r.logger.Error("Undecodable outbox event", zap.Int64("eventID", outboxEvent.ID),
+				zap.String("payload", string(outboxEvent.Payload)), zap.Error(err))
+			return fmt.Errorf("%w: %w", database.ErrDeadLetter, err)
+		}
+		return r.publisher.Publish(ctx, *mapper.OutboxEventToRentalEvent(outboxEvent, rental))
+	})
+}
+
+func (r *Relay) cleanup(ctx context.Context) {
+	deleted, err := r.store.DeletePublishedEvents(ctx, time.Now().Add(-r.retention))
+	if err != nil {
+		r.logger.Error("Error deleting published outbox events", zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		r.logger.Debug("Deleted published outbox events", zap.Int64("deleted", deleted))
+	}
+}
@@ -0,0 +1,147 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+)
+
+// memoryOutbox publishes its events in order and sets aside dead letters, as database.OutboxRepository does.
+type memoryOutbox struct {
+	events    []database.OutboxEvent
+	published map[int64]bool
+	failed    map[int64]bool
+}
+
+func newMemoryOutbox(rentalIDs ...int) *memoryOutbox {
+	outbox := &memoryOutbox{published: map[int64]bool{}, failed: map[int64]bool{}}
+	for i, rentalID := range rentalIDs {
+		payload, _ := json.Marshal(database.Rental{ID: rentalID, UserID: 1, Name: "Rental", PricePerDay: 9900})
+		outbox.events = append(outbox.events, database.OutboxEvent{
+			ID: int64(i + 1), EventType: apiv1.EventRentalCreated, RentalID: rentalID, Payload: payload,
+		})
+	}
+	return outbox
+}
+
+func (o *memoryOutbox) PublishEvents(_ context.Context, limit, maxAttempts int,
+	publish func(database.OutboxEvent) error) (int, error) {
+	settled := 0
+	for i := range o.events {
+		event := &o.events[i]
+		if o.published[event.ID] || o.failed[event.ID] {
+			continue
+		}
+		if settled == limit {
+			break
+		}
+		if err := publish(*event); err != nil {
+			event.Attempts++
+			if !errors.Is(err, database.ErrDeadLetter) && event.Attempts < maxAttempts {
+				return settled, err
+			}
+			o.failed[event.ID] = true
+		} else {
+			o.published[event.ID] = true
+		}
+		settled++
+	}
+	return settled, nil
+}
+
+func (o *memoryOutbox) DeletePublishedEvents(_ context.Context, _ time.Time) (int64, error) {
+	return 0, nil
+}
+
+// failingPublisher rejects the events of one rental until it is fixed.
+type failingPublisher struct {
+	failRentalID int
+	events       []apiv1.RentalEvent
+}
+
+func (p *failingPublisher) Publish(_ context.Context, event apiv1.RentalEvent) error {
+	if event.RentalID == p.failRentalID {
+		return errors.New("unavailable")
+	}
+	p.events = append(p.events, event)
+	return nil
+}
+
+func TestRelay_PublishBatch(t *testing.T) {
+	outbox := newMemoryOutbox(1, 2, 3)
+	publisher := &failingPublisher{failRentalID: 2}
+	relay := NewRelay(Options{BatchSize: 2}, outbox, publisher, zap.NewNop())
+
+	published, err := relay.publishBatch(context.Background())
+	assert.NotNil(t, err, "Expected the publisher error")
+	assert.Equal(t, 1, published)
+	require.Len(t, publisher.events, 1, "Events after a failed one wait for it")
+
+	publisher.failRentalID = 0
+	published, err = relay.publishBatch(context.Background())
+	require.Nil(t, err, "Error publishing events")
+	assert.Equal(t, 2, published)
+
+	require.Len(t, publisher.events, 3)
+	for i, event := range publisher.events {
+		assert.Equal(t, int64(i+1), event.ID)
+		assert.Equal(t, i+1, event.RentalID)
+		assert.Equal(t, apiv1.EventRentalCreated, event.Type)
+		assert.Equal(t, 1, event.UserID)
+		assert.Equal(t, 9900, event.Rental.Price.Day)
+	}
+}
+
+func TestRelay_PublishBatch_DeadLetters(t *testing.T) {
+	outbox := newMemoryOutbox(1, 2, 3)
+	outbox.events[0].Payload = []byte(`{"id": "corrupt"`)
+	publisher := &failingPublisher{failRentalID: 2}
+	relay := NewRelay(Options{MaxAttempts: 2}, outbox, publisher, zap.NewNop())
+
+	published, err := relay.publishBatch(context.Background())
+	assert.NotNil(t, err, "Expected the publisher error")
+	assert.Equal(t, 1, published, "The corrupt event is set aside right away")
+	assert.True(t, outbox.failed[1])
+	assert.Empty(t, publisher.events)
+
+	published, err = relay.publishBatch(context.Background())
+	require.Nil(t, err, "Error publishing events")
+	assert.Equal(t, 2, published, "The rejected event is set aside after MaxAttempts")
+	assert.True(t, outbox.failed[2])
+	require.Len(t, publisher.events, 1)
+	assert.Equal(t, 3, publisher.events[0].RentalID)
+}
+
+func TestRelay_RunWithNATS(t *testing.T) {
+	natsServer := runNATSServer(t)
+	conn, err := nats.Connect(natsServer.ClientURL())
+	require.Nil(t, err, "Error connecting to NATS")
+	defer conn.Close()
+	sub, err := conn.SubscribeSync("rentals.rental.created")
+	require.Nil(t, err, "Error subscribing")
+	require.Nil(t, conn.Flush())
+
+	outbox := newMemoryOutbox(1, 2, 3, 4, 5)
+	relay := NewRelay(Options{BatchSize: 2, Interval: 10 * time.Millisecond}, outbox, NewNATSPublisher(conn, "rentals"), zap.NewNop())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go relay.Run(ctx)
+
+	for expectedID := 1; expectedID <= 5; expectedID++ {
+		msg, err := sub.NextMsg(time.Second)
+		require.Nil(t, err, "Error receiving event")
+		var event apiv1.RentalEvent
+		require.Nil(t, json.Unmarshal(msg.Data, &event), "Error decoding event")
+		assert.Equal(t, expectedID, event.RentalID)
+	}
+}
@@ -0,0 +1,18 @@
+package mapper
+
+import (
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+)
+
+// OutboxEventToRentalEvent takes the rental decoded from the payload of event.
+func OutboxEventToRentalEvent(event database.OutboxEvent, rental database.Rental) *apiv1.RentalEvent {
+	return &apiv1.RentalEvent{
+		ID:       event.ID,
+		Type:     event.EventType,
+		RentalID: event.RentalID,
+		UserID:   rental.UserID,
+		Occurred: event.Created,
+		Rental:   *RentalToAPIRental(rental),
+	}
+}
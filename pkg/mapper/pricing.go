@@ -0,0 +1,104 @@
+package mapper
+
+import (
+	"fmt"
+	"time"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+	"github.com/mkermilska/rentals-challenge/pkg/pricing"
+)
+
+func RentalPricingToAPIPricingRules(rentalPricing database.RentalPricing) *apiv1.PricingRules {
+	rules := &apiv1.PricingRules{
+		Seasons:                 make([]apiv1.Season, len(rentalPricing.Seasons)),
+		WeekendSurchargePercent: rentalPricing.WeekendSurchargePercent,
+		WeeklyDiscountPercent:   rentalPricing.WeeklyDiscountPercent,
+		MonthlyDiscountPercent:  rentalPricing.MonthlyDiscountPercent,
+		MinNights:               rentalPricing.MinNights,
+	}
+	for i, season := range rentalPricing.Seasons {
+		rules.Seasons[i] = apiv1.Season{
+			Name:        season.Name,
+			Start:       season.StartDate.Format(pricing.DateLayout),
+			End:         season.EndDate.Format(pricing.DateLayout),
+			PricePerDay: season.PricePerDay,
+			MinNights:   season.MinNights,
+		}
+	}
+	return rules
+}
+
+// APIPricingRulesToRentalPricing fails on season dates not formatted as pricing.DateLayout.
+func APIPricingRulesToRentalPricing(rentalID int, rules apiv1.PricingRules) (*database.RentalPricing, error) {
+	rentalPricing := &database.RentalPricing{
+		RentalID:                rentalID,
+		Seasons:                 make([]database.RentalSeason, len(rules.Seasons)),
+		WeekendSurchargePercent: rules.WeekendSurchargePercent,
+		WeeklyDiscountPercent:   rules.WeeklyDiscountPercent,
+		MonthlyDiscountPercent:  rules.MonthlyDiscountPercent,
+		MinNights:               max(rules.MinNights, 1),
+	}
+	for i, season := range rules.Seasons {
+		start, err := time.Parse(pricing.DateLayout, season.Start)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid start of season %q", pricing.ErrInvalidRules, season.Name)
+		}
+		end, err := time.Parse(pricing.DateLayout, season.End)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid end of season %q", pricing.ErrInvalidRules, season.Name)
+		}
+		rentalPricing.Seasons[i] = database.RentalSeason{
+			RentalID:    rentalID,
+			Name:        season.Name,
+			StartDate:   start,
+			EndDate:     end,
+			PricePerDay: season.PricePerDay,
+			MinNights:   season.MinNights,
+		}
+	}
+	return rentalPricing, nil
+}
+
+// RentalPricingToRules prices the nights outside the seasons at pricePerDay.
+func RentalPricingToRules(pricePerDay int, rentalPricing database.RentalPricing) pricing.Rules {
+	rules := pricing.Rules{
+		PricePerDay:             pricePerDay,
+		Seasons:                 make([]pricing.Season, len(rentalPricing.Seasons)),
+		WeekendSurchargePercent: rentalPricing.WeekendSurchargePercent,
+		WeeklyDiscountPercent:   rentalPricing.WeeklyDiscountPercent,
+		MonthlyDiscountPercent:  rentalPricing.MonthlyDiscountPercent,
+		MinNights:               rentalPricing.MinNights,
+	}
+	for i, season := range rentalPricing.Seasons {
+		rules.Seasons[i] = pricing.Season{
+			Name:        season.Name,
+			Start:       season.StartDate,
+			End:         season.EndDate,
+			PricePerDay: season.PricePerDay,
+			MinNights:   season.MinNights,
+		}
+	}
+	return rules
+}
+
+func QuoteToAPIQuote(rentalID int, quote pricing.Quote) *apiv1.Quote {
+	apiQuote := &apiv1.Quote{
+		RentalID:  rentalID,
+		From:      quote.From.Format(pricing.DateLayout),
+		To:        quote.To.Format(pricing.DateLayout),
+		Nights:    quote.Nights,
+		LineItems: make([]apiv1.QuoteLineItem, len(quote.LineItems)),
+		Total:     quote.Total,
+	}
+	for i, item := range quote.LineItems {
+		apiQuote.LineItems[i] = apiv1.QuoteLineItem{
+			Kind:        item.Kind,
+			Description: item.Description,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			Amount:      item.Amount,
+		}
+	}
+	return apiQuote
+}
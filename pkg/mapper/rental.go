@@ -1,12 +1,15 @@
 package mapper
 
 import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/mkermilska/rentals-challenge/api/rentalspb"
 	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
 	"github.com/mkermilska/rentals-challenge/pkg/database"
 )
 
 func RentalToAPIRental(rental database.Rental) *apiv1.Rental {
-	return &apiv1.Rental{
+	apiRental := &apiv1.Rental{
 		ID:              rental.ID,
 		Name:            rental.Name,
 		Description:     rental.Description,
@@ -18,7 +21,13 @@ func RentalToAPIRental(rental database.Rental) *apiv1.Rental {
 		Sleeps:          rental.Sleeps,
 		PrimaryImageURL: rental.PrimaryImageURL,
 		Price: apiv1.Price{
-			Day: rental.PricePerDay,
+			Day:             rental.PricePerDay,
+			Week:            rental.PricePerWeek,
+			Month:           rental.PricePerMonth,
+			SecurityDeposit: rental.SecurityDeposit,
+			CleaningFee:     rental.CleaningFee,
+			PerMile:         rental.PricePerMile,
+			Currency:        currencyOrDefault(rental.Currency),
 		},
 		Location: apiv1.Location{
 			City:    rental.HomeCity,
@@ -28,12 +37,22 @@ func RentalToAPIRental(rental database.Rental) *apiv1.Rental {
 			Lat:     rental.Lat,
 			Lng:     rental.Lng,
 		},
-		User: apiv1.User{
+		Images:      RentalImagesToAPIRentalImages(rental.Images),
+		Amenities:   amenitiesOrEmpty(rental.Amenities),
+		RatingAvg:   rental.RatingAvg,
+		RatingCount: rental.RatingCount,
+		UserID:      rental.UserID,
+		Updated:     rental.Updated,
+	}
+	// the owner is only joined when requested, users.id is never 0 otherwise
+	if rental.User.ID != 0 {
+		apiRental.User = &apiv1.User{
 			ID:        rental.User.ID,
 			FirstName: rental.User.FirstName,
 			LastName:  rental.User.LastName,
-		},
+		}
 	}
+	return apiRental
 }
 
 func RentalsToAPIRentals(rentals []database.Rental) []apiv1.Rental {
@@ -45,3 +64,131 @@ func RentalsToAPIRentals(rentals []database.Rental) []apiv1.Rental {
 	}
 	return apiRentals
 }
+
+// RentalImagesToAPIRentalImages never returns nil, a rental without images has an empty gallery.
+func RentalImagesToAPIRentalImages(images []database.RentalImage) []apiv1.RentalImage {
+	apiImages := make([]apiv1.RentalImage, len(images))
+	for i, image := range images {
+		apiImages[i] = *RentalImageToAPIRentalImage(image)
+	}
+	return apiImages
+}
+
+func RentalImageToAPIRentalImage(image database.RentalImage) *apiv1.RentalImage {
+	apiImage := &apiv1.RentalImage{
+		ID:       image.ID,
+		URL:      image.URL,
+		Position: image.Position,
+		Caption:  image.Caption,
+		Width:    image.Width,
+		Height:   image.Height,
+	}
+	for _, variant := range image.Variants {
+		apiImage.Variants = append(apiImage.Variants, apiv1.ImageVariant{
+			Name:   variant.Name,
+			URL:    variant.URL,
+			Width:  variant.Width,
+			Height: variant.Height,
+		})
+	}
+	return apiImage
+}
+
+func APIRentalImageInputToRentalImage(rentalID int, input apiv1.RentalImageInput) *database.RentalImage {
+	return &database.RentalImage{
+		RentalID: rentalID,
+		URL:      input.URL,
+		Caption:  input.Caption,
+		Width:    input.Width,
+		Height:   input.Height,
+	}
+}
+
+func APIRentalInputToRental(input apiv1.RentalInput) *database.Rental {
+	return &database.Rental{
+		Name:            input.Name,
+		Type:            input.Type,
+		Description:     input.Description,
+		Sleeps:          input.Sleeps,
+		PricePerDay:     input.Price.Day,
+		PricePerWeek:    input.Price.Week,
+		PricePerMonth:   input.Price.Month,
+		SecurityDeposit: input.Price.SecurityDeposit,
+		CleaningFee:     input.Price.CleaningFee,
+		PricePerMile:    input.Price.PerMile,
+		HomeCity:        input.Location.City,
+		HomeState:       input.Location.State,
+		HomeZip:         input.Location.Zip,
+		HomeCountry:     input.Location.Country,
+		VehicleMake:     input.Make,
+		VehicleModel:    input.Model,
+		VehicleYear:     input.Year,
+		VehicleLength:   input.Length,
+		Lat:             input.Location.Lat,
+		Lng:             input.Location.Lng,
+		PrimaryImageURL: input.PrimaryImageURL,
+		Currency:        currencyOrDefault(input.Price.Currency),
+		Amenities:       input.Amenities,
+	}
+}
+
+// amenitiesOrEmpty never returns nil, a rental without amenities has an empty list.
+func amenitiesOrEmpty(amenities []string) []string {
+	if amenities == nil {
+		return []string{}
+	}
+	return amenities
+}
+
+func AmenitiesToAPIAmenities(amenities []database.Amenity) []apiv1.Amenity {
+	apiAmenities := make([]apiv1.Amenity, len(amenities))
+	for i, amenity := range amenities {
+		apiAmenities[i] = apiv1.Amenity{Code: amenity.Code, Name: amenity.Name, Category: amenity.Category}
+	}
+	return apiAmenities
+}
+
+// currencyOrDefault also covers outbox events stored before rentals had a currency.
+func currencyOrDefault(currency string) string {
+	if currency == "" {
+		return apiv1.DefaultCurrency
+	}
+	return currency
+}
+
+func APIRentalToProto(rental apiv1.Rental) *rentalspb.Rental {
+	protoRental := &rentalspb.Rental{
+		Id:              int64(rental.ID),
+		Name:            rental.Name,
+		Description:     rental.Description,
+		Type:            rental.Type,
+		Make:            rental.Make,
+		Model:           rental.Model,
+		Year:            int32(rental.Year),
+		Length:          rental.Length,
+		Sleeps:          int32(rental.Sleeps),
+		PrimaryImageUrl: rental.PrimaryImageURL,
+		Price: &rentalspb.Price{
+			Day: int64(rental.Price.Day),
+		},
+		Location: &rentalspb.Location{
+			City:    rental.Location.City,
+			State:   rental.Location.State,
+			Zip:     rental.Location.Zip,
+			Country: rental.Location.Country,
+			Lat:     rental.Location.Lat,
+			Lng:     rental.Location.Lng,
+		},
+	}
+	if !rental.Updated.IsZero() {
+		protoRental.Updated = timestamppb.New(rental.Updated)
+	}
+	if rental.User != nil {
+		protoRental.User = &rentalspb.User{
+			Id:        int64(rental.User.ID),
+			FirstName: rental.User.FirstName,
+			LastName:  rental.User.LastName,
+		}
+	}
+	return protoRental
+}
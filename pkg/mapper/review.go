@@ -0,0 +1,26 @@
+package mapper
+
+import (
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+)
+
+func ReviewToAPIReview(review database.Review) *apiv1.Review {
+	return &apiv1.Review{
+		ID:        review.ID,
+		RentalID:  review.RentalID,
+		BookingID: review.BookingID,
+		UserID:    review.UserID,
+		Rating:    review.Rating,
+		Text:      review.Text,
+		Created:   review.Created,
+	}
+}
+
+func ReviewsToAPIReviews(reviews []database.Review) []apiv1.Review {
+	apiReviews := make([]apiv1.Review, len(reviews))
+	for i, review := range reviews {
+		apiReviews[i] = *ReviewToAPIReview(review)
+	}
+	return apiReviews
+}
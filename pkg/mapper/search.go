@@ -0,0 +1,41 @@
+package mapper
+
+import (
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+)
+
+// SavedSearchToAPISavedSearch leaves out the secret, it is only returned when a search is created.
+func SavedSearchToAPISavedSearch(search database.SavedSearch) *apiv1.SavedSearch {
+	return &apiv1.SavedSearch{
+		ID:          search.ID,
+		UserID:      search.UserID,
+		Query:       search.Query,
+		CallbackURL: search.CallbackURL,
+		Checked:     search.Checked,
+		Created:     search.Created,
+	}
+}
+
+func SavedSearchesToAPISavedSearches(searches []database.SavedSearch) []apiv1.SavedSearch {
+	apiSearches := make([]apiv1.SavedSearch, len(searches))
+	for i, search := range searches {
+		apiSearches[i] = *SavedSearchToAPISavedSearch(search)
+	}
+	return apiSearches
+}
+
+func WebhookDeliveriesToAPIWebhookDeliveries(deliveries []database.WebhookDelivery) []apiv1.WebhookDelivery {
+	apiDeliveries := make([]apiv1.WebhookDelivery, len(deliveries))
+	for i, delivery := range deliveries {
+		apiDeliveries[i] = apiv1.WebhookDelivery{
+			ID:         delivery.ID,
+			EventID:    delivery.EventID,
+			Attempt:    delivery.Attempt,
+			StatusCode: delivery.StatusCode,
+			Error:      delivery.Error,
+			Created:    delivery.Created,
+		}
+	}
+	return apiDeliveries
+}
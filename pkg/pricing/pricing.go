@@ -0,0 +1,206 @@
+// Package pricing computes the price of a stay from the pricing rules of a rental.
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+)
+
+// DateLayout is the format of the dates of seasons and quotes.
+const DateLayout = "2006-01-02"
+
+// MaxNights is the longest stay that can be quoted.
+const MaxNights = 365
+
+// Stays of at least these nights get the weekly and monthly discounts.
+const (
+	weekNights  = 7
+	monthNights = 28
+)
+
+// Kinds of the line items of a quote.
+const (
+	ItemNights           = apiv1.QuoteItemNights
+	ItemSeasonNights     = apiv1.QuoteItemSeasonNights
+	ItemWeekendSurcharge = apiv1.QuoteItemWeekendSurcharge
+	ItemWeeklyDiscount   = apiv1.QuoteItemWeeklyDiscount
+	ItemMonthlyDiscount  = apiv1.QuoteItemMonthlyDiscount
+)
+
+var (
+	// ErrInvalidStay is returned for stays that end before they start or are longer than MaxNights.
+	ErrInvalidStay = errors.New("invalid stay")
+	// ErrMinNights is returned for stays shorter than the minimum nights of the rental or of a season.
+	ErrMinNights = errors.New("stay is shorter than the minimum nights")
+	// ErrInvalidRules is returned by Rules.Validate.
+	ErrInvalidRules = errors.New("invalid pricing rules")
+)
+
+// Rules price the nights of a rental. Prices are in cents.
+type Rules struct {
+	// PricePerDay is the rate of the nights outside every season.
+	PricePerDay int
+	Seasons     []Season
+	// WeekendSurchargePercent is added to the rate of Friday and Saturday nights.
+	WeekendSurchargePercent int
+	// WeeklyDiscountPercent is taken off stays of 7 nights or more.
+	WeeklyDiscountPercent int
+	// MonthlyDiscountPercent is taken off stays of 28 nights or more, instead of the weekly discount.
+	MonthlyDiscountPercent int
+	// MinNights is the shortest stay allowed, 1 when zero.
+	MinNights int
+}
+
+// Season replaces PricePerDay for the nights from Start to End, both included.
+type Season struct {
+	Name        string
+	Start       time.Time
+	End         time.Time
+	PricePerDay int
+	// MinNights is the shortest stay that includes a night of the season, the rental's minimum when zero.
+	MinNights int
+}
+
+type LineItem struct {
+	Kind        string
+	Description string
+	// Quantity is the number of nights the item applies to.
+	Quantity int
+	// UnitPrice is the price per night, zero for discounts.
+	UnitPrice int
+	Amount    int
+}
+
+type Quote struct {
+	From      time.Time
+	To        time.Time
+	Nights    int
+	LineItems []LineItem
+	Total     int
+}
+
+// Validate checks the ranges of the rules and that seasons do not overlap.
+func (r Rules) Validate() error {
+	switch {
+	case r.WeekendSurchargePercent < 0 || r.WeekendSurchargePercent > 1000:
+		return fmt.Errorf("%w: weekend surcharge must be between 0 and 1000 percent", ErrInvalidRules)
+	case r.WeeklyDiscountPercent < 0 || r.WeeklyDiscountPercent > 100:
+		return fmt.Errorf("%w: weekly discount must be between 0 and 100 percent", ErrInvalidRules)
+	case r.MonthlyDiscountPercent < 0 || r.MonthlyDiscountPercent > 100:
+		return fmt.Errorf("%w: monthly discount must be between 0 and 100 percent", ErrInvalidRules)
+	case r.MinNights < 0:
+		return fmt.Errorf("%w: minimum nights must not be negative", ErrInvalidRules)
+	}
+	for i, season := range r.Seasons {
+		switch {
+		case season.Name == "":
+			return fmt.Errorf("%w: season %d has no name", ErrInvalidRules, i)
+		case season.End.Before(season.Start):
+			return fmt.Errorf("%w: season %q ends before it starts", ErrInvalidRules, season.Name)
+		case season.PricePerDay < 0:
+			return fmt.Errorf("%w: season %q has a negative price", ErrInvalidRules, season.Name)
+		case season.MinNights < 0:
+			return fmt.Errorf("%w: season %q has negative minimum nights", ErrInvalidRules, season.Name)
+		}
+		for _, other := range r.Seasons[:i] {
+			if !season.Start.After(other.End) && !other.Start.After(season.End) {
+				return fmt.Errorf("%w: seasons %q and %q overlap", ErrInvalidRules, other.Name, season.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// NewQuote prices the nights from the from date up to the to date, the day of departure.
+// Nights are grouped by rate into line items, followed by the weekend surcharge and the discount.
+func NewQuote(rules Rules, from, to time.Time) (*Quote, error) {
+	from, to = truncateDay(from), truncateDay(to)
+	nights := int(to.Sub(from).Hours() / 24)
+	if nights < 1 || nights > MaxNights {
+		return nil, fmt.Errorf("%w: a stay lasts from 1 to %d nights", ErrInvalidStay, MaxNights)
+	}
+
+	minNights := max(rules.MinNights, 1)
+	quote := &Quote{From: from, To: to, Nights: nights}
+	// line items of the nights, in the order their rates first apply
+	itemIndex := map[string]int{}
+	weekendNights, weekendSurcharge := 0, 0
+	for night := from; night.Before(to); night = night.AddDate(0, 0, 1) {
+		item := LineItem{Kind: ItemNights, Description: "Nights", UnitPrice: rules.PricePerDay}
+		if season, ok := rules.seasonOf(night); ok {
+			item = LineItem{Kind: ItemSeasonNights, Description: season.Name, UnitPrice: season.PricePerDay}
+			minNights = max(minNights, season.MinNights)
+		}
+		key := fmt.Sprintf("%s/%s/%d", item.Kind, item.Description, item.UnitPrice)
+		i, ok := itemIndex[key]
+		if !ok {
+			i = len(quote.LineItems)
+			itemIndex[key] = i
+			quote.LineItems = append(quote.LineItems, item)
+		}
+		quote.LineItems[i].Quantity++
+		quote.LineItems[i].Amount += item.UnitPrice
+
+		if weekday := night.Weekday(); weekday == time.Friday || weekday == time.Saturday {
+			weekendNights++
+			weekendSurcharge += percentOf(item.UnitPrice, rules.WeekendSurchargePercent)
+		}
+	}
+	if nights < minNights {
+		return nil, fmt.Errorf("%w: the stay has %d nights, at least %d are required", ErrMinNights, nights, minNights)
+	}
+
+	if weekendSurcharge > 0 {
+		quote.LineItems = append(quote.LineItems, LineItem{
+			Kind:        ItemWeekendSurcharge,
+			Description: fmt.Sprintf("Weekend surcharge (%d%%)", rules.WeekendSurchargePercent),
+			Quantity:    weekendNights,
+			Amount:      weekendSurcharge,
+		})
+	}
+	subtotal := 0
+	for _, item := range quote.LineItems {
+		subtotal += item.Amount
+	}
+	switch {
+	case nights >= monthNights && rules.MonthlyDiscountPercent > 0:
+		quote.LineItems = append(quote.LineItems, LineItem{
+			Kind:        ItemMonthlyDiscount,
+			Description: fmt.Sprintf("Monthly discount (%d%%)", rules.MonthlyDiscountPercent),
+			Quantity:    nights,
+			Amount:      -percentOf(subtotal, rules.MonthlyDiscountPercent),
+		})
+	case nights >= weekNights && rules.WeeklyDiscountPercent > 0:
+		quote.LineItems = append(quote.LineItems, LineItem{
+			Kind:        ItemWeeklyDiscount,
+			Description: fmt.Sprintf("Weekly discount (%d%%)", rules.WeeklyDiscountPercent),
+			Quantity:    nights,
+			Amount:      -percentOf(subtotal, rules.WeeklyDiscountPercent),
+		})
+	}
+	for _, item := range quote.LineItems {
+		quote.Total += item.Amount
+	}
+	return quote, nil
+}
+
+func (r Rules) seasonOf(night time.Time) (Season, bool) {
+	for _, season := range r.Seasons {
+		if !night.Before(truncateDay(season.Start)) && !night.After(truncateDay(season.End)) {
+			return season, true
+		}
+	}
+	return Season{}, false
+}
+
+// percentOf rounds half up to the cent.
+func percentOf(amount, percent int) int {
+	return (amount*percent + 50) / 100
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,164 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(value string) time.Time {
+	t, err := time.Parse(DateLayout, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func testRules() Rules {
+	return Rules{
+		PricePerDay: 10000,
+		Seasons: []Season{
+			{Name: "Summer", Start: date("2024-07-01"), End: date("2024-08-31"), PricePerDay: 15000, MinNights: 3},
+		},
+		WeekendSurchargePercent: 20,
+		WeeklyDiscountPercent:   10,
+		MonthlyDiscountPercent:  25,
+		MinNights:               2,
+	}
+}
+
+func TestNewQuote(t *testing.T) {
+	tests := map[string]struct {
+		from          string
+		to            string
+		expectedItems []LineItem
+		expectedTotal int
+		expectedError error
+	}{
+		"Weekday nights": {
+			from: "2024-06-03",
+			to:   "2024-06-05",
+			expectedItems: []LineItem{
+				{Kind: ItemNights, Description: "Nights", Quantity: 2, UnitPrice: 10000, Amount: 20000},
+			},
+			expectedTotal: 20000,
+		},
+		"Weekend surcharge": {
+			from: "2024-06-06",
+			to:   "2024-06-09",
+			expectedItems: []LineItem{
+				{Kind: ItemNights, Description: "Nights", Quantity: 3, UnitPrice: 10000, Amount: 30000},
+				{Kind: ItemWeekendSurcharge, Description: "Weekend surcharge (20%)", Quantity: 2, Amount: 4000},
+			},
+			expectedTotal: 34000,
+		},
+		"Stay across the start of a season": {
+			from: "2024-06-29",
+			to:   "2024-07-02",
+			expectedItems: []LineItem{
+				{Kind: ItemNights, Description: "Nights", Quantity: 2, UnitPrice: 10000, Amount: 20000},
+				{Kind: ItemSeasonNights, Description: "Summer", Quantity: 1, UnitPrice: 15000, Amount: 15000},
+				{Kind: ItemWeekendSurcharge, Description: "Weekend surcharge (20%)", Quantity: 1, Amount: 2000},
+			},
+			expectedTotal: 37000,
+		},
+		"Weekly discount": {
+			from: "2024-06-03",
+			to:   "2024-06-10",
+			expectedItems: []LineItem{
+				{Kind: ItemNights, Description: "Nights", Quantity: 7, UnitPrice: 10000, Amount: 70000},
+				{Kind: ItemWeekendSurcharge, Description: "Weekend surcharge (20%)", Quantity: 2, Amount: 4000},
+				{Kind: ItemWeeklyDiscount, Description: "Weekly discount (10%)", Quantity: 7, Amount: -7400},
+			},
+			expectedTotal: 66600,
+		},
+		"Monthly discount replaces the weekly one": {
+			from: "2024-06-03",
+			to:   "2024-07-01",
+			expectedItems: []LineItem{
+				{Kind: ItemNights, Description: "Nights", Quantity: 28, UnitPrice: 10000, Amount: 280000},
+				{Kind: ItemWeekendSurcharge, Description: "Weekend surcharge (20%)", Quantity: 8, Amount: 16000},
+				{Kind: ItemMonthlyDiscount, Description: "Monthly discount (25%)", Quantity: 28, Amount: -74000},
+			},
+			expectedTotal: 222000,
+		},
+		"Shorter than the minimum nights": {
+			from:          "2024-06-03",
+			to:            "2024-06-04",
+			expectedError: ErrMinNights,
+		},
+		"Shorter than the minimum nights of the season": {
+			from:          "2024-07-01",
+			to:            "2024-07-03",
+			expectedError: ErrMinNights,
+		},
+		"Departure before arrival": {
+			from:          "2024-06-05",
+			to:            "2024-06-03",
+			expectedError: ErrInvalidStay,
+		},
+		"Too long stay": {
+			from:          "2024-01-01",
+			to:            "2025-06-01",
+			expectedError: ErrInvalidStay,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			quote, err := NewQuote(testRules(), date(test.from), date(test.to))
+			if test.expectedError != nil {
+				assert.ErrorIs(t, err, test.expectedError)
+				return
+			}
+			require.Nil(t, err, "Error quoting stay")
+			assert.Equal(t, test.expectedItems, quote.LineItems)
+			assert.Equal(t, test.expectedTotal, quote.Total)
+			assert.Equal(t, int(date(test.to).Sub(date(test.from)).Hours()/24), quote.Nights)
+		})
+	}
+}
+
+func TestRules_Validate(t *testing.T) {
+	tests := map[string]struct {
+		rules         func(*Rules)
+		expectedError bool
+	}{
+		"Valid rules": {
+			rules: func(*Rules) {},
+		},
+		"Overlapping seasons": {
+			rules: func(r *Rules) {
+				r.Seasons = append(r.Seasons, Season{Name: "Late summer", Start: date("2024-08-31"), End: date("2024-09-15")})
+			},
+			expectedError: true,
+		},
+		"Season ending before it starts": {
+			rules: func(r *Rules) {
+				r.Seasons[0].End = date("2024-06-01")
+			},
+			expectedError: true,
+		},
+		"Discount above 100 percent": {
+			rules: func(r *Rules) {
+				r.WeeklyDiscountPercent = 120
+			},
+			expectedError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rules := testRules()
+			test.rules(&rules)
+			err := rules.Validate()
+			if test.expectedError {
+				assert.ErrorIs(t, err, ErrInvalidRules)
+				return
+			}
+			assert.Nil(t, err)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := New(Limit{Rate: 1, Burst: 2})
+	limiter.now = func() time.Time { return now }
+
+	result := limiter.Allow("client-a")
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+
+	result = limiter.Allow("client-a")
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, 2*time.Second, result.Reset)
+
+	result = limiter.Allow("client-a")
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+
+	result = limiter.Allow("client-b")
+	assert.True(t, result.Allowed, "Buckets are kept per key")
+
+	now = now.Add(time.Second)
+	result = limiter.Allow("client-a")
+	assert.True(t, result.Allowed, "Bucket refills with time")
+
+	now = now.Add(time.Minute)
+	limiter.Allow("client-a")
+	assert.Len(t, limiter.buckets, 1, "Idle buckets are swept")
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := map[string]struct {
+		value         string
+		expectedLimit Limit
+		expectedError bool
+	}{
+		"Per second": {
+			value:         "5/s",
+			expectedLimit: Limit{Rate: 5, Burst: 5},
+		},
+		"Per minute": {
+			value:         "120/m",
+			expectedLimit: Limit{Rate: 2, Burst: 120},
+		},
+		"Invalid unit": {
+			value:         "5/d",
+			expectedError: true,
+		},
+		"Missing unit": {
+			value:         "5",
+			expectedError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			limit, err := ParseLimit(test.value)
+			if test.expectedError {
+				assert.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err, "Error parsing limit")
+			assert.Equal(t, test.expectedLimit, limit)
+		})
+	}
+}
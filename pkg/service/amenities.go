@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/mapper"
+)
+
+// ErrUnknownAmenity is returned when a rental lists an amenity missing from the catalogue.
+var ErrUnknownAmenity = errors.New("unknown amenity")
+
+// GetAmenities returns the catalogue of amenities ordered by code.
+func (r *RentalService) GetAmenities(ctx context.Context) ([]apiv1.Amenity, error) {
+	amenities, err := r.rentalsRepository.FindAmenities(ctx)
+	if err != nil {
+		r.logger.Error("Error getting amenities", zap.Error(err))
+		return nil, err
+	}
+	return mapper.AmenitiesToAPIAmenities(amenities), nil
+}
+
+// checkAmenities reports the first of codes missing from the catalogue with ErrUnknownAmenity.
+func (r *RentalService) checkAmenities(ctx context.Context, codes []string) error {
+	if len(codes) == 0 {
+		return nil
+	}
+	amenities, err := r.GetAmenities(ctx)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(amenities))
+	for _, amenity := range amenities {
+		known[amenity.Code] = true
+	}
+	for _, code := range codes {
+		if !known[code] {
+			return fmt.Errorf("%w: %q is not in the catalogue", ErrUnknownAmenity, code)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/cache"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+)
+
+type CacheStats struct {
+	Rental  cache.Stats `json:"rental"`
+	Rentals cache.Stats `json:"rentals"`
+}
+
+// CacheStats returns the hit and miss counters of the rental caches, nil when caching is disabled.
+func (r *RentalService) CacheStats() *CacheStats {
+	if r.rentalCache == nil {
+		return nil
+	}
+	return &CacheStats{
+		Rental:  r.rentalCache.Stats(),
+		Rentals: r.rentalsCache.Stats(),
+	}
+}
+
+// loadGeneration returns the generation to pass to storeRental and storeRentals, taken before the
+// value is loaded.
+func (r *RentalService) loadGeneration() uint64 {
+	r.cacheMu.Lock()
+	defer r.cacheMu.Unlock()
+	return r.cacheGeneration
+}
+
+// storeRental caches a rental loaded at generation, unless the cache was invalidated since.
+func (r *RentalService) storeRental(generation uint64, rental apiv1.Rental) {
+	if r.rentalCache == nil {
+		return
+	}
+	r.cacheMu.Lock()
+	defer r.cacheMu.Unlock()
+	if generation == r.cacheGeneration {
+		r.rentalCache.Set(rental.ID, rental)
+	}
+}
+
+// storeRentals caches a list loaded at generation, unless the cache was invalidated since. A read
+// that started before a write would otherwise put the list from before the write back for the TTL.
+func (r *RentalService) storeRentals(generation uint64, key string, rentals []apiv1.Rental) {
+	if r.rentalsCache == nil {
+		return
+	}
+	r.cacheMu.Lock()
+	defer r.cacheMu.Unlock()
+	if generation == r.cacheGeneration {
+		r.rentalsCache.Set(key, rentals)
+	}
+}
+
+// invalidate drops the cached rental and every cached list, as any list may contain the rental.
+func (r *RentalService) invalidate(rentalID int) {
+	if r.rentalCache == nil {
+		return
+	}
+	r.cacheMu.Lock()
+	defer r.cacheMu.Unlock()
+	r.cacheGeneration++
+	r.rentalCache.Delete(rentalID)
+	r.rentalsCache.Purge()
+}
+
+// purge drops every cached rental and list.
+func (r *RentalService) purge() {
+	if r.rentalCache == nil {
+		return
+	}
+	r.cacheMu.Lock()
+	defer r.cacheMu.Unlock()
+	r.cacheGeneration++
+	r.rentalCache.Purge()
+	r.rentalsCache.Purge()
+}
+
+// PurgeCache drops every cached rental and list, for changes that affect any of them.
+func (r *RentalService) PurgeCache() {
+	r.purge()
+}
+
+// cacheKey normalizes params so that equivalent queries share a cache entry.
+func cacheKey(params database.RentalParams) string {
+	ids := make([]int, len(params.IDs))
+	copy(ids, params.IDs)
+	sort.Ints(ids)
+	userIDs := make([]int, len(params.UserIDs))
+	copy(userIDs, params.UserIDs)
+	sort.Ints(userIDs)
+	amenities := make([]string, len(params.Amenities))
+	copy(amenities, params.Amenities)
+	sort.Strings(amenities)
+
+	return fmt.Sprintf("price_min=%d&price_max=%d&price_unit=%s&currency=%s&ids=%v&user_ids=%v&near=%g,%g,%g,%g&amenities=%v&rating_min=%g&sort=%s&limit=%d&offset=%d&user=%t",
+		params.PriceMin, params.PriceMax, params.PriceUnit, params.Currency, ids, userIDs,
+		params.Near.MinLat, params.Near.MaxLat, params.Near.MinLng, params.Near.MaxLng, amenities, params.RatingMin,
+		params.Sort, params.Limit, params.Offset, params.IncludeUser)
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+)
+
+// countingStore serves rentals named after the current name and counts the reads. The during hook runs in
+// the middle of the next read, after the rentals are loaded.
+type countingStore struct {
+	RentalsStore
+	name   string
+	reads  int
+	during func()
+}
+
+func (s *countingStore) read() string {
+	s.reads++
+	name := s.name
+	if s.during != nil {
+		during := s.during
+		s.during = nil
+		during()
+	}
+	return name
+}
+
+func (s *countingStore) FindRentalByID(_ context.Context, rentalID int) (*database.Rental, error) {
+	if rentalID > 10 {
+		return nil, database.ErrNotFound
+	}
+	return &database.Rental{ID: rentalID, Name: s.read()}, nil
+}
+
+func (s *countingStore) FindRentals(_ context.Context, _ database.RentalParams) ([]database.Rental, error) {
+	return []database.Rental{{ID: 1, Name: s.read()}}, nil
+}
+
+func newCachingService(store RentalsStore) *RentalService {
+	rentalSvc := NewRentalService(nil, zap.NewNop(), Options{CacheSize: 10, CacheTTL: time.Minute})
+	rentalSvc.rentalsRepository = store
+	return rentalSvc
+}
+
+func TestRentalService_ReadThroughCache(t *testing.T) {
+	ctx := context.Background()
+	store := &countingStore{name: "Camper"}
+	rentalSvc := newCachingService(store)
+
+	for i := 0; i < 2; i++ {
+		rental, err := rentalSvc.GetRentalByID(ctx, 1)
+		require.Nil(t, err, "Error getting rental")
+		assert.Equal(t, "Camper", rental.Name)
+		rentals, err := rentalSvc.GetRentals(ctx, database.RentalParams{PriceMax: 10000})
+		require.Nil(t, err, "Error getting rentals")
+		require.Len(t, rentals, 1)
+	}
+	assert.Equal(t, 2, store.reads, "The second reads are served from the cache")
+
+	_, err := rentalSvc.GetRentals(ctx, database.RentalParams{PriceMax: 20000})
+	require.Nil(t, err, "Error getting rentals")
+	assert.Equal(t, 3, store.reads, "Other params are another entry")
+
+	_, err = rentalSvc.GetRentalByID(ctx, 30)
+	assert.ErrorIs(t, err, database.ErrNotFound)
+	_, err = rentalSvc.GetRentalByID(ctx, 30)
+	assert.ErrorIs(t, err, database.ErrNotFound)
+
+	stats := rentalSvc.CacheStats()
+	require.NotNil(t, stats)
+	assert.Equal(t, uint64(1), stats.Rental.Hits)
+	assert.Equal(t, uint64(1), stats.Rentals.Hits)
+}
+
+func TestRentalService_Invalidate(t *testing.T) {
+	ctx := context.Background()
+	store := &countingStore{name: "Camper"}
+	rentalSvc := newCachingService(store)
+	_, err := rentalSvc.GetRentalByID(ctx, 1)
+	require.Nil(t, err, "Error getting rental")
+	_, err = rentalSvc.GetRentalByID(ctx, 2)
+	require.Nil(t, err, "Error getting rental")
+	_, err = rentalSvc.GetRentals(ctx, database.RentalParams{})
+	require.Nil(t, err, "Error getting rentals")
+
+	store.name = "Renamed Camper"
+	rentalSvc.invalidate(1)
+	rental, err := rentalSvc.GetRentalByID(ctx, 1)
+	require.Nil(t, err, "Error getting rental")
+	assert.Equal(t, "Renamed Camper", rental.Name, "The changed rental is reloaded")
+	rental, err = rentalSvc.GetRentalByID(ctx, 2)
+	require.Nil(t, err, "Error getting rental")
+	assert.Equal(t, "Camper", rental.Name, "Other rentals stay cached")
+	rentals, err := rentalSvc.GetRentals(ctx, database.RentalParams{})
+	require.Nil(t, err, "Error getting rentals")
+	assert.Equal(t, "Renamed Camper", rentals[0].Name, "Lists are reloaded")
+}
+
+func TestRentalService_InvalidateDuringRead(t *testing.T) {
+	ctx := context.Background()
+	store := &countingStore{name: "Camper"}
+	rentalSvc := newCachingService(store)
+	// the write commits and invalidates while the read holds the rentals from before it
+	store.during = func() {
+		store.name = "Renamed Camper"
+		rentalSvc.invalidate(1)
+	}
+
+	rentals, err := rentalSvc.GetRentals(ctx, database.RentalParams{})
+	require.Nil(t, err, "Error getting rentals")
+	assert.Equal(t, "Camper", rentals[0].Name, "The read returns what it loaded")
+	rentals, err = rentalSvc.GetRentals(ctx, database.RentalParams{})
+	require.Nil(t, err, "Error getting rentals")
+	assert.Equal(t, "Renamed Camper", rentals[0].Name, "The list from before the write is not cached")
+
+	store.during = func() {
+		store.name = "Camper Again"
+		rentalSvc.purge()
+	}
+	rental, err := rentalSvc.GetRentalByID(ctx, 1)
+	require.Nil(t, err, "Error getting rental")
+	assert.Equal(t, "Renamed Camper", rental.Name)
+	rental, err = rentalSvc.GetRentalByID(ctx, 1)
+	require.Nil(t, err, "Error getting rental")
+	assert.Equal(t, "Camper Again", rental.Name, "The rental from before the purge is not cached")
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+)
+
+// ChangeListener is what WatchChanges needs from database.Listener.
+type ChangeListener interface {
+	Listen(ctx context.Context, handle func(database.RentalChange))
+}
+
+// changeBroker fans the rental changes out to its subscribers. Publish never blocks: a subscriber
+// whose buffer is full is dropped and its channel closed, so it knows it missed changes.
+type changeBroker struct {
+	mu          sync.Mutex
+	subscribers map[chan database.RentalChange]struct{}
+}
+
+func newChangeBroker() *changeBroker {
+	return &changeBroker{subscribers: map[chan database.RentalChange]struct{}{}}
+}
+
+func (b *changeBroker) subscribe(buffer int) (<-chan database.RentalChange, func()) {
+	ch := make(chan database.RentalChange, buffer)
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch, func() { b.unsubscribe(ch) }
+}
+
+func (b *changeBroker) unsubscribe(ch chan database.RentalChange) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[ch]; ok {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// publish returns the number of subscribers dropped for being too slow.
+func (b *changeBroker) publish(change database.RentalChange) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	dropped := 0
+	for ch := range b.subscribers {
+		select {
+		case ch <- change:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// SubscribeChanges returns the rental changes seen by WatchChanges, buffering up to buffer of
+// them. The channel is closed by cancel, or when the subscriber falls behind.
+func (r *RentalService) SubscribeChanges(buffer int) (<-chan database.RentalChange, func()) {
+	return r.changes.subscribe(buffer)
+}
+
+// WatchChanges invalidates the cached rentals changed by any replica and fans the changes out to
+// the subscribers, until ctx is done.
+func (r *RentalService) WatchChanges(ctx context.Context, listener ChangeListener) {
+	listener.Listen(ctx, func(change database.RentalChange) {
+		if change.Op == database.ChangeResync {
+			r.purge()
+		} else {
+			r.invalidate(change.ID)
+		}
+		if dropped := r.changes.publish(change); dropped > 0 {
+			r.logger.Warn("Dropped slow rental change subscribers", zap.Int("dropped", dropped))
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+)
+
+// staticListener hands its changes over and waits for ctx, as database.Listener does.
+type staticListener []database.RentalChange
+
+func (l staticListener) Listen(ctx context.Context, handle func(database.RentalChange)) {
+	for _, change := range l {
+		handle(change)
+	}
+	<-ctx.Done()
+}
+
+func TestRentalService_WatchChanges(t *testing.T) {
+	rentalSvc := NewRentalService(nil, zap.NewNop(), Options{CacheSize: 10, CacheTTL: time.Minute})
+	for _, rentalID := range []int{1, 2, 3} {
+		rentalSvc.rentalCache.Set(rentalID, apiv1.Rental{ID: rentalID})
+	}
+	rentalSvc.rentalsCache.Set("all", []apiv1.Rental{{ID: 1}, {ID: 2}, {ID: 3}})
+
+	changes, cancelSubscription := rentalSvc.SubscribeChanges(10)
+	defer cancelSubscription()
+	slowChanges, _ := rentalSvc.SubscribeChanges(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go rentalSvc.WatchChanges(ctx, staticListener{
+		{Op: database.ChangeUpdate, ID: 2},
+		{Op: database.ChangeDelete, ID: 3},
+	})
+
+	for _, expected := range []database.RentalChange{{Op: database.ChangeUpdate, ID: 2}, {Op: database.ChangeDelete, ID: 3}} {
+		select {
+		case change := <-changes:
+			assert.Equal(t, expected, change)
+		case <-time.After(time.Second):
+			t.Fatal("No change received")
+		}
+	}
+	_, ok := rentalSvc.rentalCache.Get(1)
+	assert.True(t, ok, "Unchanged rental stays cached")
+	_, ok = rentalSvc.rentalCache.Get(2)
+	assert.False(t, ok, "Changed rental is invalidated")
+	_, ok = rentalSvc.rentalsCache.Get("all")
+	assert.False(t, ok, "Lists are invalidated")
+
+	change, ok := <-slowChanges
+	require.True(t, ok)
+	assert.Equal(t, 2, change.ID)
+	_, ok = <-slowChanges
+	assert.False(t, ok, "Slow subscriber is dropped")
+}
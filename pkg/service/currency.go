@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/currency"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+)
+
+// ExchangeRatesStore is what the service needs from database.CurrencyRepository.
+type ExchangeRatesStore interface {
+	FindExchangeRates(ctx context.Context) ([]database.ExchangeRate, error)
+	UpsertExchangeRates(ctx context.Context, rates []database.ExchangeRate) error
+}
+
+// RentalsCache is what the service needs from RentalService: the cached lists in another currency
+// were filtered and sorted at the rates of the time.
+type RentalsCache interface {
+	PurgeCache()
+}
+
+type CurrencyService struct {
+	currencyRepository ExchangeRatesStore
+	rentals            RentalsCache
+	logger             zap.Logger
+}
+
+// NewCurrencyService returns a service that purges the rentals cache whenever the rates change.
+func NewCurrencyService(db *sqlx.DB, logger *zap.Logger, rentals RentalsCache) *CurrencyService {
+	return &CurrencyService{
+		currencyRepository: database.NewCurrencyRepository(db, logger),
+		rentals:            rentals,
+		logger:             *logger,
+	}
+}
+
+// GetExchangeRates returns every stored exchange rate ordered by currency.
+func (c *CurrencyService) GetExchangeRates(ctx context.Context) ([]apiv1.ExchangeRate, error) {
+	rates, err := c.currencyRepository.FindExchangeRates(ctx)
+	if err != nil {
+		c.logger.Error("Error getting exchange rates", zap.Error(err))
+		return nil, err
+	}
+	exchangeRates := make([]apiv1.ExchangeRate, len(rates))
+	for i, rate := range rates {
+		exchangeRates[i] = apiv1.ExchangeRate{Currency: rate.Currency, Rate: rate.Rate, Updated: rate.Updated}
+	}
+	return exchangeRates, nil
+}
+
+// SetExchangeRates adds or replaces the given rates and returns all of them. Invalid rates are
+// reported with currency.ErrInvalidRate. Other replicas purge their caches on the notification of
+// the change.
+func (c *CurrencyService) SetExchangeRates(ctx context.Context, exchangeRates []apiv1.ExchangeRate) ([]apiv1.ExchangeRate, error) {
+	if err := currency.Validate(exchangeRates); err != nil {
+		return nil, err
+	}
+	rates := make([]database.ExchangeRate, len(exchangeRates))
+	for i, exchangeRate := range exchangeRates {
+		rates[i] = database.ExchangeRate{Currency: exchangeRate.Currency, Rate: exchangeRate.Rate}
+	}
+	if err := c.currencyRepository.UpsertExchangeRates(ctx, rates); err != nil {
+		c.logger.Error("Error storing exchange rates", zap.Error(err))
+		return nil, err
+	}
+	c.rentals.PurgeCache()
+	c.logger.Info("Exchange rates updated", zap.Int("rates", len(rates)))
+	return c.GetExchangeRates(ctx)
+}
+
+// GetRates returns the stored exchange rates for converting prices.
+func (c *CurrencyService) GetRates(ctx context.Context) (currency.Rates, error) {
+	exchangeRates, err := c.GetExchangeRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return currency.NewRates(exchangeRates), nil
+}
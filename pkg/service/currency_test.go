@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+)
+
+// memoryRates stores the exchange rates like database.CurrencyRepository.
+type memoryRates map[string]float64
+
+func (m memoryRates) FindExchangeRates(_ context.Context) ([]database.ExchangeRate, error) {
+	rates := make([]database.ExchangeRate, 0, len(m))
+	for code, rate := range m {
+		rates = append(rates, database.ExchangeRate{Currency: code, Rate: rate})
+	}
+	return rates, nil
+}
+
+func (m memoryRates) UpsertExchangeRates(_ context.Context, rates []database.ExchangeRate) error {
+	for _, rate := range rates {
+		m[rate.Currency] = rate.Rate
+	}
+	return nil
+}
+
+func TestCurrencyService_SetExchangeRates_PurgesRentals(t *testing.T) {
+	ctx := context.Background()
+	store := &countingStore{name: "Camper"}
+	rentalSvc := newCachingService(store)
+	currencySvc := NewCurrencyService(nil, zap.NewNop(), rentalSvc)
+	currencySvc.currencyRepository = memoryRates{"USD": 1, "EUR": 0.9}
+	params := database.RentalParams{PriceMax: 10000, Currency: "EUR"}
+
+	_, err := rentalSvc.GetRentals(ctx, params)
+	require.Nil(t, err, "Error getting rentals")
+	_, err = currencySvc.SetExchangeRates(ctx, []apiv1.ExchangeRate{{Currency: "EUR", Rate: 0.8}})
+	require.Nil(t, err, "Error setting exchange rates")
+	_, err = rentalSvc.GetRentals(ctx, params)
+	require.Nil(t, err, "Error getting rentals")
+	assert.Equal(t, 2, store.reads, "The list filtered at the old rates is not served")
+
+	_, err = currencySvc.SetExchangeRates(ctx, []apiv1.ExchangeRate{{Currency: "EUR", Rate: 0}})
+	require.NotNil(t, err, "Invalid rates are rejected")
+	_, err = rentalSvc.GetRentals(ctx, params)
+	require.Nil(t, err, "Error getting rentals")
+	assert.Equal(t, 2, store.reads, "Rejected rates keep the cache")
+}
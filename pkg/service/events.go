@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+	"github.com/mkermilska/rentals-challenge/pkg/mapper"
+)
+
+const (
+	// rentalEventsBatch is the number of outbox events read at once.
+	rentalEventsBatch = 100
+	// eventGapGrace is how long the events after a missing id wait for it. Ids are taken when a
+	// change is written, so a transaction committing late leaves a gap that is filled shortly after.
+	eventGapGrace = 2 * time.Second
+)
+
+// RentalEventPage holds the events of one GetRentalEvents call.
+type RentalEventPage struct {
+	Events []apiv1.RentalEvent
+	// Next is the id to pass as afterID to continue.
+	Next int64
+	// Full is set when more events are ready to be read right away.
+	Full bool
+	// Pending is set when events wait for a missing id, they are returned after eventGapGrace at the latest.
+	Pending bool
+}
+
+// LastRentalEventID returns the id of the newest rental event, to follow only the changes to come.
+func (r *RentalService) LastRentalEventID(ctx context.Context) (int64, error) {
+	lastID, err := r.outboxRepository.LastEventID(ctx)
+	if err != nil {
+		r.logger.Error("Error getting last rental event", zap.Error(err))
+		return 0, err
+	}
+	return lastID, nil
+}
+
+// GetRentalEvents returns the events after afterID of the rentals matching the filters of params,
+// in the order of their ids. An update moving a rental out of the filters is returned as
+// rental.removed, so followers can drop it. Events are kept as long as the outbox retains them.
+func (r *RentalService) GetRentalEvents(ctx context.Context, params database.RentalParams, afterID int64) (*RentalEventPage, error) {
+	outboxEvents, err := r.outboxRepository.FindEventsAfter(ctx, afterID, rentalEventsBatch)
+	if err != nil {
+		r.logger.Error("Error getting rental events", zap.Int64("afterID", afterID), zap.Error(err))
+		return nil, err
+	}
+
+	page := &RentalEventPage{Events: []apiv1.RentalEvent{}, Next: afterID, Full: len(outboxEvents) == rentalEventsBatch}
+	for _, outboxEvent := range outboxEvents {
+		if outboxEvent.ID != page.Next+1 && time.Since(outboxEvent.Created) < eventGapGrace {
+			page.Full = false
+			page.Pending = true
+			break
+		}
+		page.Next = outboxEvent.ID
+		rental, err := outboxEvent.Rental()
+		if err != nil {
+			r.logger.Error("Skipping invalid rental event", zap.Int64("eventID", outboxEvent.ID), zap.Error(err))
+			continue
+		}
+		if params.Matches(rental) {
+			page.Events = append(page.Events, *mapper.OutboxEventToRentalEvent(outboxEvent, rental))
+			continue
+		}
+		if r.matchedBefore(params, outboxEvent) {
+			event := mapper.OutboxEventToRentalEvent(outboxEvent, rental)
+			event.Type = apiv1.EventRentalRemoved
+			page.Events = append(page.Events, *event)
+		}
+	}
+	return page, nil
+}
+
+// matchedBefore reports whether the rental of a rental.updated event matched params before the change.
+func (r *RentalService) matchedBefore(params database.RentalParams, outboxEvent database.OutboxEvent) bool {
+	if outboxEvent.EventType != apiv1.EventRentalUpdated {
+		return false
+	}
+	previous, err := outboxEvent.PreviousRental()
+	if err != nil {
+		r.logger.Error("Skipping invalid previous rental", zap.Int64("eventID", outboxEvent.ID), zap.Error(err))
+		return false
+	}
+	return previous != nil && params.Matches(*previous)
+}
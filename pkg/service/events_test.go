@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+)
+
+// memoryOutbox serves its events like database.OutboxRepository.
+type memoryOutbox []database.OutboxEvent
+
+func (o memoryOutbox) FindEventsAfter(_ context.Context, afterID int64, limit int) ([]database.OutboxEvent, error) {
+	events := make([]database.OutboxEvent, 0)
+	for _, event := range o {
+		if event.ID > afterID && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (o memoryOutbox) LastEventID(_ context.Context) (int64, error) {
+	if len(o) == 0 {
+		return 0, nil
+	}
+	return o[len(o)-1].ID, nil
+}
+
+func outboxEvent(t *testing.T, id int64, eventType string, price, previousPrice int) database.OutboxEvent {
+	payload, err := json.Marshal(database.Rental{ID: 1, UserID: 1, PricePerDay: price})
+	require.Nil(t, err, "Error encoding rental")
+	event := database.OutboxEvent{ID: id, EventType: eventType, RentalID: 1, Payload: payload, Created: time.Now()}
+	if previousPrice != 0 {
+		previous, err := json.Marshal(database.Rental{ID: 1, UserID: 1, PricePerDay: previousPrice})
+		require.Nil(t, err, "Error encoding rental")
+		event.Previous = types.NullJSONText{JSONText: previous, Valid: true}
+	}
+	return event
+}
+
+func TestRentalService_GetRentalEvents(t *testing.T) {
+	rentalSvc := NewRentalService(nil, zap.NewNop(), Options{})
+	rentalSvc.outboxRepository = memoryOutbox{
+		outboxEvent(t, 1, apiv1.EventRentalCreated, 9000, 0),
+		// moves into the filters
+		outboxEvent(t, 2, apiv1.EventRentalUpdated, 9500, 20000),
+		// stays in the filters
+		outboxEvent(t, 3, apiv1.EventRentalUpdated, 9900, 9500),
+		// moves out of the filters
+		outboxEvent(t, 4, apiv1.EventRentalUpdated, 20000, 9900),
+		// stays out of the filters
+		outboxEvent(t, 5, apiv1.EventRentalUpdated, 25000, 20000),
+		// written without the previous state
+		outboxEvent(t, 6, apiv1.EventRentalUpdated, 30000, 0),
+		outboxEvent(t, 7, apiv1.EventRentalDeleted, 30000, 0),
+		{ID: 8, EventType: apiv1.EventRentalUpdated, RentalID: 1, Payload: types.JSONText(`{"PricePerDay": 9000}`),
+			Previous: types.NullJSONText{JSONText: types.JSONText(`{`), Valid: true}, Created: time.Now()},
+		{ID: 9, EventType: apiv1.EventRentalUpdated, RentalID: 1, Payload: types.JSONText(`{"PricePerDay": 20000}`),
+			Previous: types.NullJSONText{JSONText: types.JSONText(`{`), Valid: true}, Created: time.Now()},
+	}
+
+	page, err := rentalSvc.GetRentalEvents(context.Background(), database.RentalParams{PriceMax: 10000}, 0)
+	require.Nil(t, err, "Error getting rental events")
+	assert.Equal(t, int64(9), page.Next)
+	type sent struct {
+		ID   int64
+		Type string
+	}
+	var events []sent
+	for _, event := range page.Events {
+		events = append(events, sent{ID: event.ID, Type: event.Type})
+	}
+	assert.Equal(t, []sent{
+		{ID: 1, Type: apiv1.EventRentalCreated},
+		{ID: 2, Type: apiv1.EventRentalUpdated},
+		{ID: 3, Type: apiv1.EventRentalUpdated},
+		{ID: 4, Type: apiv1.EventRentalRemoved},
+		{ID: 8, Type: apiv1.EventRentalUpdated},
+	}, events)
+	assert.Equal(t, 20000, page.Events[3].Rental.Price.Day, "Removals hold the rental after the change")
+}
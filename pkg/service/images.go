@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+	"github.com/mkermilska/rentals-challenge/pkg/mapper"
+)
+
+// ErrInvalidImageOrder is returned when an order does not list every image of the rental exactly once.
+var ErrInvalidImageOrder = errors.New("invalid image order")
+
+// GetRentalImages returns the gallery of the rental ordered by position.
+func (r *RentalService) GetRentalImages(ctx context.Context, rentalID int) ([]apiv1.RentalImage, error) {
+	rental, err := r.GetRentalByID(ctx, rentalID)
+	if err != nil {
+		return nil, err
+	}
+	return rental.Images, nil
+}
+
+// AddRentalImage adds an image to the gallery of the rental. Only the owner of a rental can change it.
+func (r *RentalService) AddRentalImage(ctx context.Context, ownerID, rentalID int, input apiv1.RentalImageInput) (*apiv1.RentalImage, error) {
+	if _, err := r.checkOwner(ctx, ownerID, rentalID); err != nil {
+		return nil, err
+	}
+	image, err := r.rentalsRepository.InsertRentalImage(ctx, ownerID,
+		mapper.APIRentalImageInputToRentalImage(rentalID, input), input.Position)
+	if err != nil {
+		r.logger.Error("Error adding rental image", zap.Int("rentalID", rentalID), zap.Error(err))
+		return nil, err
+	}
+	r.invalidate(rentalID)
+	return mapper.RentalImageToAPIRentalImage(*image), nil
+}
+
+// ReorderRentalImages moves the images of the rental to the order of order.ImageIDs and returns the
+// reordered gallery. An order not listing every image once is reported with ErrInvalidImageOrder.
+func (r *RentalService) ReorderRentalImages(ctx context.Context, ownerID, rentalID int, order apiv1.ImageOrder) ([]apiv1.RentalImage, error) {
+	rental, err := r.checkOwner(ctx, ownerID, rentalID)
+	if err != nil {
+		return nil, err
+	}
+	if len(order.ImageIDs) != len(rental.Images) {
+		return nil, fmt.Errorf("%w: rental %d has %d images, %d are listed", ErrInvalidImageOrder,
+			rentalID, len(rental.Images), len(order.ImageIDs))
+	}
+	unlisted := make(map[int]bool, len(rental.Images))
+	for _, image := range rental.Images {
+		unlisted[image.ID] = true
+	}
+	for _, imageID := range order.ImageIDs {
+		if !unlisted[imageID] {
+			return nil, fmt.Errorf("%w: image %d is not an image of rental %d or is listed twice", ErrInvalidImageOrder,
+				imageID, rentalID)
+		}
+		delete(unlisted, imageID)
+	}
+
+	if err := r.rentalsRepository.ReorderRentalImages(ctx, ownerID, rentalID, order.ImageIDs); err != nil {
+		r.logger.Error("Error reordering rental images", zap.Int("rentalID", rentalID), zap.Error(err))
+		return nil, err
+	}
+	r.invalidate(rentalID)
+	return r.GetRentalImages(ctx, rentalID)
+}
+
+// DeleteRentalImage removes an image from the gallery of the rental. Only the owner of a rental can change it.
+func (r *RentalService) DeleteRentalImage(ctx context.Context, ownerID, rentalID, imageID int) error {
+	if _, err := r.checkOwner(ctx, ownerID, rentalID); err != nil {
+		return err
+	}
+	image, err := r.rentalsRepository.DeleteRentalImage(ctx, ownerID, rentalID, imageID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			r.logger.Debug("Rental image not found", zap.Int("rentalID", rentalID), zap.Int("imageID", imageID))
+			return err
+		}
+		r.logger.Error("Error deleting rental image", zap.Int("rentalID", rentalID), zap.Int("imageID", imageID),
+			zap.Error(err))
+		return err
+	}
+	r.invalidate(rentalID)
+	r.deleteBlobs(ctx, image.BlobKeys())
+	return nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+	"github.com/mkermilska/rentals-challenge/pkg/mapper"
+	"github.com/mkermilska/rentals-challenge/pkg/pricing"
+)
+
+type PricingService struct {
+	rentalsRepository *database.RentalsRepository
+	pricingRepository *database.PricingRepository
+	logger            zap.Logger
+}
+
+func NewPricingService(db *sqlx.DB, logger *zap.Logger) *PricingService {
+	return &PricingService{
+		rentalsRepository: database.NewRentalsRepository(db, logger),
+		pricingRepository: database.NewPricingRepository(db, logger),
+		logger:            *logger,
+	}
+}
+
+// GetPricing returns the pricing rules of the rental, rules without seasons, surcharges and
+// discounts when none were set.
+func (p *PricingService) GetPricing(ctx context.Context, rentalID int) (*apiv1.PricingRules, error) {
+	if _, err := p.findRental(ctx, rentalID); err != nil {
+		return nil, err
+	}
+	rentalPricing, err := p.findPricing(ctx, rentalID)
+	if err != nil {
+		return nil, err
+	}
+	return mapper.RentalPricingToAPIPricingRules(*rentalPricing), nil
+}
+
+// SetPricing replaces the pricing rules of the rental. Only the owner of a rental can change them.
+// Invalid rules are reported with pricing.ErrInvalidRules.
+func (p *PricingService) SetPricing(ctx context.Context, ownerID, rentalID int, rules apiv1.PricingRules) (*apiv1.PricingRules, error) {
+	rental, err := p.findRental(ctx, rentalID)
+	if err != nil {
+		return nil, err
+	}
+	if rental.UserID != ownerID {
+		p.logger.Info("Pricing change denied", zap.Int("rentalID", rentalID), zap.Int("ownerID", rental.UserID),
+			zap.Int("callerID", ownerID))
+		return nil, fmt.Errorf("%w: rental %d is owned by another user", ErrForbidden, rentalID)
+	}
+
+	rentalPricing, err := mapper.APIPricingRulesToRentalPricing(rentalID, rules)
+	if err != nil {
+		return nil, err
+	}
+	if err := mapper.RentalPricingToRules(rental.PricePerDay, *rentalPricing).Validate(); err != nil {
+		return nil, err
+	}
+	if err := p.pricingRepository.ReplacePricing(ctx, rentalPricing); err != nil {
+		p.logger.Error("Error storing rental pricing", zap.Int("rentalID", rentalID), zap.Error(err))
+		return nil, err
+	}
+	return p.GetPricing(ctx, rentalID)
+}
+
+// GetQuote prices a stay at the rental from the from date to the to date, the day of departure.
+// Stays the rules do not allow are reported with pricing.ErrInvalidStay or pricing.ErrMinNights.
+func (p *PricingService) GetQuote(ctx context.Context, rentalID int, from, to time.Time) (*apiv1.Quote, error) {
+	rental, err := p.findRental(ctx, rentalID)
+	if err != nil {
+		return nil, err
+	}
+	rentalPricing, err := p.findPricing(ctx, rentalID)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := pricing.NewQuote(mapper.RentalPricingToRules(rental.PricePerDay, *rentalPricing), from, to)
+	if err != nil {
+		return nil, err
+	}
+	return mapper.QuoteToAPIQuote(rentalID, *quote), nil
+}
+
+func (p *PricingService) findRental(ctx context.Context, rentalID int) (*database.Rental, error) {
+	rental, err := p.rentalsRepository.FindRentalByID(ctx, rentalID)
+	if err != nil {
+		if !errors.Is(err, database.ErrNotFound) {
+			p.logger.Error("Error getting rental by ID", zap.Error(err))
+		}
+		return nil, err
+	}
+	return rental, nil
+}
+
+// findPricing returns the default rules for rentals without pricing rules.
+func (p *PricingService) findPricing(ctx context.Context, rentalID int) (*database.RentalPricing, error) {
+	rentalPricing, err := p.pricingRepository.FindPricing(ctx, rentalID)
+	if errors.Is(err, database.ErrNotFound) {
+		return &database.RentalPricing{RentalID: rentalID, MinNights: 1}, nil
+	}
+	if err != nil {
+		p.logger.Error("Error getting rental pricing", zap.Int("rentalID", rentalID), zap.Error(err))
+		return nil, err
+	}
+	return rentalPricing, nil
+}
@@ -1,45 +1,225 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
 	"go.uber.org/zap"
 
 	"github.com/jmoiron/sqlx"
 
 	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/cache"
 	"github.com/mkermilska/rentals-challenge/pkg/database"
 	"github.com/mkermilska/rentals-challenge/pkg/mapper"
+	"github.com/mkermilska/rentals-challenge/pkg/storage"
 )
 
+// ErrForbidden is returned when the caller is not allowed to change a rental.
+var ErrForbidden = errors.New("forbidden")
+
+type Options struct {
+	// CacheSize enables the read-through cache of rental lookups when greater than zero.
+	CacheSize int
+	CacheTTL  time.Duration
+	// Blobs enables image uploads when set. Uploaded images are served below MediaBaseURL and
+	// scaled down to each of ThumbnailWidths.
+	Blobs           storage.BlobStore
+	MediaBaseURL    string
+	ThumbnailWidths []int
+	// ImageDecodes is the number of uploaded images decoded and scaled at once, others wait for
+	// their turn. Each takes up to 4 bytes per pixel of the image.
+	ImageDecodes int
+}
+
+// defaultImageDecodes applies to the zero Options.ImageDecodes.
+const defaultImageDecodes = 2
+
+// RentalsStore is what the service needs from database.RentalsRepository.
+type RentalsStore interface {
+	FindRentalByID(ctx context.Context, rentalID int) (*database.Rental, error)
+	FindRentals(ctx context.Context, params database.RentalParams) ([]database.Rental, error)
+	InsertRental(ctx context.Context, rental *database.Rental) (int, error)
+	UpdateRental(ctx context.Context, rental *database.Rental) error
+	DeleteRental(ctx context.Context, rentalID, userID int) error
+	InsertRentalImage(ctx context.Context, userID int, image *database.RentalImage, position *int) (*database.RentalImage, error)
+	ReorderRentalImages(ctx context.Context, userID, rentalID int, imageIDs []int) error
+	DeleteRentalImage(ctx context.Context, userID, rentalID, imageID int) (*database.RentalImage, error)
+	FindAmenities(ctx context.Context) ([]database.Amenity, error)
+	FindBooking(ctx context.Context, bookingID int) (*database.Booking, error)
+	FindReviews(ctx context.Context, rentalID, limit, offset int) ([]database.Review, error)
+	InsertReview(ctx context.Context, review *database.Review) (*database.Review, error)
+}
+
+// OutboxStore is what the service needs from database.OutboxRepository.
+type OutboxStore interface {
+	FindEventsAfter(ctx context.Context, afterID int64, limit int) ([]database.OutboxEvent, error)
+	LastEventID(ctx context.Context) (int64, error)
+}
+
 type RentalService struct {
-	rentalsRepository *database.RentalsRepository
+	rentalsRepository RentalsStore
+	outboxRepository  OutboxStore
+	rentalCache       *cache.LRU[int, apiv1.Rental]
+	rentalsCache      *cache.LRU[string, []apiv1.Rental]
+	changes           *changeBroker
+	blobs             storage.BlobStore
+	mediaBaseURL      string
+	thumbnailWidths   []int
+	decodes           chan struct{}
 	logger            zap.Logger
+
+	// cacheMu orders the stores of loaded values against invalidations, cacheGeneration counts the
+	// invalidations so values loaded before one are not stored after it.
+	cacheMu         sync.Mutex
+	cacheGeneration uint64
 }
 
-func NewRentalService(db *sqlx.DB, logger *zap.Logger) *RentalService {
+func NewRentalService(db *sqlx.DB, logger *zap.Logger, opts Options) *RentalService {
 	rentalsRepository := database.NewRentalsRepository(db, logger)
 
-	return &RentalService{
+	rentalSvc := &RentalService{
 		rentalsRepository: rentalsRepository,
+		outboxRepository:  database.NewOutboxRepository(db, logger),
+		changes:           newChangeBroker(),
+		blobs:             opts.Blobs,
+		mediaBaseURL:      strings.TrimSuffix(opts.MediaBaseURL, "/"),
+		thumbnailWidths:   opts.ThumbnailWidths,
 		logger:            *logger,
 	}
+	if opts.ImageDecodes <= 0 {
+		opts.ImageDecodes = defaultImageDecodes
+	}
+	rentalSvc.decodes = make(chan struct{}, opts.ImageDecodes)
+	if opts.CacheSize > 0 {
+		rentalSvc.rentalCache = cache.NewLRU[int, apiv1.Rental](opts.CacheSize, opts.CacheTTL)
+		rentalSvc.rentalsCache = cache.NewLRU[string, []apiv1.Rental](opts.CacheSize, opts.CacheTTL)
+	}
+	return rentalSvc
 }
 
-func (r *RentalService) GetRentalByID(rentalID int) (*apiv1.Rental, error) {
-	rental, err := r.rentalsRepository.FindRentalByID(rentalID)
+func (r *RentalService) GetRentalByID(ctx context.Context, rentalID int) (*apiv1.Rental, error) {
+	if r.rentalCache != nil {
+		if rental, ok := r.rentalCache.Get(rentalID); ok {
+			return &rental, nil
+		}
+	}
+
+	generation := r.loadGeneration()
+	rental, err := r.rentalsRepository.FindRentalByID(ctx, rentalID)
 	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			r.logger.Debug("Rental not found", zap.Int("rentalID", rentalID))
+			return nil, err
+		}
 		r.logger.Error("Error getting rental by ID", zap.Error(err))
 		return nil, err
 	}
 	apiRental := mapper.RentalToAPIRental(*rental)
+	r.storeRental(generation, *apiRental)
 	return apiRental, nil
 }
 
-func (r *RentalService) GetRentals(params database.RentalParams) ([]apiv1.Rental, error) {
-	rentals, err := r.rentalsRepository.FindRentals(params)
+// GetRentals finds the rentals matching params. The returned slice may be shared with the cache
+// and must not be modified.
+func (r *RentalService) GetRentals(ctx context.Context, params database.RentalParams) ([]apiv1.Rental, error) {
+	key := cacheKey(params)
+	if r.rentalsCache != nil {
+		if rentals, ok := r.rentalsCache.Get(key); ok {
+			return rentals, nil
+		}
+	}
+
+	generation := r.loadGeneration()
+	rentals, err := r.rentalsRepository.FindRentals(ctx, params)
 	if err != nil {
+		if errors.Is(err, database.ErrInvalidQuery) {
+			r.logger.Warn("Invalid rentals query", zap.Any("rentalParams", params), zap.Error(err))
+			return nil, err
+		}
 		r.logger.Error("Error getting rentals", zap.Error(err))
 		return nil, err
 	}
 	apiRentals := mapper.RentalsToAPIRentals(rentals)
+	r.storeRentals(generation, key, apiRentals)
 	return apiRentals, nil
 }
+
+func (r *RentalService) CreateRental(ctx context.Context, ownerID int, input apiv1.RentalInput) (*apiv1.Rental, error) {
+	if err := r.checkAmenities(ctx, input.Amenities); err != nil {
+		return nil, err
+	}
+	rental := mapper.APIRentalInputToRental(input)
+	rental.UserID = ownerID
+	rentalID, err := r.rentalsRepository.InsertRental(ctx, rental)
+	if err != nil {
+		r.logger.Error("Error creating rental", zap.Error(err))
+		return nil, err
+	}
+	r.invalidate(rentalID)
+	return r.GetRentalByID(ctx, rentalID)
+}
+
+// UpdateRental replaces the rental with rentalID. Only the owner of a rental can change it.
+func (r *RentalService) UpdateRental(ctx context.Context, ownerID, rentalID int, input apiv1.RentalInput) (*apiv1.Rental, error) {
+	old, err := r.checkOwner(ctx, ownerID, rentalID)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.checkAmenities(ctx, input.Amenities); err != nil {
+		return nil, err
+	}
+	rental := mapper.APIRentalInputToRental(input)
+	rental.ID = rentalID
+	rental.UserID = ownerID
+	err = r.rentalsRepository.UpdateRental(ctx, rental)
+	if err != nil {
+		r.logger.Error("Error updating rental", zap.Int("rentalID", rentalID), zap.Error(err))
+		return nil, err
+	}
+	r.invalidate(rentalID)
+	if len(old.Images) > 0 && rental.PrimaryImageURL != "" && rental.PrimaryImageURL != old.Images[0].URL {
+		// the new primary image replaced the first image, with its uploaded blobs
+		r.deleteBlobs(ctx, old.Images[0].BlobKeys())
+	}
+	return r.GetRentalByID(ctx, rentalID)
+}
+
+// DeleteRental removes the rental with rentalID. Only the owner of a rental can delete it.
+func (r *RentalService) DeleteRental(ctx context.Context, ownerID, rentalID int) error {
+	rental, err := r.checkOwner(ctx, ownerID, rentalID)
+	if err != nil {
+		return err
+	}
+	err = r.rentalsRepository.DeleteRental(ctx, rentalID, ownerID)
+	if err != nil {
+		r.logger.Error("Error deleting rental", zap.Int("rentalID", rentalID), zap.Error(err))
+		return err
+	}
+	r.invalidate(rentalID)
+	for _, image := range rental.Images {
+		r.deleteBlobs(ctx, image.BlobKeys())
+	}
+	return nil
+}
+
+// checkOwner returns the rental when it is owned by ownerID.
+func (r *RentalService) checkOwner(ctx context.Context, ownerID, rentalID int) (*database.Rental, error) {
+	rental, err := r.rentalsRepository.FindRentalByID(ctx, rentalID)
+	if err != nil {
+		if !errors.Is(err, database.ErrNotFound) {
+			r.logger.Error("Error getting rental by ID", zap.Error(err))
+		}
+		return nil, err
+	}
+	if rental.UserID != ownerID {
+		r.logger.Info("Rental change denied", zap.Int("rentalID", rentalID), zap.Int("ownerID", rental.UserID),
+			zap.Int("callerID", ownerID))
+		return nil, fmt.Errorf("%w: rental %d is owned by another user", ErrForbidden, rentalID)
+	}
+	return rental, nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+	"github.com/mkermilska/rentals-challenge/pkg/mapper"
+)
+
+var (
+	// ErrInvalidBooking is returned for reviews of a booking missing or made for another rental.
+	ErrInvalidBooking = errors.New("invalid booking")
+	// ErrBookingNotCompleted is returned for reviews of a booking that was cancelled or did not end yet.
+	ErrBookingNotCompleted = errors.New("booking not completed")
+)
+
+// GetReviews returns a page of the reviews of the rental, newest first.
+func (r *RentalService) GetReviews(ctx context.Context, rentalID, limit, offset int) ([]apiv1.Review, error) {
+	if _, err := r.GetRentalByID(ctx, rentalID); err != nil {
+		return nil, err
+	}
+	reviews, err := r.rentalsRepository.FindReviews(ctx, rentalID, limit, offset)
+	if err != nil {
+		r.logger.Error("Error getting reviews", zap.Int("rentalID", rentalID), zap.Error(err))
+		return nil, err
+	}
+	return mapper.ReviewsToAPIReviews(reviews), nil
+}
+
+// CreateReview stores the review of a completed booking the user made on the rental and updates the
+// rating of the rental. A booking is reviewed once, a second review gives database.ErrConflict.
+func (r *RentalService) CreateReview(ctx context.Context, userID, rentalID int, input apiv1.ReviewInput) (*apiv1.Review, error) {
+	if _, err := r.GetRentalByID(ctx, rentalID); err != nil {
+		return nil, err
+	}
+	booking, err := r.rentalsRepository.FindBooking(ctx, input.BookingID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return nil, fmt.Errorf("%w: booking %d does not exist", ErrInvalidBooking, input.BookingID)
+		}
+		r.logger.Error("Error getting booking", zap.Int("bookingID", input.BookingID), zap.Error(err))
+		return nil, err
+	}
+	switch {
+	case booking.RentalID != rentalID:
+		return nil, fmt.Errorf("%w: booking %d is not a booking of rental %d", ErrInvalidBooking, booking.ID, rentalID)
+	case booking.UserID != userID:
+		r.logger.Info("Review denied", zap.Int("bookingID", booking.ID), zap.Int("callerID", userID))
+		return nil, fmt.Errorf("%w: booking %d was made by another user", ErrForbidden, booking.ID)
+	case booking.Cancelled != nil:
+		return nil, fmt.Errorf("%w: booking %d was cancelled", ErrBookingNotCompleted, booking.ID)
+	case !booking.Completed(time.Now()):
+		return nil, fmt.Errorf("%w: booking %d ends on %s", ErrBookingNotCompleted, booking.ID,
+			booking.EndDate.Format(time.DateOnly))
+	}
+
+	review, err := r.rentalsRepository.InsertReview(ctx, &database.Review{
+		RentalID:  rentalID,
+		BookingID: booking.ID,
+		UserID:    userID,
+		Rating:    input.Rating,
+		Text:      input.Text,
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrConflict) {
+			r.logger.Info("Booking already reviewed", zap.Int("bookingID", booking.ID))
+			return nil, err
+		}
+		r.logger.Error("Error creating review", zap.Int("rentalID", rentalID), zap.Error(err))
+		return nil, err
+	}
+	r.invalidate(rentalID)
+	return mapper.ReviewToAPIReview(*review), nil
+}
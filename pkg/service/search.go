@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+	"github.com/mkermilska/rentals-challenge/pkg/mapper"
+)
+
+// secretBytes is the size of the generated webhook secrets.
+const secretBytes = 32
+
+type SavedSearchService struct {
+	searchesRepository *database.SearchesRepository
+	logger             zap.Logger
+}
+
+func NewSavedSearchService(db *sqlx.DB, logger *zap.Logger) *SavedSearchService {
+	return &SavedSearchService{
+		searchesRepository: database.NewSearchesRepository(db, logger),
+		logger:             *logger,
+	}
+}
+
+// CreateSavedSearch saves params, parsed from input.Query, with a new webhook secret. The secret is
+// only returned here.
+func (s *SavedSearchService) CreateSavedSearch(ctx context.Context, ownerID int, input apiv1.SavedSearchInput,
+	params database.RentalParams) (*apiv1.SavedSearch, error) {
+	encodedParams, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding rental params: %w", err)
+	}
+	secret := make([]byte, secretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("error generating webhook secret: %w", err)
+	}
+
+	searchID, err := s.searchesRepository.InsertSavedSearch(ctx, &database.SavedSearch{
+		UserID:      ownerID,
+		Query:       input.Query,
+		Params:      encodedParams,
+		CallbackURL: input.CallbackURL,
+		Secret:      hex.EncodeToString(secret),
+	})
+	if err != nil {
+		s.logger.Error("Error creating saved search", zap.Error(err))
+		return nil, err
+	}
+	search, err := s.searchesRepository.FindSavedSearchByID(ctx, searchID)
+	if err != nil {
+		s.logger.Error("Error getting saved search by ID", zap.Error(err))
+		return nil, err
+	}
+	apiSearch := mapper.SavedSearchToAPISavedSearch(*search)
+	apiSearch.Secret = search.Secret
+	return apiSearch, nil
+}
+
+// GetSavedSearch returns the saved search with searchID. Only the owner of a search can read it.
+func (s *SavedSearchService) GetSavedSearch(ctx context.Context, ownerID, searchID int) (*apiv1.SavedSearch, error) {
+	search, err := s.findOwnedSearch(ctx, ownerID, searchID)
+	if err != nil {
+		return nil, err
+	}
+	return mapper.SavedSearchToAPISavedSearch(*search), nil
+}
+
+func (s *SavedSearchService) GetSavedSearches(ctx context.Context, ownerID int) ([]apiv1.SavedSearch, error) {
+	searches, err := s.searchesRepository.FindSavedSearches(ctx, ownerID)
+	if err != nil {
+		s.logger.Error("Error getting saved searches", zap.Error(err))
+		return nil, err
+	}
+	return mapper.SavedSearchesToAPISavedSearches(searches), nil
+}
+
+// DeleteSavedSearch removes the saved search with searchID. Only the owner of a search can delete it.
+func (s *SavedSearchService) DeleteSavedSearch(ctx context.Context, ownerID, searchID int) error {
+	if _, err := s.findOwnedSearch(ctx, ownerID, searchID); err != nil {
+		return err
+	}
+	err := s.searchesRepository.DeleteSavedSearch(ctx, searchID, ownerID)
+	if err != nil {
+		s.logger.Error("Error deleting saved search", zap.Int("searchID", searchID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// GetWebhookDeliveries returns the latest limit delivery attempts of the saved search, newest first.
+func (s *SavedSearchService) GetWebhookDeliveries(ctx context.Context, ownerID, searchID, limit int) ([]apiv1.WebhookDelivery, error) {
+	if _, err := s.findOwnedSearch(ctx, ownerID, searchID); err != nil {
+		return nil, err
+	}
+	deliveries, err := s.searchesRepository.FindWebhookDeliveries(ctx, searchID, limit)
+	if err != nil {
+		s.logger.Error("Error getting webhook deliveries", zap.Int("searchID", searchID), zap.Error(err))
+		return nil, err
+	}
+	return mapper.WebhookDeliveriesToAPIWebhookDeliveries(deliveries), nil
+}
+
+func (s *SavedSearchService) findOwnedSearch(ctx context.Context, ownerID, searchID int) (*database.SavedSearch, error) {
+	search, err := s.searchesRepository.FindSavedSearchByID(ctx, searchID)
+	if err != nil {
+		if !errors.Is(err, database.ErrNotFound) {
+			s.logger.Error("Error getting saved search by ID", zap.Error(err))
+		}
+		return nil, err
+	}
+	if search.UserID != ownerID {
+		s.logger.Info("Saved search access denied", zap.Int("searchID", searchID), zap.Int("ownerID", search.UserID),
+			zap.Int("callerID", ownerID))
+		return nil, fmt.Errorf("%w: saved search %d is owned by another user", ErrForbidden, searchID)
+	}
+	return search, nil
+}
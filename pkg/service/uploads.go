@@ -0,0 +1,135 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+	"github.com/mkermilska/rentals-challenge/pkg/mapper"
+	"github.com/mkermilska/rentals-challenge/pkg/thumbnail"
+)
+
+var (
+	// ErrInvalidImage is returned for uploads that are not a supported image.
+	ErrInvalidImage = errors.New("invalid image")
+	// ErrUploadsDisabled is returned for uploads when no blob store is configured.
+	ErrUploadsDisabled = errors.New("image uploads are disabled")
+)
+
+// UploadRentalImage stores an uploaded image with variants scaled down to the thumbnail widths and
+// adds it to the gallery of the rental, like AddRentalImage. Only the owner of a rental can change it.
+// Images are decoded by at most Options.ImageDecodes uploads at once, the others wait until ctx is done.
+func (r *RentalService) UploadRentalImage(ctx context.Context, ownerID, rentalID int, data []byte, caption string,
+	position *int) (*apiv1.RentalImage, error) {
+	if r.blobs == nil {
+		return nil, ErrUploadsDisabled
+	}
+	if _, err := r.checkOwner(ctx, ownerID, rentalID); err != nil {
+		return nil, err
+	}
+	source, variants, err := r.renderImage(ctx, rentalID, data)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, err := blobPrefix(rentalID)
+	if err != nil {
+		return nil, err
+	}
+	key := prefix + "/original" + source.Extension()
+	image := &database.RentalImage{
+		RentalID: rentalID,
+		URL:      r.mediaURL(key),
+		Caption:  caption,
+		Width:    &source.Width,
+		Height:   &source.Height,
+		BlobKey:  &key,
+		Variants: make(database.ImageVariants, 0, len(variants)),
+	}
+	stored := make([]string, 0, len(variants)+1)
+	put := func(key string, data []byte, contentType string) error {
+		if err := r.blobs.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+			return err
+		}
+		stored = append(stored, key)
+		return nil
+	}
+	err = put(key, data, source.ContentType())
+	for i := 0; err == nil && i < len(variants); i++ {
+		variant := variants[i]
+		variantKey := prefix + "/" + variant.Name + variant.Extension
+		image.Variants = append(image.Variants, database.ImageVariant{Name: variant.Name, Key: variantKey,
+			URL: r.mediaURL(variantKey), Width: variant.Width, Height: variant.Height})
+		err = put(variantKey, variant.Data, variant.ContentType)
+	}
+	if err != nil {
+		r.logger.Error("Error storing uploaded image", zap.Int("rentalID", rentalID), zap.Error(err))
+		r.deleteBlobs(ctx, stored)
+		return nil, err
+	}
+
+	inserted, err := r.rentalsRepository.InsertRentalImage(ctx, ownerID, image, position)
+	if err != nil {
+		r.logger.Error("Error adding uploaded rental image", zap.Int("rentalID", rentalID), zap.Error(err))
+		r.deleteBlobs(ctx, stored)
+		return nil, err
+	}
+	r.invalidate(rentalID)
+	return mapper.RentalImageToAPIRentalImage(*inserted), nil
+}
+
+// renderImage decodes the upload and scales it down to the thumbnail widths, once it gets one of the
+// decode slots.
+func (r *RentalService) renderImage(ctx context.Context, rentalID int, data []byte) (*thumbnail.Source, []thumbnail.Variant, error) {
+	select {
+	case r.decodes <- struct{}{}:
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+	defer func() { <-r.decodes }()
+
+	source, err := thumbnail.Decode(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	variants, err := source.Variants(r.thumbnailWidths)
+	if err != nil {
+		r.logger.Error("Error rendering image variants", zap.Int("rentalID", rentalID), zap.Error(err))
+		return nil, nil, err
+	}
+	return source, variants, nil
+}
+
+func (r *RentalService) mediaURL(key string) string {
+	return r.mediaBaseURL + "/" + key
+}
+
+// deleteBlobs removes blobs no image refers to anymore. Failures only leave unused blobs behind,
+// so they are logged and not returned.
+func (r *RentalService) deleteBlobs(ctx context.Context, keys []string) {
+	if r.blobs == nil {
+		return
+	}
+	for _, key := range keys {
+		if err := r.blobs.Delete(ctx, key); err != nil {
+			r.logger.Warn("Error deleting blob", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
+
+// blobPrefix returns a new random prefix for the blobs of an upload, so blobs are never overwritten
+// and can be cached forever.
+func blobPrefix(rentalID int) (string, error) {
+	random := make([]byte, 8)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("error generating blob key: %w", err)
+	}
+	return fmt.Sprintf("rentals/%d/%s", rentalID, hex.EncodeToString(random)), nil
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+	"github.com/mkermilska/rentals-challenge/pkg/storage"
+)
+
+// galleryStore serves rentals owned by user 1 and adds the uploaded images.
+type galleryStore struct {
+	RentalsStore
+}
+
+func (s *galleryStore) FindRentalByID(_ context.Context, rentalID int) (*database.Rental, error) {
+	return &database.Rental{ID: rentalID, UserID: 1}, nil
+}
+
+func (s *galleryStore) InsertRentalImage(_ context.Context, _ int, image *database.RentalImage, _ *int) (*database.RentalImage, error) {
+	inserted := *image
+	inserted.ID = 1
+	return &inserted, nil
+}
+
+func TestRentalService_UploadRentalImage_DecodeSlots(t *testing.T) {
+	blobs, err := storage.NewLocalStore(t.TempDir())
+	require.Nil(t, err, "Error creating blob store")
+	rentalSvc := NewRentalService(nil, zap.NewNop(), Options{Blobs: blobs, ThumbnailWidths: []int{2}, ImageDecodes: 1})
+	rentalSvc.rentalsRepository = &galleryStore{}
+	var data bytes.Buffer
+	require.Nil(t, png.Encode(&data, image.NewRGBA(image.Rect(0, 0, 4, 4))), "Error encoding image")
+
+	// another upload holds the only slot
+	rentalSvc.decodes <- struct{}{}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = rentalSvc.UploadRentalImage(ctx, 1, 3, data.Bytes(), "", nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "Uploads wait for a free slot")
+
+	<-rentalSvc.decodes
+	uploaded, err := rentalSvc.UploadRentalImage(context.Background(), 1, 3, data.Bytes(), "", nil)
+	require.Nil(t, err, "Error uploading image")
+	require.Len(t, uploaded.Variants, 1)
+	assert.Equal(t, 2, uploaded.Variants[0].Width)
+	assert.Empty(t, rentalSvc.decodes, "The slot is released")
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+)
+
+type UserService struct {
+	usersRepository *database.UsersRepository
+	logger          zap.Logger
+}
+
+func NewUserService(db *sqlx.DB, logger *zap.Logger) *UserService {
+	return &UserService{
+		usersRepository: database.NewUsersRepository(db, logger),
+		logger:          *logger,
+	}
+}
+
+// GetUsersByIDs returns the existing users among userIDs, ordered by id.
+func (u *UserService) GetUsersByIDs(ctx context.Context, userIDs []int) ([]apiv1.User, error) {
+	users, err := u.usersRepository.FindUsersByIDs(ctx, userIDs)
+	if err != nil {
+		u.logger.Error("Error getting users by IDs", zap.Error(err))
+		return nil, err
+	}
+	return users, nil
+}
@@ -0,0 +1,49 @@
+// Package storage keeps uploaded files, such as rental images, in a local directory or an
+// S3-compatible bucket.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when no object is stored under a key.
+	ErrNotFound = errors.New("object not found")
+	// ErrInvalidKey is returned for keys that are empty, absolute or leave their store through "..".
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// BlobStore stores objects under slash separated keys like "rentals/1/3f2a/original.jpg".
+type BlobStore interface {
+	// Put stores size bytes of data under key, replacing any object stored there.
+	Put(ctx context.Context, key string, data io.Reader, size int64, contentType string) error
+	// Open returns the object stored under key, or ErrNotFound. The caller closes it.
+	Open(ctx context.Context, key string) (*Object, error)
+	// Delete removes the object stored under key. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Object is a stored object opened for reading.
+type Object struct {
+	io.ReadSeekCloser
+	ContentType string
+	Size        int64
+	Modified    time.Time
+}
+
+// ValidKey reports whether key can be stored, see ErrInvalidKey.
+func ValidKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
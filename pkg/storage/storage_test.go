@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	require.Nil(t, err, "Error creating store")
+	testBlobStore(t, store)
+}
+
+// TestS3Store runs against the bucket of a local MinIO, e.g. the minio service of docker-compose:
+// S3_TEST_ENDPOINT=127.0.0.1:9000 S3_TEST_ACCESS_KEY=minioadmin S3_TEST_SECRET_KEY=minioadmin go test ./pkg/storage
+func TestS3Store(t *testing.T) {
+	endpoint := os.Getenv("S3_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("S3_TEST_ENDPOINT is not set")
+	}
+	store, err := NewS3Store(context.Background(), S3Options{
+		Endpoint:  endpoint,
+		Bucket:    "rentals-storage-test",
+		AccessKey: os.Getenv("S3_TEST_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_TEST_SECRET_KEY"),
+	})
+	require.Nil(t, err, "Error creating store")
+	testBlobStore(t, store)
+}
+
+func testBlobStore(t *testing.T, store BlobStore) {
+	ctx := context.Background()
+	data := []byte("not really a jpeg")
+	err := store.Put(ctx, "rentals/1/abc/original.jpg", bytes.NewReader(data), int64(len(data)), "image/jpeg")
+	require.Nil(t, err, "Error putting object")
+
+	object, err := store.Open(ctx, "rentals/1/abc/original.jpg")
+	require.Nil(t, err, "Error opening object")
+	read, err := io.ReadAll(object)
+	require.Nil(t, err, "Error reading object")
+	assert.Equal(t, data, read)
+	assert.Equal(t, "image/jpeg", object.ContentType)
+	assert.Equal(t, int64(len(data)), object.Size)
+	_, err = object.Seek(4, io.SeekStart)
+	require.Nil(t, err, "Error seeking object")
+	read, err = io.ReadAll(object)
+	require.Nil(t, err, "Error reading object")
+	assert.Equal(t, data[4:], read)
+	require.Nil(t, object.Close())
+
+	_, err = store.Open(ctx, "rentals/1/abc/missing.jpg")
+	assert.ErrorIs(t, err, ErrNotFound)
+	for _, key := range []string{"", "/etc/passwd", "rentals/../../secret", "rentals//1"} {
+		_, err = store.Open(ctx, key)
+		assert.ErrorIs(t, err, ErrInvalidKey, key)
+	}
+
+	require.Nil(t, store.Delete(ctx, "rentals/1/abc/original.jpg"), "Error deleting object")
+	_, err = store.Open(ctx, "rentals/1/abc/original.jpg")
+	assert.ErrorIs(t, err, ErrNotFound, "Deleted object is gone")
+	assert.Nil(t, store.Delete(ctx, "rentals/1/abc/original.jpg"), "Deleting twice is not an error")
+}
@@ -0,0 +1,109 @@
+// Package thumbnail checks uploaded images and renders smaller variants of them.
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+)
+
+// MaxPixels caps the size of decoded images, so a small file can not expand to gigabytes of memory.
+// A decoded image takes up to 4 bytes per pixel, 100 MB at the limit, enough for 8K photos.
+const MaxPixels = 25_000_000
+
+// ErrUnsupported is returned for data that is not a JPEG, PNG or GIF image, or is too large.
+var ErrUnsupported = errors.New("unsupported image")
+
+// Source is a decoded upload.
+type Source struct {
+	// Format is "jpeg", "png" or "gif".
+	Format        string
+	Width, Height int
+	image         image.Image
+}
+
+// Variant is a rendered variant of a Source, named after its width like "w480".
+type Variant struct {
+	Name          string
+	Width, Height int
+	ContentType   string
+	Extension     string
+	Data          []byte
+}
+
+// Decode reads an image, checking format and dimensions before decoding the pixels.
+func Decode(data []byte) (*Source, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return nil, fmt.Errorf("%w: %dx%d pixels exceed the limit of %d", ErrUnsupported,
+			config.Width, config.Height, MaxPixels)
+	}
+	var img image.Image
+	switch format {
+	case "jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	case "png":
+		img, err = png.Decode(bytes.NewReader(data))
+	case "gif":
+		img, err = gif.Decode(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("%w: format %s", ErrUnsupported, format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	return &Source{Format: format, Width: config.Width, Height: config.Height, image: img}, nil
+}
+
+// ContentType is the media type of the uploaded data.
+func (s *Source) ContentType() string {
+	return "image/" + s.Format
+}
+
+// Extension is the file extension of the uploaded data, with the dot.
+func (s *Source) Extension() string {
+	if s.Format == "jpeg" {
+		return ".jpg"
+	}
+	return "." + s.Format
+}
+
+// Variants scales the image down to every width smaller than its own, keeping the aspect ratio.
+// JPEG images give JPEG variants, PNG and GIF images, which may be transparent, give PNG variants.
+func (s *Source) Variants(widths []int) ([]Variant, error) {
+	variants := make([]Variant, 0, len(widths))
+	for _, width := range widths {
+		if width <= 0 || width >= s.Width {
+			continue
+		}
+		height := max(1, (s.Height*width+s.Width/2)/s.Width)
+		scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(scaled, scaled.Bounds(), s.image, s.image.Bounds(), draw.Src, nil)
+
+		variant := Variant{Name: fmt.Sprintf("w%d", width), Width: width, Height: height}
+		var buf bytes.Buffer
+		var err error
+		if s.Format == "jpeg" {
+			variant.ContentType, variant.Extension = "image/jpeg", ".jpg"
+			err = jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: 85})
+		} else {
+			variant.ContentType, variant.Extension = "image/png", ".png"
+			err = png.Encode(&buf, scaled)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error encoding %s variant: %w", variant.Name, err)
+		}
+		variant.Data = buf.Bytes()
+		variants = append(variants, variant)
+	}
+	return variants, nil
+}
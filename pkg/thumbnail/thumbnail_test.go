@@ -0,0 +1,83 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVariants(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	for x := 0; x < 800; x++ {
+		img.Set(x, x*600/800, color.RGBA{R: 255, A: 255})
+	}
+	encode := map[string]func(*bytes.Buffer) error{
+		"jpeg": func(buf *bytes.Buffer) error { return jpeg.Encode(buf, img, nil) },
+		"png":  func(buf *bytes.Buffer) error { return png.Encode(buf, img) },
+		"gif":  func(buf *bytes.Buffer) error { return gif.Encode(buf, img, nil) },
+	}
+	tests := map[string]struct {
+		format              string
+		expectedContentType string
+		expectedExtension   string
+		expectedVariantType string
+	}{
+		"JPEG": {format: "jpeg", expectedContentType: "image/jpeg", expectedExtension: ".jpg", expectedVariantType: "image/jpeg"},
+		"PNG":  {format: "png", expectedContentType: "image/png", expectedExtension: ".png", expectedVariantType: "image/png"},
+		"GIF":  {format: "gif", expectedContentType: "image/gif", expectedExtension: ".gif", expectedVariantType: "image/png"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.Nil(t, encode[test.format](&buf), "Error encoding image")
+			source, err := Decode(buf.Bytes())
+			require.Nil(t, err, "Error decoding image")
+			assert.Equal(t, test.expectedContentType, source.ContentType())
+			assert.Equal(t, test.expectedExtension, source.Extension())
+			assert.Equal(t, 800, source.Width)
+			assert.Equal(t, 600, source.Height)
+
+			variants, err := source.Variants([]int{160, 480, 800, 1024})
+			require.Nil(t, err, "Error rendering variants")
+			require.Len(t, variants, 2, "Only widths smaller than the image are rendered")
+			assert.Equal(t, "w160", variants[0].Name)
+			assert.Equal(t, "w480", variants[1].Name)
+			for _, variant := range variants {
+				assert.Equal(t, test.expectedVariantType, variant.ContentType)
+				config, _, err := image.DecodeConfig(bytes.NewReader(variant.Data))
+				require.Nil(t, err, "Error decoding variant")
+				assert.Equal(t, variant.Width, config.Width)
+				assert.Equal(t, variant.Height, config.Height)
+				assert.Equal(t, variant.Width*3/4, variant.Height, "Aspect ratio is kept")
+			}
+		})
+	}
+}
+
+func TestDecode_Unsupported(t *testing.T) {
+	tests := map[string][]byte{
+		"Text":        []byte("hello"),
+		"Truncated":   {0xff, 0xd8, 0xff},
+		"Too large":   gifHeader(60_000, 60_000),
+		"Above limit": gifHeader(5_001, 5_000),
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Decode(data)
+			assert.ErrorIs(t, err, ErrUnsupported)
+		})
+	}
+}
+
+// gifHeader returns the header of a GIF image of the given size, enough for image.DecodeConfig.
+func gifHeader(width, height int) []byte {
+	return []byte{'G', 'I', 'F', '8', '9', 'a', byte(width), byte(width >> 8), byte(height), byte(height >> 8), 0, 0, 0}
+}
@@ -0,0 +1,69 @@
+// Package webhook re-runs saved searches and notifies their callback URLs of changed matches.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of the webhook requests.
+const (
+	SignatureHeader = "X-Rentals-Signature"
+	EventHeader     = "X-Rentals-Event"
+	EventIDHeader   = "X-Rentals-Event-Id"
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Sign returns the signature header of body sent at timestamp, "t=<unix seconds>,v1=<hex HMAC-SHA256>".
+// The HMAC covers "<unix seconds>.<body>", so a captured request can not be replayed with another time.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	unix := timestamp.Unix()
+	return fmt.Sprintf("t=%d,v1=%s", unix, hex.EncodeToString(mac(secret, unix, body)))
+}
+
+// Verify checks the signature header of a received webhook. Requests signed more than tolerance
+// ago are rejected, a zero tolerance skips the check.
+func Verify(secret, header string, body []byte, tolerance time.Duration) error {
+	var unix int64
+	var signature []byte
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			var err error
+			if unix, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return fmt.Errorf("%w: invalid timestamp", ErrInvalidSignature)
+			}
+		case "v1":
+			var err error
+			if signature, err = hex.DecodeString(value); err != nil {
+				return fmt.Errorf("%w: invalid signature encoding", ErrInvalidSignature)
+			}
+		}
+	}
+	if unix == 0 || signature == nil {
+		return fmt.Errorf("%w: missing timestamp or signature", ErrInvalidSignature)
+	}
+	if tolerance > 0 && time.Since(time.Unix(unix, 0)) > tolerance {
+		return fmt.Errorf("%w: timestamp outside the tolerance", ErrInvalidSignature)
+	}
+	if !hmac.Equal(signature, mac(secret, unix, body)) {
+		return fmt.Errorf("%w: signature mismatch", ErrInvalidSignature)
+	}
+	return nil
+}
+
+func mac(secret string, unix int64, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(strconv.FormatInt(unix, 10)))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
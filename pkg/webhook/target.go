@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateTarget is returned for callback URLs on loopback, private, link-local or other
+// non-public addresses, which the worker must not reach from inside the network.
+var ErrPrivateTarget = errors.New("callback target is not a public address")
+
+// sharedAddressSpace is the carrier-grade NAT range of RFC 6598, not covered by net.IP.IsPrivate.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// CheckCallbackURL accepts absolute http or https URLs whose host is a public IP or a public
+// domain name. Names are not resolved here, the worker checks every address it dials.
+func CheckCallbackURL(rawURL string) error {
+	callbackURL, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if (callbackURL.Scheme != "http" && callbackURL.Scheme != "https") || callbackURL.Hostname() == "" {
+		return errors.New("callback URL must be an absolute http or https URL")
+	}
+	host := strings.TrimSuffix(strings.ToLower(callbackURL.Hostname()), ".")
+	if ip := net.ParseIP(host); ip != nil {
+		if !IsPublicIP(ip) {
+			return fmt.Errorf("%w: %s", ErrPrivateTarget, host)
+		}
+		return nil
+	}
+	// single label names like "db" and the special use domains only resolve inside the network
+	if !strings.Contains(host, ".") || host == "localhost" ||
+		strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".local") ||
+		strings.HasSuffix(host, ".internal") || strings.HasSuffix(host, ".home.arpa") {
+		return fmt.Errorf("%w: %s", ErrPrivateTarget, host)
+	}
+	return nil
+}
+
+// IsPublicIP reports whether ip is a globally routable unicast address.
+func IsPublicIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		if ip4[0] == 0 || sharedAddressSpace.Contains(ip4) {
+			return false
+		}
+	}
+	return ip.IsGlobalUnicast() && !ip.IsPrivate()
+}
+
+// publicOnly is a net.Dialer Control that refuses connections to non-public addresses. It runs
+// after name resolution, so a name that later resolves to a private address is refused too.
+func publicOnly(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !IsPublicIP(ip) {
+		return fmt.Errorf("%w: %s", ErrPrivateTarget, host)
+	}
+	return nil
+}
+
+// newHTTPClient returns the client of the deliveries. It does not use proxies, so control sees
+// the callback addresses, and it does not follow redirects, a 3xx answer is a failed delivery.
+func newHTTPClient(timeout time.Duration, control func(network, address string, c syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: control}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConnsPerHost: 2,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,294 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	apiv1 "github.com/mkermilska/rentals-challenge/api/v1"
+	"github.com/mkermilska/rentals-challenge/pkg/database"
+)
+
+// Defaults of the zero Options fields.
+const (
+	defaultInterval    = time.Minute
+	defaultMaxAttempts = 5
+	defaultMinBackoff  = time.Second
+	defaultMaxBackoff  = time.Minute
+	defaultTimeout     = 10 * time.Second
+	defaultConcurrency = 4
+)
+
+type Options struct {
+	// Interval between two runs of all saved searches.
+	Interval time.Duration
+	// MaxAttempts is the number of deliveries tried per run before the event is left to the next run.
+	MaxAttempts int
+	// MinBackoff is the wait after the first failed delivery, it doubles per attempt up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Timeout of a single delivery, ignored when HTTPClient is set.
+	Timeout time.Duration
+	// HTTPClient replaces the default client, which only connects to public addresses and does
+	// not follow redirects.
+	HTTPClient *http.Client
+	// Concurrency is the number of saved searches checked at once, so a slow callback only holds
+	// back its own search.
+	Concurrency int
+}
+
+// SearchStore is what the worker needs from database.SearchesRepository.
+type SearchStore interface {
+	FindSavedSearches(ctx context.Context, userID int) ([]database.SavedSearch, error)
+	UpdateSavedSearchMatches(ctx context.Context, searchID int, rentalIDs []int) error
+	InsertWebhookDelivery(ctx context.Context, delivery *database.WebhookDelivery) error
+}
+
+// RentalService is what the worker needs from service.RentalService.
+type RentalService interface {
+	GetRentals(ctx context.Context, params database.RentalParams) ([]apiv1.Rental, error)
+}
+
+// Worker periodically re-runs every saved search and posts the rentals that newly match or dropped
+// out to its callback URL. The first run of a search only records its matches. The matches are
+// only updated once the event is delivered, so an undelivered event is sent again, with the changes
+// since, by the next run. Searches are checked by a fixed number of goroutines, each retrying its
+// own delivery.
+type Worker struct {
+	interval    time.Duration
+	concurrency int
+	maxAttempts int
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	httpClient  *http.Client
+	store       SearchStore
+	rentalSvc   RentalService
+	logger      *zap.Logger
+}
+
+func NewWorker(opts Options, store SearchStore, rentalSvc RentalService, logger *zap.Logger) *Worker {
+	w := &Worker{
+		interval:    opts.Interval,
+		concurrency: opts.Concurrency,
+		maxAttempts: opts.MaxAttempts,
+		minBackoff:  opts.MinBackoff,
+		maxBackoff:  opts.MaxBackoff,
+		httpClient:  opts.HTTPClient,
+		store:       store,
+		rentalSvc:   rentalSvc,
+		logger:      logger,
+	}
+	if w.interval <= 0 {
+		w.interval = defaultInterval
+	}
+	if w.concurrency <= 0 {
+		w.concurrency = defaultConcurrency
+	}
+	if w.maxAttempts <= 0 {
+		w.maxAttempts = defaultMaxAttempts
+	}
+	if w.minBackoff <= 0 {
+		w.minBackoff = defaultMinBackoff
+	}
+	if w.maxBackoff <= 0 {
+		w.maxBackoff = defaultMaxBackoff
+	}
+	if w.httpClient == nil {
+		timeout := opts.Timeout
+		if timeout <= 0 {
+			timeout = defaultTimeout
+		}
+		w.httpClient = newHTTPClient(timeout, publicOnly)
+	}
+	return w
+}
+
+// Run checks the saved searches every interval until ctx is done.
+func (w *Worker) Run(ctx context.Context) {
+	w.logger.Info("Starting webhook worker", zap.Duration("interval", w.interval))
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		w.checkSearches(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Worker) checkSearches(ctx context.Context) {
+	searches, err := w.store.FindSavedSearches(ctx, 0)
+	if err != nil {
+		w.logger.Error("Error getting saved searches", zap.Error(err))
+		return
+	}
+
+	queue := make(chan database.SavedSearch)
+	var wg sync.WaitGroup
+	for i := 0; i < w.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for search := range queue {
+				if err := w.checkSearch(ctx, search); err != nil {
+					w.logger.Error("Error checking saved search", zap.Int("searchID", search.ID), zap.Error(err))
+				}
+			}
+		}()
+	}
+	defer wg.Wait()
+	defer close(queue)
+	for _, search := range searches {
+		select {
+		case <-ctx.Done():
+			return
+		case queue <- search:
+		}
+	}
+}
+
+func (w *Worker) checkSearch(ctx context.Context, search database.SavedSearch) error {
+	var params database.RentalParams
+	if err := json.Unmarshal(search.Params, &params); err != nil {
+		return fmt.Errorf("error decoding rental params: %w", err)
+	}
+	rentals, err := w.rentalSvc.GetRentals(ctx, params)
+	if err != nil {
+		return fmt.Errorf("error getting rentals: %w", err)
+	}
+
+	rentalIDs := make([]int, len(rentals))
+	for i, rental := range rentals {
+		rentalIDs[i] = rental.ID
+	}
+	if search.Checked == nil {
+		return w.store.UpdateSavedSearchMatches(ctx, search.ID, rentalIDs)
+	}
+
+	event := diff(search, rentals)
+	if len(event.Added) > 0 || len(event.Removed) > 0 {
+		if err := w.deliver(ctx, search, event); err != nil {
+			return err
+		}
+	}
+	return w.store.UpdateSavedSearchMatches(ctx, search.ID, rentalIDs)
+}
+
+// diff lists the rentals that were not matched by the last run and the ids no longer matched.
+func diff(search database.SavedSearch, rentals []apiv1.Rental) apiv1.SavedSearchEvent {
+	event := apiv1.SavedSearchEvent{
+		Type:          apiv1.EventSavedSearchChanged,
+		SavedSearchID: search.ID,
+		Added:         []apiv1.Rental{},
+		Removed:       []int{},
+	}
+	previous := make(map[int]bool, len(search.MatchedIDs))
+	for _, rentalID := range search.MatchedIDs {
+		previous[int(rentalID)] = true
+	}
+	for _, rental := range rentals {
+		if !previous[rental.ID] {
+			event.Added = append(event.Added, rental)
+		}
+		delete(previous, rental.ID)
+	}
+	for rentalID := range previous {
+		event.Removed = append(event.Removed, rentalID)
+	}
+	sort.Ints(event.Removed)
+	return event
+}
+
+// deliver posts the event until the callback URL accepts it or the attempts run out, logging every attempt.
+func (w *Worker) deliver(ctx context.Context, search database.SavedSearch, event apiv1.SavedSearchEvent) error {
+	eventID := newEventID(search, event)
+	event.ID = eventID
+	event.Created = time.Now().UTC()
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error encoding event: %w", err)
+	}
+
+	backoff := w.minBackoff
+	for attempt := 1; ; attempt++ {
+		delivery := database.WebhookDelivery{SavedSearchID: search.ID, EventID: eventID, Attempt: attempt}
+		delivery.StatusCode, err = w.send(ctx, search, eventID, body)
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+		if logErr := w.store.InsertWebhookDelivery(ctx, &delivery); logErr != nil {
+			w.logger.Error("Error logging webhook delivery", zap.Int("searchID", search.ID), zap.Error(logErr))
+		}
+		if err == nil {
+			w.logger.Debug("Webhook delivered", zap.Int("searchID", search.ID), zap.String("eventID", eventID))
+			return nil
+		}
+		w.logger.Info("Webhook delivery failed", zap.Int("searchID", search.ID), zap.String("eventID", eventID),
+			zap.Int("attempt", attempt), zap.Error(err))
+		if attempt >= w.maxAttempts {
+			return fmt.Errorf("webhook not delivered after %d attempts: %w", attempt, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > w.maxBackoff {
+			backoff = w.maxBackoff
+		}
+	}
+}
+
+// send posts one signed delivery, responses other than 2xx are errors.
+func (w *Worker) send(ctx context.Context, search database.SavedSearch, eventID string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, search.CallbackURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, apiv1.EventSavedSearchChanged)
+	req.Header.Set(EventIDHeader, eventID)
+	req.Header.Set(SignatureHeader, Sign(search.Secret, time.Now(), body))
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("callback answered %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// newEventID derives the id of the event from the search, its last delivered run and the changes,
+// so the runs sending the same changes again after failed deliveries use the same id.
+func newEventID(search database.SavedSearch, event apiv1.SavedSearchEvent) string {
+	added := make([]int, len(event.Added))
+	for i, rental := range event.Added {
+		added[i] = rental.ID
+	}
+	sort.Ints(added)
+	var checked int64
+	if search.Checked != nil {
+		checked = search.Checked.UnixNano()
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%d.%d.%v.%v", search.ID, checked, added, event.Removed)
+	return "evt_" + hex.EncodeToString(h.Sum(nil)[:16])
+}
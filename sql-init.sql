@@ -12,6 +12,12 @@ CREATE TABLE IF NOT EXISTS rentals (
     description text,
     sleeps integer,
     price_per_day bigint,
+    -- optional charges, NULL when the rental does not offer them
+    price_per_week bigint,
+    price_per_month bigint,
+    security_deposit bigint,
+    cleaning_fee bigint,
+    price_per_mile bigint,
     home_city text,
     home_state text,
     home_zip text,
@@ -24,9 +30,172 @@ CREATE TABLE IF NOT EXISTS rentals (
     updated timestamp with time zone,
     lat double precision,
     lng double precision,
-    primary_image_url text
+    primary_image_url text,
+    currency text NOT NULL DEFAULT 'USD',
+    -- maintained from the reviews of the rental, rating_avg is NULL until the first review
+    rating_avg numeric(3,2),
+    rating_count integer NOT NULL DEFAULT 0
 );
 
+-- rental changes written in the same transaction as the rentals row, published by the outbox relay
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_type text NOT NULL,
+    rental_id integer NOT NULL,
+    payload jsonb NOT NULL,
+    -- the rental before the change, for rental.updated
+    previous jsonb,
+    created timestamp with time zone NOT NULL DEFAULT now(),
+    published timestamp with time zone,
+    -- failed publishing attempts, events that can not be published are set aside as failed
+    attempts integer NOT NULL DEFAULT 0,
+    last_error text,
+    failed timestamp with time zone
+);
+
+CREATE INDEX IF NOT EXISTS outbox_unpublished_idx ON outbox (id) WHERE published IS NULL AND failed IS NULL;
+
+-- saved searches are re-run by the webhook worker, matched_ids holds the result of the last run
+CREATE TABLE IF NOT EXISTS saved_searches (
+    id SERIAL PRIMARY KEY,
+    user_id integer NOT NULL,
+    query text NOT NULL,
+    params jsonb NOT NULL,
+    callback_url text NOT NULL,
+    secret text NOT NULL,
+    matched_ids integer[] NOT NULL DEFAULT '{}',
+    checked timestamp with time zone,
+    created timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id SERIAL PRIMARY KEY,
+    saved_search_id integer NOT NULL REFERENCES saved_searches (id) ON DELETE CASCADE,
+    event_id text NOT NULL,
+    attempt integer NOT NULL,
+    status_code integer NOT NULL DEFAULT 0,
+    error text NOT NULL DEFAULT '',
+    created timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_saved_search_id_idx ON webhook_deliveries (saved_search_id, id);
+
+-- pricing rules of a rental on top of its price_per_day, a rental without a row has none
+CREATE TABLE IF NOT EXISTS rental_pricing (
+    rental_id integer PRIMARY KEY REFERENCES rentals (id) ON DELETE CASCADE,
+    weekend_surcharge_percent integer NOT NULL DEFAULT 0 CHECK (weekend_surcharge_percent BETWEEN 0 AND 1000),
+    weekly_discount_percent integer NOT NULL DEFAULT 0 CHECK (weekly_discount_percent BETWEEN 0 AND 100),
+    monthly_discount_percent integer NOT NULL DEFAULT 0 CHECK (monthly_discount_percent BETWEEN 0 AND 100),
+    min_nights integer NOT NULL DEFAULT 1 CHECK (min_nights >= 1),
+    updated timestamp with time zone NOT NULL DEFAULT now()
+);
+
+-- seasonal rates replace price_per_day for the nights from start_date to end_date, both included
+CREATE TABLE IF NOT EXISTS rental_seasons (
+    id SERIAL PRIMARY KEY,
+    rental_id integer NOT NULL REFERENCES rentals (id) ON DELETE CASCADE,
+    name text NOT NULL,
+    start_date date NOT NULL,
+    end_date date NOT NULL CHECK (end_date >= start_date),
+    price_per_day bigint NOT NULL CHECK (price_per_day >= 0),
+    min_nights integer NOT NULL DEFAULT 0 CHECK (min_nights >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS rental_seasons_rental_id_idx ON rental_seasons (rental_id, start_date);
+
+-- gallery of a rental, ordered by position from 0, rentals.primary_image_url is the url of position 0
+CREATE TABLE IF NOT EXISTS rental_images (
+    id SERIAL PRIMARY KEY,
+    rental_id integer NOT NULL REFERENCES rentals (id) ON DELETE CASCADE,
+    url text NOT NULL,
+    position integer NOT NULL CHECK (position >= 0),
+    caption text NOT NULL DEFAULT '',
+    width integer CHECK (width > 0),
+    height integer CHECK (height > 0),
+    -- key of the uploaded original in the blob store, NULL for images linked by url
+    blob_key text,
+    -- [{"name": "w480", "key": "...", "url": "...", "width": 480, "height": 360}, ...]
+    variants jsonb NOT NULL DEFAULT '[]',
+    created timestamp with time zone NOT NULL DEFAULT now(),
+    -- deferred, so positions can be shifted and swapped within a transaction
+    UNIQUE (rental_id, position) DEFERRABLE INITIALLY DEFERRED
+);
+
+-- catalogue of the amenities rentals can offer, codes are used by the amenities filter
+CREATE TABLE IF NOT EXISTS amenities (
+    code text PRIMARY KEY CHECK (code ~ '^[a-z0-9_]+$'),
+    name text NOT NULL,
+    category text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS rental_amenities (
+    rental_id integer NOT NULL REFERENCES rentals (id) ON DELETE CASCADE,
+    amenity_code text NOT NULL REFERENCES amenities (code),
+    PRIMARY KEY (rental_id, amenity_code)
+);
+
+CREATE INDEX IF NOT EXISTS rental_amenities_amenity_code_idx ON rental_amenities (amenity_code);
+
+-- stays booked by users, a booking is completed once its end_date has passed unless it was cancelled
+CREATE TABLE IF NOT EXISTS bookings (
+    id SERIAL PRIMARY KEY,
+    rental_id integer NOT NULL REFERENCES rentals (id) ON DELETE CASCADE,
+    user_id integer NOT NULL,
+    start_date date NOT NULL,
+    end_date date NOT NULL CHECK (end_date > start_date),
+    cancelled timestamp with time zone,
+    created timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS bookings_rental_id_idx ON bookings (rental_id);
+
+-- one review per completed booking, rentals.rating_avg and rating_count are maintained from them
+CREATE TABLE IF NOT EXISTS reviews (
+    id SERIAL PRIMARY KEY,
+    rental_id integer NOT NULL REFERENCES rentals (id) ON DELETE CASCADE,
+    booking_id integer NOT NULL UNIQUE REFERENCES bookings (id),
+    user_id integer NOT NULL,
+    rating integer NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    text text NOT NULL,
+    created timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS reviews_rental_id_idx ON reviews (rental_id, id);
+
+-- amount of each currency worth one USD, prices of rentals are converted with them on request
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    currency text PRIMARY KEY,
+    rate numeric(18,8) NOT NULL CHECK (rate > 0),
+    updated timestamp with time zone NOT NULL DEFAULT now()
+);
+
+INSERT INTO "exchange_rates"("currency", "rate")
+VALUES
+    ('USD', 1),
+    ('CAD', 1.35),
+    ('EUR', 0.92)
+;
+
+INSERT INTO "amenities"("code", "name", "category")
+VALUES
+    ('ac', 'Air conditioning', 'comfort'),
+    ('heater', 'Heater', 'comfort'),
+    ('generator', 'Generator', 'power'),
+    ('solar', 'Solar panels', 'power'),
+    ('inverter', 'Inverter', 'power'),
+    ('shower', 'Shower', 'bathroom'),
+    ('toilet', 'Toilet', 'bathroom'),
+    ('kitchen', 'Kitchen', 'kitchen'),
+    ('refrigerator', 'Refrigerator', 'kitchen'),
+    ('microwave', 'Microwave', 'kitchen'),
+    ('wifi', 'Wi-Fi', 'entertainment'),
+    ('tv', 'TV', 'entertainment'),
+    ('bike_rack', 'Bike rack', 'outdoor'),
+    ('awning', 'Awning', 'outdoor'),
+    ('pet_friendly', 'Pet friendly', 'rules'),
+    ('smoking_allowed', 'Smoking allowed', 'rules')
+;
+
 INSERT INTO "users"("id", "first_name", "last_name")
 VALUES
     (1, 'John', 'Smith'),
@@ -67,4 +236,76 @@ VALUES
 (2, E'Coya | Van-gelina Jolie',E'camper-van',E'lacus cras molestie nam dapibus ullamcorper massa ultricies bibendum lectus auctor nisi ridiculus ultricies tristique curabitur diam feugiat erat inceptos sapien vivamus parturient sem nibh',2,20000,E'Seattle',E'WA',E'98116',E'US',E'Ford',E'Transit',2019,20,E'2021-11-29 22:42:06.478595+00',E'2021-11-29 22:42:06.478595+00',47.56,-122.39,E'https://res.cloudinary.com/outdoorsy/image/upload/v1582091293/p/rentals/153401/images/kaqt2b6n6sm1xnmvbi5w.jpg'),
 (3, E'sCAMPer X',E'camper-van',E'ac tellus phasellus ultrices nostra eros aenean metus ridiculus adipiscing habitant nulla cubilia tortor rhoncus quisque sem ultrices varius massa mollis congue praesent nam ante',4,17500,E'Atlanta',E'GA',E'30310',E'US',E'Ram',E'Promaster',2020,19,E'2021-11-29 22:42:06.478595+00',E'2021-11-29 22:42:06.478595+00',33.73,-84.41,E'https://res.cloudinary.com/outdoorsy/image/upload/v1589910541/p/rentals/156152/images/jvyvtqoeljadoizjjzag.jpg'),
 (4, E'2015 Dodge Sprinter Van',E'camper-van',E'pretium non litora lobortis pharetra elit sociosqu platea nostra interdum odio vestibulum tincidunt mi blandit convallis pellentesque tempor viverra fermentum ultricies nunc egestas id arcu',2,17000,E'Silverthorne',E'CO',E'80498',E'US',E'Dodge',E'Sprinter Van',2015,20,E'2021-11-29 22:42:06.478595+00',E'2021-11-29 22:42:06.478595+00',39.62,-106.09,E'https://res.cloudinary.com/outdoorsy/image/upload/v1588550855/p/rentals/162781/images/az0xp8wbdto4pjzlkyh3.jpg'),
-(5, E'The New Adventures of Pearl - 2014 Nissan NV2500 High Top',E'camper-van',E'malesuada eget conubia porta sollicitudin urna ad aenean lacus vulputate parturient vulputate suspendisse sit parturient ante mauris maecenas dignissim donec eget adipiscing dui luctus eget',2,18900,E'Denver',E'CO',E'80222',E'US',E'Nissan',E'NV2500',2014,20,E'2021-11-29 22:42:06.478595+00',E'2021-11-29 22:42:06.478595+00',39.67,-104.92,E'https://res.cloudinary.com/outdoorsy/image/upload/v1590500837/undefined/rentals/164961/images/t3nkxdl0ua8g6gp1idcm.jpg');
\ No newline at end of file
+(5, E'The New Adventures of Pearl - 2014 Nissan NV2500 High Top',E'camper-van',E'malesuada eget conubia porta sollicitudin urna ad aenean lacus vulputate parturient vulputate suspendisse sit parturient ante mauris maecenas dignissim donec eget adipiscing dui luctus eget',2,18900,E'Denver',E'CO',E'80222',E'US',E'Nissan',E'NV2500',2014,20,E'2021-11-29 22:42:06.478595+00',E'2021-11-29 22:42:06.478595+00',39.67,-104.92,E'https://res.cloudinary.com/outdoorsy/image/upload/v1590500837/undefined/rentals/164961/images/t3nkxdl0ua8g6gp1idcm.jpg');
+
+UPDATE "rentals" SET "price_per_week" = "price_per_day" * 6, "security_deposit" = 50000, "cleaning_fee" = 7500
+WHERE "id" IN (1, 2, 3, 4, 5);
+
+UPDATE "rentals" SET "price_per_month" = "price_per_day" * 24, "price_per_mile" = 35
+WHERE "id" IN (4, 5, 6, 7);
+
+INSERT INTO "rental_images"("rental_id", "url", "position")
+SELECT "id", "primary_image_url", 0 FROM "rentals" WHERE "primary_image_url" <> '';
+
+INSERT INTO "rental_amenities"("rental_id", "amenity_code")
+VALUES
+    (1, 'kitchen'), (1, 'pet_friendly'), (1, 'awning'),
+    (2, 'ac'), (2, 'pet_friendly'), (2, 'shower'), (2, 'toilet'),
+    (3, 'kitchen'), (3, 'refrigerator'),
+    (4, 'ac'), (4, 'generator'), (4, 'shower'),
+    (9, 'ac'),
+    (10, 'kitchen'), (10, 'pet_friendly')
+;
+
+INSERT INTO "bookings"("rental_id", "user_id", "start_date", "end_date", "cancelled")
+VALUES
+    (1, 2, '2023-06-02', '2023-06-09', NULL),
+    (1, 3, '2023-08-11', '2023-08-14', NULL),
+    (2, 1, '2023-07-01', '2023-07-05', NULL),
+    (3, 2, '2023-09-15', '2023-09-20', NULL),
+    (2, 1, '2030-07-01', '2030-07-08', NULL),
+    (4, 1, '2023-05-01', '2023-05-03', '2023-04-20 10:00:00+00'),
+    (4, 2, '2023-10-01', '2023-10-05', NULL)
+;
+
+INSERT INTO "reviews"("rental_id", "booking_id", "user_id", "rating", "text")
+VALUES
+    (1, 1, 2, 5, 'Spotless van and a great host, the pop-top was perfect for the coast.'),
+    (1, 2, 3, 4, 'Comfortable and easy to drive, pickup took a while.'),
+    (2, 3, 1, 3, 'Fine for a weekend, the fridge was not working.'),
+    (4, 7, 2, 2, 'Smaller than the pictures and the AC was weak.')
+;
+
+UPDATE "rentals" SET "rating_avg" = "ratings"."avg", "rating_count" = "ratings"."count"
+FROM (SELECT "rental_id", round(avg("rating"), 2) AS "avg", count(*) AS "count" FROM "reviews" GROUP BY "rental_id") AS "ratings"
+WHERE "rentals"."id" = "ratings"."rental_id";
+
+-- every change of a rental is announced on the rental_changes channel, API replicas LISTEN to it
+-- to drop their cached copies and to push the change to their subscribers
+CREATE OR REPLACE FUNCTION notify_rental_change() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('rental_changes', json_build_object(
+        'op', lower(TG_OP),
+        'id', CASE WHEN TG_OP = 'DELETE' THEN OLD.id ELSE NEW.id END
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS rentals_notify_change ON rentals;
+CREATE TRIGGER rentals_notify_change
+    AFTER INSERT OR UPDATE OR DELETE ON rentals
+    FOR EACH ROW EXECUTE FUNCTION notify_rental_change();
+
+-- the prices of any rental in another currency follow the exchange rates
+CREATE OR REPLACE FUNCTION notify_rates_change() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('rental_changes', json_build_object('op', 'resync')::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS exchange_rates_notify_change ON exchange_rates;
+CREATE TRIGGER exchange_rates_notify_change
+    AFTER INSERT OR UPDATE OR DELETE ON exchange_rates
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_rates_change();